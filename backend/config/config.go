@@ -3,16 +3,28 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 )
 
 type Config struct {
-	Environment   string
-	Port          string
-	DatabaseURL   string
-	RedisURL      string
-	JWTSecret     string
-	UploadPath    string
-	PrometheusURL string
+	Environment       string
+	Port              string
+	DatabaseURL       string
+	RedisURL          string
+	JWTSecret         string
+	SecretKey         string // 凭据加密主密钥，必须单独设置
+	OldSecretKeys     string // 逗号分隔的旧主密钥，密钥轮换期间用于解密
+	UploadPath        string
+	PrometheusURL     string
+	AlertmanagerURL   string // 为空时维护窗口不创建静默
+	PollInterval      int // 秒
+	PollWorkers       int
+	InventoryInterval int // 秒，0 表示不定时采集
+	InterfaceInterval int // 秒，0 表示不定时采集
+	TopologyInterval  int // 秒，0 表示不定时采集
+	HealthInterval    int // 秒，0 表示不定时检查
+	HealthWorkers     int
+	BackupInterval    int // 秒，0 表示不定时备份设备配置
 }
 
 func Load() *Config {
@@ -41,13 +53,24 @@ func Load() *Config {
 	}
 	
 	return &Config{
-		Environment:   getEnv("ENVIRONMENT", "development"),
-		Port:          getEnv("SERVER_PORT", "8080"),
-		DatabaseURL:   getEnv("DATABASE_URL", databaseURL),
-		RedisURL:      getEnv("REDIS_URL", redisURL),
-		JWTSecret:     getEnv("JWT_SECRET", "your-secret-key"),
-		UploadPath:    getEnv("UPLOAD_PATH", "./uploads"),
-		PrometheusURL: getEnv("PROMETHEUS_URL", "http://localhost:8428"),
+		Environment:       getEnv("ENVIRONMENT", "development"),
+		Port:              getEnv("SERVER_PORT", "8080"),
+		DatabaseURL:       getEnv("DATABASE_URL", databaseURL),
+		RedisURL:          getEnv("REDIS_URL", redisURL),
+		JWTSecret:         getEnv("JWT_SECRET", "your-secret-key"),
+		SecretKey:         getEnv("SECRET_KEY", ""),
+		OldSecretKeys:     getEnv("SECRET_KEYS_OLD", ""),
+		UploadPath:        getEnv("UPLOAD_PATH", "./uploads"),
+		PrometheusURL:     getEnv("PROMETHEUS_URL", "http://localhost:8428"),
+		AlertmanagerURL:   getEnv("ALERTMANAGER_URL", ""),
+		PollInterval:      getEnvInt("POLL_INTERVAL", 60),
+		PollWorkers:       getEnvInt("POLL_WORKERS", 10),
+		InventoryInterval: getEnvInt("INVENTORY_INTERVAL", 86400),
+		InterfaceInterval: getEnvInt("INTERFACE_INTERVAL", 300),
+		TopologyInterval:  getEnvInt("TOPOLOGY_INTERVAL", 600),
+		HealthInterval:    getEnvInt("HEALTH_CHECK_INTERVAL", 60),
+		HealthWorkers:     getEnvInt("HEALTH_CHECK_WORKERS", 20),
+		BackupInterval:    getEnvInt("CONFIG_BACKUP_INTERVAL", 86400),
 	}
 }
 
@@ -57,3 +80,12 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
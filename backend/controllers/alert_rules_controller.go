@@ -628,6 +628,52 @@ func (c *AlertRulesController) GetDeviceGroupDevices(ctx *gin.Context) {
 	utils.SuccessResponse(ctx, "获取分组设备成功", devices)
 }
 
+// PreviewDeviceGroup 预览选择器匹配的设备
+// @Summary 预览设备分组选择器
+// @Description 返回选择器当前匹配的设备，不保存分组
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param request body models.PreviewDeviceGroupRequest true "选择器"
+// @Success 200 {object} utils.Response{data=models.DeviceGroupPreview}
+// @Router /api/v1/device-groups/preview [post]
+func (c *AlertRulesController) PreviewDeviceGroup(ctx *gin.Context) {
+	var req models.PreviewDeviceGroupRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(ctx, http.StatusBadRequest, "请求参数错误", err)
+		return
+	}
+
+	preview, err := c.alertRulesService.PreviewDeviceGroup(req.Selector)
+	if err != nil {
+		utils.ErrorResponse(ctx, http.StatusBadRequest, "预览设备分组失败", err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, "预览设备分组成功", preview)
+}
+
+// RefreshDeviceGroup 按选择器重新计算分组成员
+// @Summary 重新计算设备分组成员
+// @Description 按选择器重新计算分组成员，手动添加的设备不受影响
+// @Tags alert-rules
+// @Accept json
+// @Produce json
+// @Param id path string true "分组ID"
+// @Success 200 {object} utils.Response{data=models.DeviceGroupSyncResult}
+// @Router /api/v1/device-groups/{id}/refresh [post]
+func (c *AlertRulesController) RefreshDeviceGroup(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	result, err := c.alertRulesService.RefreshDeviceGroup(id)
+	if err != nil {
+		utils.ErrorResponse(ctx, http.StatusInternalServerError, "重新计算设备分组成员失败", err)
+		return
+	}
+
+	utils.SuccessResponse(ctx, "重新计算设备分组成员成功", result)
+}
+
 // BatchCreateDeviceGroups 批量创建设备分组
 // @Summary 批量创建设备分组
 // @Description 批量创建设备分组
@@ -776,7 +822,7 @@ func (c *AlertRulesController) GetSyncHistory(ctx *gin.Context) {
 
 // DiscoverDevices 设备自动发现
 // @Summary 设备自动发现
-// @Description 从VictoriaMetrics扫描并发现新设备
+// @Description 汇总 SNMP 发现任务登记的新设备、更新设备和离线设备，force 时重新执行已结束的发现任务
 // @Tags alert-rules
 // @Accept json
 // @Produce json
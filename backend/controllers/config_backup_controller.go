@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mib-platform/services"
+)
+
+type ConfigBackupController struct {
+	configBackupService *services.ConfigBackupService
+}
+
+func NewConfigBackupController(configBackupService *services.ConfigBackupService) *ConfigBackupController {
+	return &ConfigBackupController{
+		configBackupService: configBackupService,
+	}
+}
+
+// GetProfiles 获取内置的厂商备份配置
+func (c *ConfigBackupController) GetProfiles(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": c.configBackupService.GetProfiles()})
+}
+
+// BackupDevice 立即备份设备运行配置
+func (c *ConfigBackupController) BackupDevice(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	result, err := c.configBackupService.BackupDevice(uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+		case errors.Is(err, services.ErrConfigBackupNotConfigured):
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// GetBackups 获取设备的配置备份版本列表
+func (c *ConfigBackupController) GetBackups(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	backups, total, err := c.configBackupService.GetBackups(uint(id), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  backups,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetBackup 获取指定版本的配置，version 为 latest 时返回最新版本，format=text 时直接输出配置文本
+func (c *ConfigBackupController) GetBackup(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	version := 0
+	if v := ctx.Param("version"); v != "latest" {
+		if version, err = strconv.Atoi(v); err != nil || version < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+			return
+		}
+	}
+
+	backup, err := c.configBackupService.GetBackup(uint(id), version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Config backup not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if ctx.Query("format") == "text" {
+		ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(backup.Content))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": backup})
+}
+
+// DiffBackups 比较两个配置版本，from/to 省略时比较最新版本与上一个版本，format=text 时直接输出 unified diff
+func (c *ConfigBackupController) DiffBackups(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	from, errFrom := strconv.Atoi(ctx.DefaultQuery("from", "0"))
+	to, errTo := strconv.Atoi(ctx.DefaultQuery("to", "0"))
+	if errFrom != nil || errTo != nil || from < 0 || to < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	diff, err := c.configBackupService.DiffBackups(uint(id), from, to)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Config backup not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if ctx.Query("format") == "text" {
+		ctx.Data(http.StatusOK, "text/x-diff; charset=utf-8", []byte(diff.Diff))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
+// GetDeviceEvents 获取设备的配置变更和备份失败事件
+func (c *ConfigBackupController) GetDeviceEvents(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	c.listEvents(ctx, uint(id))
+}
+
+// GetEvents 获取全部配置备份事件，可按 device_id 和 type 过滤
+func (c *ConfigBackupController) GetEvents(ctx *gin.Context) {
+	deviceID, _ := strconv.ParseUint(ctx.Query("device_id"), 10, 32)
+	c.listEvents(ctx, uint(deviceID))
+}
+
+func (c *ConfigBackupController) listEvents(ctx *gin.Context, deviceID uint) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+
+	events, total, err := c.configBackupService.GetEvents(deviceID, ctx.Query("type"), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  events,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
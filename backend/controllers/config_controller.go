@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -206,14 +208,18 @@ func (c *ConfigController) UpdateConfig(ctx *gin.Context) {
 		return
 	}
 
-	var updates models.Config
-	if err := ctx.ShouldBindJSON(&updates); err != nil {
+	var req models.UpdateConfigRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	config, err := c.service.UpdateConfig(uint(id), &updates)
+	config, err := c.service.UpdateConfig(uint(id), &req)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Configuration not found"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -299,6 +305,101 @@ func (c *ConfigController) GetConfigVersions(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"data": versions})
 }
 
+// GetConfigVersion 获取指定版本，revision 为 latest 时返回最新版本，format=text 时直接输出配置文本
+func (c *ConfigController) GetConfigVersion(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid config ID"})
+		return
+	}
+	revision := 0
+	if v := ctx.Param("revision"); v != "latest" {
+		if revision, err = strconv.Atoi(v); err != nil || revision < 1 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+			return
+		}
+	}
+
+	version, err := c.service.GetConfigVersion(uint(id), revision)
+	if err != nil {
+		configVersionError(ctx, err)
+		return
+	}
+
+	if ctx.Query("format") == "text" {
+		ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(version.Content))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": version})
+}
+
+// DiffConfigVersions 比较两个版本，from/to 省略时比较最新版本与上一个版本，format=text 时直接输出 unified diff
+func (c *ConfigController) DiffConfigVersions(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid config ID"})
+		return
+	}
+	from, errFrom := strconv.Atoi(ctx.DefaultQuery("from", "0"))
+	to, errTo := strconv.Atoi(ctx.DefaultQuery("to", "0"))
+	if errFrom != nil || errTo != nil || from < 0 || to < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	diff, err := c.service.DiffConfigVersions(uint(id), from, to)
+	if err != nil {
+		configVersionError(ctx, err)
+		return
+	}
+
+	if ctx.Query("format") == "text" {
+		ctx.Data(http.StatusOK, "text/x-diff; charset=utf-8", []byte(diff.Diff))
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
+// RollbackConfig 把配置恢复为指定版本的内容，回滚本身记录为新版本
+func (c *ConfigController) RollbackConfig(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid config ID"})
+		return
+	}
+	revision, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil || revision < 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	var req models.ConfigRollbackRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	version, err := c.service.RollbackConfig(uint(id), revision, &req)
+	if err != nil {
+		configVersionError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": version})
+}
+
+func configVersionError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Config version not found"})
+	case errors.Is(err, services.ErrConfigUnchanged):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
 func (c *ConfigController) CompareConfigs(ctx *gin.Context) {
 	var req struct {
 		Config1 string `json:"config1" binding:"required"`
@@ -319,3 +420,37 @@ func (c *ConfigController) CompareConfigs(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"data": diff})
 }
+
+// ExportZabbixTemplate 把选中的 OID 导出为 Zabbix 模板，download=true 时直接下载模板文件
+func (c *ConfigController) ExportZabbixTemplate(ctx *gin.Context) {
+	var req services.ZabbixExportRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.SelectedOIDs) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "selected_oids is required"})
+		return
+	}
+
+	result, err := c.service.ExportZabbixTemplate(req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	writeZabbixExport(ctx, result)
+}
+
+func writeZabbixExport(ctx *gin.Context, result *services.ZabbixExportResult) {
+	if download, _ := strconv.ParseBool(ctx.Query("download")); !download {
+		ctx.JSON(http.StatusOK, gin.H{"data": result})
+		return
+	}
+
+	contentType := "application/x-yaml"
+	if result.Format == "xml" {
+		contentType = "application/xml"
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", result.Filename))
+	ctx.Data(http.StatusOK, contentType, []byte(result.Content))
+}
@@ -1,8 +1,15 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -149,9 +156,288 @@ func (c *DeviceController) CreateDeviceTemplate(ctx *gin.Context) {
 	}
 
 	if err := c.service.CreateDeviceTemplate(&template); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		templateError(ctx, err)
 		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{"data": template})
 }
+
+// GetDeviceTemplate 获取模板以及合并父模板后的生效 OID 和配置
+func (c *DeviceController) GetDeviceTemplate(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	template, err := c.service.GetDeviceTemplate(uint(id))
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": template})
+}
+
+// UpdateDeviceTemplate 修改模板并生成新版本；apply=true 时随后为绑定的设备重新生成采集配置
+func (c *DeviceController) UpdateDeviceTemplate(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	var updates models.UpdateDeviceTemplateRequest
+	if err := ctx.ShouldBindJSON(&updates); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := c.service.UpdateDeviceTemplate(uint(id), &updates)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+
+	response := gin.H{"data": template}
+	if apply, _ := strconv.ParseBool(ctx.Query("apply")); apply {
+		req := &models.ApplyDeviceTemplateRequest{}
+		if types := ctx.Query("config_types"); types != "" {
+			req.ConfigTypes = strings.Split(types, ",")
+		}
+		result, err := c.service.ApplyDeviceTemplate(uint(id), req)
+		if err != nil {
+			templateError(ctx, err)
+			return
+		}
+		response["apply"] = result
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetDeviceTemplateVersions 获取模板的版本历史
+func (c *DeviceController) GetDeviceTemplateVersions(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	versions, err := c.service.GetDeviceTemplateVersions(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// GetDeviceTemplateUsage 按版本列出使用该模板的设备
+func (c *DeviceController) GetDeviceTemplateUsage(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	usage, err := c.service.GetDeviceTemplateUsage(uint(id))
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": usage})
+}
+
+// ApplyDeviceTemplate 为绑定该模板及其子模板的全部设备重新生成采集配置
+func (c *DeviceController) ApplyDeviceTemplate(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	var req models.ApplyDeviceTemplateRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := c.service.ApplyDeviceTemplate(uint(id), &req)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// ExportDeviceTemplateZabbix 把模板导出为 Zabbix 模板，download=true 时直接下载模板文件
+func (c *DeviceController) ExportDeviceTemplateZabbix(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+		return
+	}
+
+	var req services.ZabbixExportRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if format := ctx.Query("format"); format != "" {
+		req.Format = format
+	}
+
+	result, err := c.service.ExportDeviceTemplateZabbix(uint(id), &req)
+	if err != nil {
+		templateError(ctx, err)
+		return
+	}
+	writeZabbixExport(ctx, result)
+}
+
+func templateError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+	case errors.Is(err, services.ErrInvalidTemplate):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// ImportDevices 批量导入设备：上传 file 字段或直接提交请求体
+// 参数：format=csv|json|yaml（默认按文件扩展名或 Content-Type 判断）、dry_run、update_existing、mapping（JSON 对象，源列名到字段名）
+func (c *DeviceController) ImportDevices(ctx *gin.Context) {
+	opts := models.DeviceImportOptions{
+		Format: ctx.Query("format"),
+	}
+	opts.DryRun, _ = strconv.ParseBool(ctx.DefaultQuery("dry_run", "false"))
+	opts.UpdateExisting, _ = strconv.ParseBool(ctx.DefaultQuery("update_existing", "true"))
+
+	var data []byte
+	var filename string
+	if file, header, err := ctx.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		filename = header.Filename
+		if data, err = io.ReadAll(file); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if opts.Format == "" {
+			opts.Format = ctx.PostForm("format")
+		}
+	} else {
+		if data, err = io.ReadAll(ctx.Request.Body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if len(data) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Import content is empty"})
+		return
+	}
+
+	mapping := ctx.Query("mapping")
+	if mapping == "" && filename != "" {
+		mapping = ctx.PostForm("mapping")
+	}
+	if mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping: " + err.Error()})
+			return
+		}
+	}
+
+	if opts.Format == "" {
+		switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), ".")); {
+		case ext != "":
+			opts.Format = ext
+		case strings.Contains(ctx.ContentType(), "json"):
+			opts.Format = "json"
+		case strings.Contains(ctx.ContentType(), "yaml"):
+			opts.Format = "yaml"
+		default:
+			opts.Format = "csv"
+		}
+	}
+
+	result, err := c.service.ImportDevices(data, &opts)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// ExportDevices 导出设备清单：format=csv|json|yaml，redact=false 时包含明文密钥
+func (c *DeviceController) ExportDevices(ctx *gin.Context) {
+	format := strings.ToLower(ctx.DefaultQuery("format", "csv"))
+	redact, _ := strconv.ParseBool(ctx.DefaultQuery("redact", "true"))
+
+	data, err := c.service.ExportDevices(format, redact)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case "json":
+		contentType = "application/json"
+	case "yaml", "yml":
+		contentType = "application/x-yaml"
+	}
+	filename := fmt.Sprintf("devices_%s.%s", time.Now().Format("20060102_150405"), format)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+func (c *DeviceController) GetCredentialProfiles(ctx *gin.Context) {
+	profiles, err := c.service.GetCredentialProfiles()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": profiles})
+}
+
+func (c *DeviceController) CreateCredentialProfile(ctx *gin.Context) {
+	var profile models.SNMPCredentialProfile
+	if err := ctx.ShouldBindJSON(&profile); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.CreateCredentialProfile(&profile); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": profile})
+}
+
+func (c *DeviceController) DeleteCredentialProfile(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile ID"})
+		return
+	}
+
+	if err := c.service.DeleteCredentialProfile(uint(id)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Credential profile deleted successfully"})
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/services"
+)
+
+type FingerprintController struct {
+	db      *gorm.DB
+	redis   *redis.Client
+	service *services.FingerprintService
+}
+
+func NewFingerprintController(db *gorm.DB, redis *redis.Client) *FingerprintController {
+	return &FingerprintController{
+		db:      db,
+		redis:   redis,
+		service: services.NewFingerprintService(db, redis),
+	}
+}
+
+// GetFingerprints 获取指纹规则列表
+func (c *FingerprintController) GetFingerprints(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+	vendor := ctx.Query("vendor")
+
+	fingerprints, total, err := c.service.GetFingerprints(page, limit, vendor)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  fingerprints,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (c *FingerprintController) GetFingerprint(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fingerprint ID"})
+		return
+	}
+
+	fingerprint, err := c.service.GetFingerprint(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Fingerprint not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": fingerprint})
+}
+
+func (c *FingerprintController) CreateFingerprint(ctx *gin.Context) {
+	var fingerprint models.DeviceFingerprint
+	if err := ctx.ShouldBindJSON(&fingerprint); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := c.service.CreateFingerprint(&fingerprint); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": fingerprint})
+}
+
+func (c *FingerprintController) UpdateFingerprint(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fingerprint ID"})
+		return
+	}
+
+	var updates models.DeviceFingerprint
+	if err := ctx.ShouldBindJSON(&updates); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fingerprint, err := c.service.UpdateFingerprint(uint(id), &updates)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Fingerprint not found"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": fingerprint})
+}
+
+func (c *FingerprintController) DeleteFingerprint(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fingerprint ID"})
+		return
+	}
+
+	if err := c.service.DeleteFingerprint(uint(id)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Fingerprint deleted successfully"})
+}
+
+// ImportFingerprints 导入指纹规则，请求体为 JSON 或 YAML（Content-Type 含 yaml 时）
+func (c *FingerprintController) ImportFingerprints(ctx *gin.Context) {
+	var req models.ImportFingerprintsRequest
+	var err error
+	if strings.Contains(ctx.ContentType(), "yaml") {
+		err = ctx.ShouldBindYAML(&req)
+	} else {
+		err = ctx.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := c.service.ImportFingerprints(&req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// ExportFingerprints 导出指纹规则，格式与导入一致：?format=json|yaml
+func (c *FingerprintController) ExportFingerprints(ctx *gin.Context) {
+	entries, err := c.service.ExportFingerprints()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	export := models.ImportFingerprintsRequest{Fingerprints: entries}
+	if ctx.DefaultQuery("format", "json") == "yaml" {
+		ctx.Header("Content-Disposition", "attachment; filename=fingerprints.yaml")
+		ctx.YAML(http.StatusOK, export)
+		return
+	}
+	ctx.JSON(http.StatusOK, export)
+}
+
+// LoadBuiltinFingerprints 写入内置指纹规则
+func (c *FingerprintController) LoadBuiltinFingerprints(ctx *gin.Context) {
+	result, err := c.service.LoadBuiltinFingerprints()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// Classify 按给定的 sysObjectID 和 sysDescr 试算识别结果
+func (c *FingerprintController) Classify(ctx *gin.Context) {
+	var req models.ClassifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	match, err := c.service.Classify(req.SysObjectID, req.SysDescr)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": match})
+}
+
+// ClassifyDevice 读取设备系统信息并自动填写厂商、型号和模板：?force=true 覆盖已有值
+func (c *FingerprintController) ClassifyDevice(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	force, _ := strconv.ParseBool(ctx.DefaultQuery("force", "false"))
+
+	device, match, err := c.service.ClassifyDevice(uint(id), force)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  device,
+		"match": match,
+	})
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mib-platform/services"
+)
+
+type HealthController struct {
+	healthService *services.HealthService
+}
+
+func NewHealthController(healthService *services.HealthService) *HealthController {
+	return &HealthController{
+		healthService: healthService,
+	}
+}
+
+// GetStatus 获取健康检查器状态
+func (c *HealthController) GetStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": c.healthService.GetStatus()})
+}
+
+// RunCheck 立即在后台检查所有设备
+func (c *HealthController) RunCheck(ctx *gin.Context) {
+	if err := c.healthService.TriggerCheck(); err != nil {
+		if errors.Is(err, services.ErrHealthCheckRunning) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "Health check started"})
+}
+
+// GetDeviceStatusEvents 获取设备状态变化历史
+func (c *HealthController) GetDeviceStatusEvents(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	c.listEvents(ctx, uint(id))
+}
+
+// GetStatusEvents 获取全部设备状态变化历史，可按 device_id 过滤
+func (c *HealthController) GetStatusEvents(ctx *gin.Context) {
+	deviceID, _ := strconv.ParseUint(ctx.Query("device_id"), 10, 32)
+	c.listEvents(ctx, uint(deviceID))
+}
+
+// GetDeviceAvailability 获取设备可用性：?from=&to=（RFC3339）或 ?period=7d，默认最近 24 小时
+func (c *HealthController) GetDeviceAvailability(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	from, to, err := parseTimeRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	availability, err := c.healthService.GetAvailability(uint(id), from, to)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": availability})
+}
+
+// GetAvailabilityReport 获取所有设备的可用性报表，可用性低的在前
+func (c *HealthController) GetAvailabilityReport(ctx *gin.Context) {
+	from, to, err := parseTimeRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := c.healthService.GetAvailabilityReport(from, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  report,
+		"total": len(report),
+		"from":  from,
+		"to":    to,
+	})
+}
+
+func (c *HealthController) listEvents(ctx *gin.Context, deviceID uint) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+
+	includeSuppressed := ctx.Query("include_suppressed") == "true"
+
+	events, total, err := c.healthService.GetStatusEvents(deviceID, includeSuppressed, page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  events,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// parseTimeRange 解析 from/to（RFC3339）或 period（如 24h、7d），period 以 to 为终点
+func parseTimeRange(ctx *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now()
+	if v := ctx.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+		}
+		to = t
+	}
+
+	if v := ctx.Query("from"); v != "" {
+		from, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+		}
+		return from, to, nil
+	}
+
+	period := 24 * time.Hour
+	if v := ctx.Query("period"); v != "" {
+		var err error
+		if days := strings.TrimSuffix(v, "d"); days != v {
+			var n int
+			n, err = strconv.Atoi(days)
+			period = time.Duration(n) * 24 * time.Hour
+		} else {
+			period, err = time.ParseDuration(v)
+		}
+		if err != nil || period <= 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period: %s", v)
+		}
+	}
+	return to.Add(-period), to, nil
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/services"
+)
+
+type InterfaceController struct {
+	interfaceService *services.InterfaceService
+}
+
+func NewInterfaceController(interfaceService *services.InterfaceService) *InterfaceController {
+	return &InterfaceController{
+		interfaceService: interfaceService,
+	}
+}
+
+// GetDeviceInterfaces 获取设备接口，include_removed=true 时包含已消失的接口
+func (c *InterfaceController) GetDeviceInterfaces(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	includeRemoved, _ := strconv.ParseBool(ctx.DefaultQuery("include_removed", "false"))
+
+	interfaces, err := c.interfaceService.GetDeviceInterfaces(uint(id), includeRemoved)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": interfaces})
+}
+
+// SyncInterfaces 立即采集设备接口
+func (c *InterfaceController) SyncInterfaces(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	result, err := c.interfaceService.SyncInterfaces(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// GetDeviceInterfaceEvents 获取设备接口变化历史
+func (c *InterfaceController) GetDeviceInterfaceEvents(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	c.listEvents(ctx, uint(id), 0)
+}
+
+// GetInterfaces 跨设备查询接口：/interfaces?tag=uplink&tag=critical&device_id=1&status=down
+func (c *InterfaceController) GetInterfaces(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	deviceID, _ := strconv.ParseUint(ctx.Query("device_id"), 10, 32)
+
+	var tags []string
+	for _, tag := range ctx.QueryArray("tag") {
+		tags = append(tags, strings.Split(tag, ",")...)
+	}
+
+	interfaces, total, err := c.interfaceService.GetInterfaces(uint(deviceID), tags, ctx.Query("status"), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  interfaces,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (c *InterfaceController) GetInterface(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interface ID"})
+		return
+	}
+
+	iface, err := c.interfaceService.GetInterface(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Interface not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": iface})
+}
+
+// UpdateInterfaceTags 设置接口标签
+func (c *InterfaceController) UpdateInterfaceTags(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interface ID"})
+		return
+	}
+
+	var req models.UpdateInterfaceTagsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	iface, err := c.interfaceService.UpdateInterfaceTags(uint(id), req.Tags)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Interface not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": iface})
+}
+
+// GetInterfaceEvents 获取单个接口的变化历史
+func (c *InterfaceController) GetInterfaceEvents(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interface ID"})
+		return
+	}
+	c.listEvents(ctx, 0, uint(id))
+}
+
+func (c *InterfaceController) listEvents(ctx *gin.Context, deviceID, interfaceID uint) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+
+	events, total, err := c.interfaceService.GetInterfaceEvents(deviceID, interfaceID, ctx.Query("type"), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  events,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
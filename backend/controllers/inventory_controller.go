@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mib-platform/services"
+)
+
+type InventoryController struct {
+	inventoryService *services.InventoryService
+}
+
+func NewInventoryController(inventoryService *services.InventoryService) *InventoryController {
+	return &InventoryController{
+		inventoryService: inventoryService,
+	}
+}
+
+// GetInventory 获取设备物理组件：默认返回树，tree=false 返回平铺列表，可按 class 过滤
+func (c *InventoryController) GetInventory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	tree, _ := strconv.ParseBool(ctx.DefaultQuery("tree", "true"))
+
+	items, err := c.inventoryService.GetInventory(uint(id), ctx.Query("class"), tree)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// CollectInventory 立即采集设备库存
+func (c *InventoryController) CollectInventory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	result, err := c.inventoryService.CollectInventory(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// GetDeviceInventoryEvents 获取设备库存变化历史
+func (c *InventoryController) GetDeviceInventoryEvents(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	c.listEvents(ctx, uint(id))
+}
+
+// GetInventoryEvents 获取全部设备的库存变化历史
+func (c *InventoryController) GetInventoryEvents(ctx *gin.Context) {
+	c.listEvents(ctx, 0)
+}
+
+func (c *InventoryController) listEvents(ctx *gin.Context, deviceID uint) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+
+	events, total, err := c.inventoryService.GetInventoryEvents(deviceID, ctx.Query("type"), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  events,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// SearchInventory 按序列号查找组件：/inventory/search?serial=xxx
+func (c *InventoryController) SearchInventory(ctx *gin.Context) {
+	serial := ctx.Query("serial")
+	if serial == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "serial is required"})
+		return
+	}
+
+	items, err := c.inventoryService.SearchBySerial(serial)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": items})
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/services"
+)
+
+type MaintenanceController struct {
+	maintenanceService *services.MaintenanceService
+}
+
+func NewMaintenanceController(maintenanceService *services.MaintenanceService) *MaintenanceController {
+	return &MaintenanceController{
+		maintenanceService: maintenanceService,
+	}
+}
+
+// GetWindows 获取维护窗口列表，active=true 时只返回生效中的窗口
+func (c *MaintenanceController) GetWindows(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	windows, total, err := c.maintenanceService.GetWindows(page, limit, ctx.Query("active") == "true")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  windows,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (c *MaintenanceController) GetWindow(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance window ID"})
+		return
+	}
+
+	window, err := c.maintenanceService.GetWindow(uint(id))
+	if err != nil {
+		c.windowError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": window})
+}
+
+func (c *MaintenanceController) CreateWindow(ctx *gin.Context) {
+	var req models.MaintenanceWindowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := c.maintenanceService.CreateWindow(&req)
+	if err != nil {
+		c.windowError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": window})
+}
+
+func (c *MaintenanceController) UpdateWindow(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance window ID"})
+		return
+	}
+
+	var req models.MaintenanceWindowRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := c.maintenanceService.UpdateWindow(uint(id), &req)
+	if err != nil {
+		c.windowError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": window})
+}
+
+func (c *MaintenanceController) DeleteWindow(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid maintenance window ID"})
+		return
+	}
+
+	if err := c.maintenanceService.DeleteWindow(uint(id)); err != nil {
+		c.windowError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Maintenance window deleted successfully"})
+}
+
+// GetDeviceMaintenance 获取设备生效中和即将开始的维护窗口
+func (c *MaintenanceController) GetDeviceMaintenance(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	windows, err := c.maintenanceService.GetDeviceMaintenance(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": windows})
+}
+
+func (c *MaintenanceController) windowError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Maintenance window not found"})
+	case errors.Is(err, services.ErrInvalidMaintenanceWindow):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"mib-platform/services"
+)
+
+type PollingController struct {
+	pollingService *services.PollingService
+}
+
+func NewPollingController(pollingService *services.PollingService) *PollingController {
+	return &PollingController{
+		pollingService: pollingService,
+	}
+}
+
+// GetStatus 获取轮询调度器状态
+func (c *PollingController) GetStatus(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"data": c.pollingService.GetStatus()})
+}
+
+// GetLatestValues 获取设备最新采集值
+func (c *PollingController) GetLatestValues(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	values, err := c.pollingService.GetLatestValues(uint(id), ctx.Query("oid"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": values})
+}
+
+// GetPollHistory 获取设备轮询历史
+func (c *PollingController) GetPollHistory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	records, total, err := c.pollingService.GetPollHistory(uint(id), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  records,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// PollDevice 立即轮询设备
+func (c *PollingController) PollDevice(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	record, err := c.pollingService.PollDevice(uint(id))
+	if record == nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 轮询失败时仍返回本次记录，错误信息在 record.error 中
+	ctx.JSON(http.StatusOK, gin.H{"data": record})
+}
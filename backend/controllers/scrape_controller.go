@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"mib-platform/services"
+)
+
+type ScrapeController struct {
+	db      *gorm.DB
+	redis   *redis.Client
+	service *services.ScrapeService
+}
+
+func NewScrapeController(db *gorm.DB, redis *redis.Client) *ScrapeController {
+	return &ScrapeController{
+		db:      db,
+		redis:   redis,
+		service: services.NewScrapeService(db, redis),
+	}
+}
+
+// Scrape 兼容 snmp_exporter 的采集接口：/snmp?target=<device>&module=<template>&auth=<auth>
+func (c *ScrapeController) Scrape(ctx *gin.Context) {
+	target := ctx.Query("target")
+	if target == "" {
+		ctx.String(http.StatusBadRequest, "'target' parameter must be specified once")
+		return
+	}
+
+	metrics, err := c.service.Scrape(target, ctx.Query("module"), ctx.Query("auth"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrScrapeTargetNotFound) || errors.Is(err, services.ErrScrapeModuleNotFound) {
+			status = http.StatusBadRequest
+		}
+		ctx.String(status, "An error has occurred while serving metrics:\n\n%s", err.Error())
+		return
+	}
+
+	ctx.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(metrics))
+}
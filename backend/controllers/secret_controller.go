@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"mib-platform/services"
+)
+
+type SecretController struct {
+	secretService *services.SecretService
+}
+
+func NewSecretController(secretService *services.SecretService) *SecretController {
+	return &SecretController{
+		secretService: secretService,
+	}
+}
+
+// GetKeyStatus 获取当前主密钥以及各敏感字段使用的密钥分布
+func (c *SecretController) GetKeyStatus(ctx *gin.Context) {
+	status, err := c.secretService.GetStatus()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// RotateKeys 用当前主密钥重新加密所有敏感字段
+func (c *SecretController) RotateKeys(ctx *gin.Context) {
+	result, err := c.secretService.Rotate()
+	if err != nil {
+		if errors.Is(err, services.ErrSecretRotationRunning) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/services"
+)
+
+type SnapshotController struct {
+	db      *gorm.DB
+	redis   *redis.Client
+	service *services.SnapshotService
+}
+
+func NewSnapshotController(db *gorm.DB, redis *redis.Client) *SnapshotController {
+	return &SnapshotController{
+		db:      db,
+		redis:   redis,
+		service: services.NewSnapshotService(db, redis),
+	}
+}
+
+// CreateSnapshot 采集设备遍历快照
+func (c *SnapshotController) CreateSnapshot(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	var req models.CreateSnapshotRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	snapshot, err := c.service.CreateSnapshot(uint(id), &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": snapshot})
+}
+
+// GetSnapshots 获取设备的快照列表
+func (c *SnapshotController) GetSnapshots(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	snapshots, total, err := c.service.GetSnapshots(uint(id), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  snapshots,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetSnapshot 获取快照及其内容，可用 oid 参数按前缀过滤
+func (c *SnapshotController) GetSnapshot(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot ID"})
+		return
+	}
+
+	snapshot, err := c.service.GetSnapshot(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+		return
+	}
+
+	entries, err := c.service.GetSnapshotEntries(snapshot, ctx.Query("oid"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":    snapshot,
+		"entries": entries,
+	})
+}
+
+// DeleteSnapshot 删除快照
+func (c *SnapshotController) DeleteSnapshot(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid snapshot ID"})
+		return
+	}
+
+	if err := c.service.DeleteSnapshot(uint(id)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Snapshot deleted successfully"})
+}
+
+// DiffSnapshots 比较两个快照：/snapshots/diff?from=<id>&to=<id>&include_volatile=false
+func (c *SnapshotController) DiffSnapshots(ctx *gin.Context) {
+	fromID, err := strconv.ParseUint(ctx.Query("from"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from snapshot ID"})
+		return
+	}
+	toID, err := strconv.ParseUint(ctx.Query("to"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to snapshot ID"})
+		return
+	}
+	includeVolatile, _ := strconv.ParseBool(ctx.DefaultQuery("include_volatile", "false"))
+
+	diff, err := c.service.DiffSnapshots(uint(fromID), uint(toID), includeVolatile)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Snapshot not found"})
+			return
+		}
+		if errors.Is(err, services.ErrSnapshotMismatch) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": diff})
+}
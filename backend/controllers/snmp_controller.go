@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
@@ -30,6 +32,7 @@ func (c *SNMPController) GetSNMPConfig(ctx *gin.Context) {
 		"status": "active",
 		"version": "v1.0.0",
 		"supported_versions": []string{"1", "2c", "3"},
+		"supported_transports": []string{"udp", "udp6", "tcp", "tcp6"},
 		"default_timeout": 5,
 		"default_retries": 3,
 	}
@@ -89,6 +92,72 @@ func (c *SNMPController) SNMPSet(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+func (c *SNMPController) GetSetAudits(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	target := ctx.Query("target")
+	oid := ctx.Query("oid")
+	status := ctx.Query("status")
+
+	audits, total, err := c.service.GetSetAudits(page, limit, target, oid, status)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  audits,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (c *SNMPController) GetSetAudit(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid audit ID"})
+		return
+	}
+
+	audit, err := c.service.GetSetAudit(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Audit record not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": audit})
+}
+
+func (c *SNMPController) RollbackSet(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid audit ID"})
+		return
+	}
+
+	// 请求体可选，不提供凭据时使用设备保存的凭据
+	var req models.SNMPRollbackRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	response, err := c.service.RollbackSet(uint(id), &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Audit record not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
 func (c *SNMPController) TestConnection(ctx *gin.Context) {
 	var req models.SNMPRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -105,6 +174,48 @@ func (c *SNMPController) TestConnection(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"data": result})
 }
 
+// ProbeCredentials 按优先级探测设备可用的 SNMP 凭据
+func (c *SNMPController) ProbeCredentials(ctx *gin.Context) {
+	var req models.SNMPProbeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := c.service.ProbeCredentials(&req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": response})
+}
+
+// DiagnoseV3 SNMPv3 引擎发现与 USM 诊断
+func (c *SNMPController) DiagnoseV3(ctx *gin.Context) {
+	var req models.SNMPV3DiagnosticRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	diagnosis, err := c.service.DiagnoseV3(&req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": diagnosis})
+}
+
 func (c *SNMPController) BulkOperations(ctx *gin.Context) {
 	operationType := ctx.Query("type")
 	if operationType == "" {
@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/services"
+)
+
+type SNMPDiscoveryController struct {
+	db      *gorm.DB
+	redis   *redis.Client
+	service *services.SNMPDiscoveryService
+}
+
+func NewSNMPDiscoveryController(db *gorm.DB, redis *redis.Client) *SNMPDiscoveryController {
+	return &SNMPDiscoveryController{
+		db:      db,
+		redis:   redis,
+		service: services.NewSNMPDiscoveryService(db, redis),
+	}
+}
+
+// GetTasks 获取 SNMP 发现任务列表
+func (c *SNMPDiscoveryController) GetTasks(ctx *gin.Context) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+
+	tasks, total, err := c.service.GetTasks(page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  tasks,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// CreateTask 创建 SNMP 发现任务，start 为 true 时立即执行
+func (c *SNMPDiscoveryController) CreateTask(ctx *gin.Context) {
+	var req models.CreateSNMPDiscoveryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := c.service.CreateTask(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"data": task})
+}
+
+// GetTask 获取任务状态和进度
+func (c *SNMPDiscoveryController) GetTask(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	task, err := c.service.GetTask(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": task})
+}
+
+// StartTask 启动（或重新执行）发现任务
+func (c *SNMPDiscoveryController) StartTask(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	if err := c.service.StartTask(uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Discovery started"})
+}
+
+// GetTaskResults 获取任务的逐 IP 结果，responded=true 只返回有响应的地址
+func (c *SNMPDiscoveryController) GetTaskResults(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	respondedOnly, _ := strconv.ParseBool(ctx.DefaultQuery("responded", "false"))
+
+	results, total, err := c.service.GetTaskResults(uint(id), page, limit, respondedOnly)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  results,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
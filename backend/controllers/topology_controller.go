@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"mib-platform/services"
+)
+
+type TopologyController struct {
+	topologyService *services.TopologyService
+}
+
+func NewTopologyController(topologyService *services.TopologyService) *TopologyController {
+	return &TopologyController{
+		topologyService: topologyService,
+	}
+}
+
+// GetDeviceNeighbors 获取设备最近一次采集到的 LLDP/CDP 邻居
+func (c *TopologyController) GetDeviceNeighbors(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	neighbors, err := c.topologyService.GetDeviceNeighbors(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": neighbors})
+}
+
+// CollectNeighbors 立即采集设备邻居
+func (c *TopologyController) CollectNeighbors(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+
+	result, err := c.topologyService.CollectNeighbors(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Device not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": result})
+}
+
+// GetDeviceLinkEvents 获取设备链路变化历史
+func (c *TopologyController) GetDeviceLinkEvents(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device ID"})
+		return
+	}
+	c.listEvents(ctx, uint(id))
+}
+
+// GetLinkEvents 获取全部链路变化历史，可按 device_id 和 type 过滤
+func (c *TopologyController) GetLinkEvents(ctx *gin.Context) {
+	deviceID, _ := strconv.ParseUint(ctx.Query("device_id"), 10, 32)
+	c.listEvents(ctx, uint(deviceID))
+}
+
+// GetTopology 获取拓扑图，format=dot 时输出 Graphviz DOT，device_id 限定为单台设备的直连邻居
+func (c *TopologyController) GetTopology(ctx *gin.Context) {
+	deviceID, _ := strconv.ParseUint(ctx.Query("device_id"), 10, 32)
+
+	graph, err := c.topologyService.GetTopology(uint(deviceID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch ctx.DefaultQuery("format", "json") {
+	case "json":
+		ctx.JSON(http.StatusOK, gin.H{"data": graph})
+	case "dot":
+		ctx.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(services.RenderTopologyDOT(graph)))
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported format, expected json or dot"})
+	}
+}
+
+func (c *TopologyController) listEvents(ctx *gin.Context, deviceID uint) {
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "50"))
+
+	events, total, err := c.topologyService.GetLinkEvents(deviceID, ctx.Query("type"), page, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":  events,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
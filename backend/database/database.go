@@ -19,16 +19,40 @@ func Initialize(databaseURL string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err := migrateConfigVersionRevisions(db); err != nil {
+		return nil, err
+	}
+
 	// Auto migrate the schema
 	err = db.AutoMigrate(
 		&models.MIB{},
 		&models.OID{},
 		&models.Device{},
 		&models.DeviceTemplate{},
+		&models.DeviceTemplateVersion{},
 		&models.Config{},
 		&models.ConfigTemplate{},
 		&models.ConfigVersion{},
 		&models.SNMPCredential{},
+		&models.SNMPCredentialProfile{},
+		&models.SNMPSetAudit{},
+		&models.PollValue{},
+		&models.PollRecord{},
+		&models.SNMPSnapshot{},
+		&models.InventoryItem{},
+		&models.InventoryEvent{},
+		&models.DeviceInterface{},
+		&models.InterfaceEvent{},
+		&models.DeviceNeighbor{},
+		&models.TopologyLinkEvent{},
+		&models.DeviceConfigBackup{},
+		&models.DeviceConfigEvent{},
+		&models.DeviceStatusEvent{},
+		&models.MaintenanceWindow{},
+		&models.SNMPDiscoveryTask{},
+		&models.SNMPDiscoveryResult{},
+		&models.DiscoveredDevice{},
+		&models.DeviceFingerprint{},
 		&models.Setting{},
 		&models.Host{},
 		&models.HostComponent{},
@@ -43,6 +67,28 @@ func Initialize(databaseURL string) (*gorm.DB, error) {
 	return db, nil
 }
 
+// migrateConfigVersionRevisions 建立 (config_id, revision) 唯一索引之前，按创建顺序为旧的配置版本重新编号，
+// 没有版本号或版本号重复的记录都会被修正
+func migrateConfigVersionRevisions(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.ConfigVersion{}) || migrator.HasIndex(&models.ConfigVersion{}, "idx_config_version_config_revision") {
+		return nil
+	}
+	if !migrator.HasColumn(&models.ConfigVersion{}, "Revision") {
+		if err := migrator.AddColumn(&models.ConfigVersion{}, "Revision"); err != nil {
+			return err
+		}
+	}
+	if migrator.HasIndex(&models.ConfigVersion{}, "idx_config_version_revision") {
+		if err := migrator.DropIndex(&models.ConfigVersion{}, "idx_config_version_revision"); err != nil {
+			return err
+		}
+	}
+	return db.Exec(`UPDATE config_versions AS v SET revision = n.revision
+		FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY config_id ORDER BY revision, id) AS revision FROM config_versions) AS n
+		WHERE v.id = n.id AND v.revision IS DISTINCT FROM n.revision`).Error
+}
+
 func InitializeRedis(redisURL string) *redis.Client {
 	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
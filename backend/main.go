@@ -31,6 +31,13 @@ func main() {
 	// Initialize configuration
 	cfg := config.Load()
 
+	// Initialize credential encryption before any secret is read from the database.
+	// A dedicated SECRET_KEY is required; falling back to a default key would store credentials with a public key.
+	// Data encrypted earlier with the JWT_SECRET fallback stays readable by listing that key in SECRET_KEYS_OLD and rotating.
+	if err := utils.InitSecrets(cfg.SecretKey, cfg.OldSecretKeys); err != nil {
+		log.Fatal("Failed to initialize secret encryption (set a random SECRET_KEY, e.g. openssl rand -base64 32): ", err)
+	}
+
 	// Initialize database
 	db, err := database.Initialize(cfg.DatabaseURL)
 	if err != nil {
@@ -77,6 +84,14 @@ func main() {
 	hostService := services.NewHostService(db, redis)
 	deploymentService := services.NewDeploymentService(db, redis, hostService)
 	configDeploymentService := services.NewConfigDeploymentService(db, redis, hostService)
+	pollingService := services.NewPollingService(db, redis, logger, cfg.PollInterval, cfg.PollWorkers)
+	inventoryService := services.NewInventoryService(db, redis, logger, cfg.InventoryInterval)
+	interfaceService := services.NewInterfaceService(db, redis, logger, cfg.InterfaceInterval)
+	topologyService := services.NewTopologyService(db, redis, logger, cfg.TopologyInterval)
+	healthService := services.NewHealthService(db, redis, logger, cfg.HealthInterval, cfg.HealthWorkers)
+	secretService := services.NewSecretService(db, redis)
+	configBackupService := services.NewConfigBackupService(db, redis, logger, cfg.BackupInterval)
+	maintenanceService := services.NewMaintenanceService(db, redis, logger, cfg.AlertmanagerURL)
 
 	// Initialize controllers
 	mibController := controllers.NewMIBController(db, redis)
@@ -87,6 +102,21 @@ func main() {
 	hostController := controllers.NewHostController(hostService)
 	deploymentController := controllers.NewDeploymentController(deploymentService, hostService)
 	configDeploymentController := controllers.NewConfigDeploymentController(configDeploymentService, hostService)
+	pollingController := controllers.NewPollingController(pollingService)
+	inventoryController := controllers.NewInventoryController(inventoryService)
+	interfaceController := controllers.NewInterfaceController(interfaceService)
+	topologyController := controllers.NewTopologyController(topologyService)
+	healthController := controllers.NewHealthController(healthService)
+	secretController := controllers.NewSecretController(secretService)
+	configBackupController := controllers.NewConfigBackupController(configBackupService)
+	maintenanceController := controllers.NewMaintenanceController(maintenanceService)
+	scrapeController := controllers.NewScrapeController(db, redis)
+	snapshotController := controllers.NewSnapshotController(db, redis)
+	snmpDiscoveryController := controllers.NewSNMPDiscoveryController(db, redis)
+	fingerprintController := controllers.NewFingerprintController(db, redis)
+
+	// snmp_exporter compatible scrape endpoint for Prometheus / vmagent
+	router.GET("/snmp", scrapeController.Scrape)
 
 
 
@@ -119,7 +149,12 @@ func main() {
 			snmp.POST("/get", snmpController.SNMPGet)
 			snmp.POST("/walk", snmpController.SNMPWalk)
 			snmp.POST("/set", snmpController.SNMPSet)
+			snmp.GET("/set/audits", snmpController.GetSetAudits)
+			snmp.GET("/set/audits/:id", snmpController.GetSetAudit)
+			snmp.POST("/set/audits/:id/rollback", snmpController.RollbackSet)
 			snmp.POST("/test", snmpController.TestConnection)
+			snmp.POST("/probe", snmpController.ProbeCredentials)
+			snmp.POST("/v3/diagnose", snmpController.DiagnoseV3)
 			snmp.POST("/bulk", snmpController.BulkOperations)
 		}
 
@@ -136,7 +171,11 @@ func main() {
 			configs.GET("/templates", configController.GetTemplates)
 			configs.POST("/templates", configController.CreateTemplate)
 			configs.GET("/:id/versions", configController.GetConfigVersions)
+			configs.GET("/:id/versions/diff", configController.DiffConfigVersions)
+			configs.GET("/:id/versions/:revision", configController.GetConfigVersion)
+			configs.POST("/:id/versions/:revision/rollback", configController.RollbackConfig)
 			configs.POST("/diff", configController.CompareConfigs)
+			configs.POST("/zabbix", configController.ExportZabbixTemplate)
 			// 新增的 API 端点
 			configs.POST("/save-to-file", configController.SaveConfigToFile)
 			configs.POST("/merge-to-file", configController.MergeConfigToFile)
@@ -152,10 +191,109 @@ func main() {
 			devices.PUT("/:id", deviceController.UpdateDevice)
 			devices.DELETE("/:id", deviceController.DeleteDevice)
 			devices.POST("/:id/test", deviceController.TestDevice)
+			devices.POST("/:id/classify", fingerprintController.ClassifyDevice)
+			devices.GET("/:id/poll/latest", pollingController.GetLatestValues)
+			devices.GET("/:id/poll/history", pollingController.GetPollHistory)
+			devices.POST("/:id/poll", pollingController.PollDevice)
+			devices.GET("/:id/inventory", inventoryController.GetInventory)
+			devices.POST("/:id/inventory", inventoryController.CollectInventory)
+			devices.GET("/:id/inventory/events", inventoryController.GetDeviceInventoryEvents)
+			devices.GET("/:id/interfaces", interfaceController.GetDeviceInterfaces)
+			devices.POST("/:id/interfaces", interfaceController.SyncInterfaces)
+			devices.GET("/:id/interfaces/events", interfaceController.GetDeviceInterfaceEvents)
+			devices.GET("/:id/neighbors", topologyController.GetDeviceNeighbors)
+			devices.POST("/:id/neighbors", topologyController.CollectNeighbors)
+			devices.GET("/:id/links/events", topologyController.GetDeviceLinkEvents)
+			devices.GET("/:id/status/events", healthController.GetDeviceStatusEvents)
+			devices.GET("/:id/availability", healthController.GetDeviceAvailability)
+			devices.GET("/:id/maintenance", maintenanceController.GetDeviceMaintenance)
+			devices.GET("/:id/snapshots", snapshotController.GetSnapshots)
+			devices.POST("/:id/snapshots", snapshotController.CreateSnapshot)
+			devices.GET("/:id/config-backups", configBackupController.GetBackups)
+			devices.POST("/:id/config-backups", configBackupController.BackupDevice)
+			devices.GET("/:id/config-backups/diff", configBackupController.DiffBackups)
+			devices.GET("/:id/config-backups/events", configBackupController.GetDeviceEvents)
+			devices.GET("/:id/config-backups/:version", configBackupController.GetBackup)
 			devices.GET("/templates", deviceController.GetDeviceTemplates)
+			devices.GET("/templates/:id", deviceController.GetDeviceTemplate)
+			devices.PUT("/templates/:id", deviceController.UpdateDeviceTemplate)
+			devices.GET("/templates/:id/versions", deviceController.GetDeviceTemplateVersions)
+			devices.GET("/templates/:id/usage", deviceController.GetDeviceTemplateUsage)
+			devices.POST("/templates/:id/apply", deviceController.ApplyDeviceTemplate)
+			devices.POST("/templates/:id/zabbix", deviceController.ExportDeviceTemplateZabbix)
+			devices.POST("/import", deviceController.ImportDevices)
+			devices.GET("/export", deviceController.ExportDevices)
+			devices.GET("/credential-profiles", deviceController.GetCredentialProfiles)
+			devices.POST("/credential-profiles", deviceController.CreateCredentialProfile)
+			devices.DELETE("/credential-profiles/:id", deviceController.DeleteCredentialProfile)
 			devices.POST("/templates", deviceController.CreateDeviceTemplate)
 		}
 
+		// Device fingerprint registry routes
+		fingerprints := api.Group("/fingerprints")
+		{
+			fingerprints.GET("", fingerprintController.GetFingerprints)
+			fingerprints.POST("", fingerprintController.CreateFingerprint)
+			fingerprints.GET("/export", fingerprintController.ExportFingerprints)
+			fingerprints.POST("/import", fingerprintController.ImportFingerprints)
+			fingerprints.POST("/builtin", fingerprintController.LoadBuiltinFingerprints)
+			fingerprints.POST("/classify", fingerprintController.Classify)
+			fingerprints.GET("/:id", fingerprintController.GetFingerprint)
+			fingerprints.PUT("/:id", fingerprintController.UpdateFingerprint)
+			fingerprints.DELETE("/:id", fingerprintController.DeleteFingerprint)
+		}
+
+		// Walk snapshot routes
+		snapshots := api.Group("/snapshots")
+		{
+			snapshots.GET("/diff", snapshotController.DiffSnapshots)
+			snapshots.GET("/:id", snapshotController.GetSnapshot)
+			snapshots.DELETE("/:id", snapshotController.DeleteSnapshot)
+		}
+
+		// Polling scheduler routes
+		api.GET("/polling/status", pollingController.GetStatus)
+
+		// Interface routes
+		interfaces := api.Group("/interfaces")
+		{
+			interfaces.GET("", interfaceController.GetInterfaces)
+			interfaces.GET("/:id", interfaceController.GetInterface)
+			interfaces.PUT("/:id/tags", interfaceController.UpdateInterfaceTags)
+			interfaces.GET("/:id/events", interfaceController.GetInterfaceEvents)
+		}
+
+		// Physical inventory routes
+		api.GET("/inventory/events", inventoryController.GetInventoryEvents)
+		api.GET("/inventory/search", inventoryController.SearchInventory)
+
+		// LLDP/CDP topology routes
+		api.GET("/topology", topologyController.GetTopology)
+		api.GET("/topology/events", topologyController.GetLinkEvents)
+
+		// Device running-config backup routes
+		api.GET("/config-backups/profiles", configBackupController.GetProfiles)
+		api.GET("/config-backups/events", configBackupController.GetEvents)
+
+		// Device health check routes
+		healthCheck := api.Group("/health-check")
+		{
+			healthCheck.GET("/status", healthController.GetStatus)
+			healthCheck.POST("/run", healthController.RunCheck)
+			healthCheck.GET("/events", healthController.GetStatusEvents)
+			healthCheck.GET("/availability", healthController.GetAvailabilityReport)
+		}
+
+		// Maintenance window routes
+		maintenance := api.Group("/maintenance-windows")
+		{
+			maintenance.GET("", maintenanceController.GetWindows)
+			maintenance.POST("", maintenanceController.CreateWindow)
+			maintenance.GET("/:id", maintenanceController.GetWindow)
+			maintenance.PUT("/:id", maintenanceController.UpdateWindow)
+			maintenance.DELETE("/:id", maintenanceController.DeleteWindow)
+		}
+
 		// Host discovery and management routes
 		hosts := api.Group("/hosts")
 		{
@@ -174,6 +312,13 @@ func main() {
 			discovery.POST("/tasks", hostController.CreateDiscoveryTask)
 			discovery.GET("/tasks/:id", hostController.GetDiscoveryTask)
 			discovery.POST("/tasks/:id/start", hostController.StartDiscovery)
+
+			// SNMP subnet sweep
+			discovery.GET("/snmp/tasks", snmpDiscoveryController.GetTasks)
+			discovery.POST("/snmp/tasks", snmpDiscoveryController.CreateTask)
+			discovery.GET("/snmp/tasks/:id", snmpDiscoveryController.GetTask)
+			discovery.POST("/snmp/tasks/:id/start", snmpDiscoveryController.StartTask)
+			discovery.GET("/snmp/tasks/:id/results", snmpDiscoveryController.GetTaskResults)
 		}
 
 		// Host credentials routes
@@ -183,6 +328,13 @@ func main() {
 			credentials.POST("", hostController.CreateCredential)
 		}
 
+		// Stored secret encryption key routes
+		security := api.Group("/security")
+		{
+			security.GET("/keys", secretController.GetKeyStatus)
+			security.POST("/keys/rotate", secretController.RotateKeys)
+		}
+
 		// Deployment routes
 		deployment := api.Group("/deployment")
 		{
@@ -213,6 +365,34 @@ func main() {
 	// Register alert rules routes
 	routes.RegisterAlertRulesRoutes(router, alertRulesController)
 
+	// Start background SNMP polling
+	pollingService.Start()
+	defer pollingService.Stop()
+
+	// Start periodic ENTITY-MIB inventory collection
+	inventoryService.Start()
+	defer inventoryService.Stop()
+
+	// Start periodic IF-MIB interface sync
+	interfaceService.Start()
+	defer interfaceService.Stop()
+
+	// Start periodic LLDP/CDP neighbor collection
+	topologyService.Start()
+	defer topologyService.Stop()
+
+	// Start periodic device health checks
+	healthService.Start()
+	defer healthService.Stop()
+
+	// Start scheduled running-config backups over SSH
+	configBackupService.Start()
+	defer configBackupService.Stop()
+
+	// Start maintenance window silence sync
+	maintenanceService.Start()
+	defer maintenanceService.Stop()
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
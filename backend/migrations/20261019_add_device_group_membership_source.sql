@@ -0,0 +1,3 @@
+-- 设备分组成员来源：static 为手动添加，selector 为按选择器自动计算
+ALTER TABLE device_group_devices ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'static';
+CREATE INDEX idx_device_group_devices_source ON device_group_devices (device_group_id, source);
@@ -95,6 +95,7 @@ type DeviceGroup struct {
 type DeviceGroupDevice struct {
 	DeviceGroupID string    `json:"device_group_id" gorm:"primaryKey;type:varchar(36)"`
 	DeviceID      string    `json:"device_id" gorm:"primaryKey;type:varchar(36)"`
+	Source        string    `json:"source" gorm:"type:varchar(20);default:'static'"` // static 手动添加, selector 由选择器计算
 	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
@@ -342,6 +343,25 @@ type UpdateDeviceGroupRequest struct {
 	Selector    map[string]interface{} `json:"selector,omitempty" example:"{\"job\":\"switch\",\"instance\":\"~192.168.1.*\"}"`
 }
 
+// PreviewDeviceGroupRequest 预览选择器匹配的设备
+type PreviewDeviceGroupRequest struct {
+	Selector map[string]interface{} `json:"selector" binding:"required" example:"{\"vendor\":[\"cisco\",\"huawei\"],\"cidr\":\"10.0.0.0/8\",\"location\":\"!~^lab\"}"`
+}
+
+// DeviceGroupPreview 选择器预览结果
+type DeviceGroupPreview struct {
+	Total   int      `json:"total"`
+	Devices []Device `json:"devices"`
+}
+
+// DeviceGroupSyncResult 按选择器重新计算分组成员的结果
+type DeviceGroupSyncResult struct {
+	GroupID string `json:"group_id"`
+	Matched int    `json:"matched"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+}
+
 // AddDevicesToGroupRequest 添加设备到分组请求
 type AddDevicesToGroupRequest struct {
 	DeviceIDs []string `json:"device_ids" binding:"required" example:"[\"device-001\",\"device-002\"]"`
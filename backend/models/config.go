@@ -37,13 +37,40 @@ type ConfigTemplate struct {
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
+// ConfigVersion 配置内容每次变化时的快照，Revision 在同一配置内从 1 递增
 type ConfigVersion struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	ConfigID  uint           `json:"config_id" gorm:"not null"`
-	Version   string         `json:"version" gorm:"not null"`
-	Content   string         `json:"content" gorm:"type:text"`
-	Changes   string         `json:"changes" gorm:"type:text"`
-	CreatedBy string         `json:"created_by"`
-	CreatedAt time.Time      `json:"created_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	ConfigID     uint           `json:"config_id" gorm:"not null;uniqueIndex:idx_config_version_config_revision"`
+	Revision     int            `json:"revision" gorm:"uniqueIndex:idx_config_version_config_revision"`
+	Version      string         `json:"version" gorm:"not null"` // 版本标签，模板生成时为模板名和模板版本
+	Content      string         `json:"content" gorm:"type:text"`
+	Hash         string         `json:"hash" gorm:"size:64"` // 内容的 sha256
+	Changes      string         `json:"changes" gorm:"type:text"`
+	RestoredFrom int            `json:"restored_from,omitempty"` // 回滚时恢复的版本号
+	CreatedBy    string         `json:"created_by"`
+	CreatedAt    time.Time      `json:"created_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// ConfigVersionDiff 两个配置版本之间的 unified diff
+type ConfigVersionDiff struct {
+	ConfigID     uint   `json:"config_id"`
+	FromRevision int    `json:"from_revision"`
+	ToRevision   int    `json:"to_revision"`
+	Added        int    `json:"added"`
+	Removed      int    `json:"removed"`
+	Diff         string `json:"diff"`
+}
+
+// UpdateConfigRequest 修改配置；内容变化时以 Author 和 Changes 记录新版本，Changes 为空时按差异行数生成
+type UpdateConfigRequest struct {
+	Config
+	Author  string `json:"author"`
+	Changes string `json:"changes"`
+}
+
+// ConfigRollbackRequest 回滚到历史版本，回滚本身也会生成新版本
+type ConfigRollbackRequest struct {
+	Author  string `json:"author"`
+	Changes string `json:"changes"`
 }
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// DeviceConfigBackup 通过 SSH 备份的设备运行配置，配置有变化时才新增版本，内容中的密码等敏感值已掩码
+type DeviceConfigBackup struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	DeviceID      uint      `json:"device_id" gorm:"not null;uniqueIndex:idx_device_config_backup_version"`
+	Version       int       `json:"version" gorm:"not null;uniqueIndex:idx_device_config_backup_version"`
+	Profile       string    `json:"profile"`
+	Command       string    `json:"command"`
+	Content       string    `json:"content,omitempty" gorm:"type:text"`
+	Hash          string    `json:"hash" gorm:"size:64"` // 去掉时间戳等易变行后的 sha256
+	Lines         int       `json:"lines"`
+	LastCheckedAt time.Time `json:"last_checked_at"` // 最近一次备份结果与该版本一致的时间
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
+
+// DeviceConfigEvent 配置备份事件，配置与上一次备份不同时记录 changed，备份失败时记录 failed
+type DeviceConfigEvent struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	DeviceID    uint      `json:"device_id" gorm:"not null;index"`
+	EventType   string    `json:"event_type" gorm:"index"` // changed, failed
+	FromVersion int       `json:"from_version,omitempty"`
+	ToVersion   int       `json:"to_version,omitempty"`
+	Added       int       `json:"added"`
+	Removed     int       `json:"removed"`
+	Diff        string    `json:"diff,omitempty" gorm:"type:text"`
+	Error       string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at" gorm:"index"`
+}
+
+// DeviceConfigDiff 两个备份版本之间的 unified diff
+type DeviceConfigDiff struct {
+	DeviceID    uint   `json:"device_id"`
+	FromVersion int    `json:"from_version"`
+	ToVersion   int    `json:"to_version"`
+	Added       int    `json:"added"`
+	Removed     int    `json:"removed"`
+	Diff        string `json:"diff"`
+}
+
+// ConfigBackupResult 一次配置备份的结果
+type ConfigBackupResult struct {
+	DeviceID uint                `json:"device_id"`
+	Backup   *DeviceConfigBackup `json:"backup"`
+	Changed  bool                `json:"changed"`
+	Event    *DeviceConfigEvent  `json:"event,omitempty"`
+	Duration int64               `json:"duration"` // 毫秒
+}
+
+// ConfigBackupProfile 厂商备份配置，说明执行的命令和匹配的厂商
+type ConfigBackupProfile struct {
+	Name    string   `json:"name"`
+	Vendors []string `json:"vendors"`
+	Command string   `json:"command"`
+}
@@ -12,16 +12,28 @@ type Device struct {
 	Hostname    string         `json:"hostname"`
 	IPAddress   string         `json:"ip_address" gorm:"not null"`
 	Port        int            `json:"port" gorm:"default:161"`
+	Transport   string         `json:"transport" gorm:"default:'udp'"` // udp, udp6, tcp, tcp6
 	Type        string         `json:"type"`
 	Vendor      string         `json:"vendor"`
 	Model       string         `json:"model"`
+	OSFamily    string         `json:"os_family"`
 	Location    string         `json:"location"`
+	Contact     string         `json:"contact"`
 	Description string         `json:"description"`
+	Tags        []string       `json:"tags" gorm:"type:jsonb;serializer:json"`
 	Status      string         `json:"status" gorm:"default:'unknown'"` // online, offline, unknown
 	LastSeen    *time.Time     `json:"last_seen"`
+	StatusChangedAt *time.Time `json:"status_changed_at"`
+	Flapping    bool           `json:"flapping"` // 近期状态频繁切换
+	PollInterval int           `json:"poll_interval" gorm:"default:0"` // 秒，0 表示使用模板或全局默认值
 	TemplateID  *uint          `json:"template_id"`
+	TemplateVersion int        `json:"template_version" gorm:"default:0"` // 采集配置最近一次按模板的哪个版本生成，0 表示尚未生成
 	Template    *DeviceTemplate `json:"template" gorm:"foreignKey:TemplateID"`
 	Credentials []SNMPCredential `json:"credentials" gorm:"foreignKey:DeviceID"`
+	SSHCredentialID *uint      `json:"ssh_credential_id"` // 备份运行配置使用的主机凭据
+	SSHPort     int            `json:"ssh_port" gorm:"default:22"`
+	BackupProfile string       `json:"backup_profile"` // cisco, huawei, h3c, juniper，为空时按厂商识别
+	Maintenance []DeviceMaintenance `json:"maintenance,omitempty" gorm:"-"` // 生效中和即将开始的维护窗口
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -33,9 +45,12 @@ type DeviceTemplate struct {
 	Type        string         `json:"type" gorm:"not null"`
 	Vendor      string         `json:"vendor"`
 	Description string         `json:"description"`
+	ParentID    *uint          `json:"parent_id" gorm:"index"` // 继承的父模板
+	Version     int            `json:"version" gorm:"default:1"`
 	MIBs        []MIB          `json:"mibs" gorm:"many2many:device_template_mibs;"`
-	OIDs        []string       `json:"oids" gorm:"type:text[]"`
-	Config      map[string]interface{} `json:"config" gorm:"type:jsonb"`
+	OIDs        []string       `json:"oids" gorm:"type:text[]"`         // 在父模板 OID 基础上追加
+	ExcludeOIDs []string       `json:"exclude_oids" gorm:"type:text[]"` // 从父模板继承时去掉的 OID 及其子树
+	Config      map[string]interface{} `json:"config" gorm:"type:jsonb"` // 与父模板按键合并，子模板优先
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
@@ -44,13 +59,13 @@ type DeviceTemplate struct {
 type SNMPCredential struct {
 	ID        uint           `json:"id" gorm:"primaryKey"`
 	DeviceID  uint           `json:"device_id" gorm:"not null"`
-	Version   string         `json:"version" gorm:"not null"` // v1, v2c, v3
-	Community string         `json:"community"`               // for v1, v2c
-	Username  string         `json:"username"`                // for v3
-	AuthProto string         `json:"auth_proto"`              // MD5, SHA
-	AuthKey   string         `json:"auth_key"`
-	PrivProto string         `json:"priv_proto"`              // DES, AES
-	PrivKey   string         `json:"priv_key"`
+	Version   string         `json:"version" gorm:"not null"`            // v1, v2c, v3
+	Community string         `json:"community" gorm:"serializer:secret"` // for v1, v2c
+	Username  string         `json:"username"`                           // for v3
+	AuthProto string         `json:"auth_proto"`                         // MD5, SHA
+	AuthKey   string         `json:"auth_key" gorm:"serializer:secret"`
+	PrivProto string         `json:"priv_proto"` // DES, AES
+	PrivKey   string         `json:"priv_key" gorm:"serializer:secret"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
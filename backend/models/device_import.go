@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SNMPCredentialProfile 命名的 SNMP 凭据模板，批量导入时可按名称引用
+type SNMPCredentialProfile struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"not null;uniqueIndex"`
+	Description string         `json:"description"`
+	Version     string         `json:"version" gorm:"not null"` // v1, v2c, v3
+	Community   string         `json:"community" gorm:"serializer:secret"`
+	Username    string         `json:"username"`
+	AuthProto   string         `json:"auth_proto"`
+	AuthKey     string         `json:"auth_key" gorm:"serializer:secret"`
+	PrivProto   string         `json:"priv_proto"`
+	PrivKey     string         `json:"priv_key" gorm:"serializer:secret"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// DeviceRecord 批量导入导出使用的扁平设备记录，字段名即 CSV 列名
+type DeviceRecord struct {
+	Name              string `json:"name" yaml:"name"`
+	Hostname          string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	IPAddress         string `json:"ip_address" yaml:"ip_address"`
+	Port              string `json:"port,omitempty" yaml:"port,omitempty"`
+	Transport         string `json:"transport,omitempty" yaml:"transport,omitempty"`
+	Type              string `json:"type,omitempty" yaml:"type,omitempty"`
+	Vendor            string `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	Model             string `json:"model,omitempty" yaml:"model,omitempty"`
+	OSFamily          string `json:"os_family,omitempty" yaml:"os_family,omitempty"`
+	Location          string `json:"location,omitempty" yaml:"location,omitempty"`
+	Contact           string `json:"contact,omitempty" yaml:"contact,omitempty"`
+	Description       string `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags              string `json:"tags,omitempty" yaml:"tags,omitempty"` // 逗号或分号分隔
+	PollInterval      string `json:"poll_interval,omitempty" yaml:"poll_interval,omitempty"`
+	Template          string `json:"template,omitempty" yaml:"template,omitempty"`
+	CredentialProfile string `json:"credential_profile,omitempty" yaml:"credential_profile,omitempty"`
+	SNMPVersion       string `json:"snmp_version,omitempty" yaml:"snmp_version,omitempty"`
+	Community         string `json:"community,omitempty" yaml:"community,omitempty"`
+	Username          string `json:"username,omitempty" yaml:"username,omitempty"`
+	AuthProto         string `json:"auth_proto,omitempty" yaml:"auth_proto,omitempty"`
+	AuthKey           string `json:"auth_key,omitempty" yaml:"auth_key,omitempty"`
+	PrivProto         string `json:"priv_proto,omitempty" yaml:"priv_proto,omitempty"`
+	PrivKey           string `json:"priv_key,omitempty" yaml:"priv_key,omitempty"`
+}
+
+// DeviceImportOptions 批量导入选项
+type DeviceImportOptions struct {
+	Format         string            `json:"format"`          // csv, json, yaml
+	Mapping        map[string]string `json:"mapping"`         // 源列名 -> DeviceRecord 字段名
+	DryRun         bool              `json:"dry_run"`         // 只校验并返回预览，不写库
+	UpdateExisting bool              `json:"update_existing"` // IP 已存在时更新，否则跳过
+}
+
+// DeviceImportRow 单行导入结果
+type DeviceImportRow struct {
+	Row       int      `json:"row"`
+	Name      string   `json:"name"`
+	IPAddress string   `json:"ip_address"`
+	Action    string   `json:"action"` // create, update, skip, error
+	DeviceID  *uint    `json:"device_id,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// DeviceImportResult 批量导入结果
+type DeviceImportResult struct {
+	DryRun         bool              `json:"dry_run"`
+	Total          int               `json:"total"`
+	Created        int               `json:"created"`
+	Updated        int               `json:"updated"`
+	Skipped        int               `json:"skipped"`
+	Failed         int               `json:"failed"`
+	UnknownColumns []string          `json:"unknown_columns,omitempty"` // 未能映射的列，通常是 mapping 缺失
+	Rows           []DeviceImportRow `json:"rows"`
+}
+
+func (SNMPCredentialProfile) TableName() string {
+	return "snmp_credential_profiles"
+}
@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// DeviceTemplateVersion 设备模板每个版本的快照；父模板修改导致生效 OID 变化时子模板也会生成新版本
+type DeviceTemplateVersion struct {
+	ID            uint                   `json:"id" gorm:"primaryKey"`
+	TemplateID    uint                   `json:"template_id" gorm:"not null;uniqueIndex:idx_device_template_version"`
+	Version       int                    `json:"version" gorm:"not null;uniqueIndex:idx_device_template_version"`
+	Name          string                 `json:"name"`
+	ParentID      *uint                  `json:"parent_id"`
+	OIDs          []string               `json:"oids" gorm:"type:jsonb;serializer:json"`
+	ExcludeOIDs   []string               `json:"exclude_oids" gorm:"type:jsonb;serializer:json"`
+	EffectiveOIDs []string               `json:"effective_oids" gorm:"type:jsonb;serializer:json"` // 合并父模板后的 OID
+	Config        map[string]interface{} `json:"config" gorm:"type:jsonb;serializer:json"`         // 合并父模板后的配置
+	Changes       string                 `json:"changes" gorm:"type:text"`
+	CreatedAt     time.Time              `json:"created_at"`
+}
+
+// DeviceTemplateRef 继承链上的模板
+type DeviceTemplateRef struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+// EffectiveDeviceTemplate 模板本身以及合并继承链后的生效内容
+type EffectiveDeviceTemplate struct {
+	Template      DeviceTemplate         `json:"template"`
+	Chain         []DeviceTemplateRef    `json:"chain"` // 从根模板到当前模板
+	EffectiveOIDs []string               `json:"effective_oids"`
+	Config        map[string]interface{} `json:"config"`
+	MIBs          []MIB                  `json:"mibs"`
+}
+
+// DeviceTemplateVersionUsage 使用某个模板版本生成采集配置的设备，Version 为 0 表示尚未生成
+type DeviceTemplateVersionUsage struct {
+	Version int                  `json:"version"`
+	Current bool                 `json:"current"`
+	Devices []DeviceTemplateUser `json:"devices"`
+}
+
+type DeviceTemplateUser struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	IPAddress string `json:"ip_address"`
+}
+
+// DeviceTemplateUsage 模板各版本的使用情况
+type DeviceTemplateUsage struct {
+	TemplateID     uint                         `json:"template_id"`
+	Name           string                       `json:"name"`
+	CurrentVersion int                          `json:"current_version"`
+	Total          int                          `json:"total"`
+	Outdated       int                          `json:"outdated"` // 采集配置不是按当前版本生成的设备数
+	Versions       []DeviceTemplateVersionUsage `json:"versions"`
+	Children       []DeviceTemplateRef          `json:"children"` // 继承该模板的子模板
+}
+
+// UpdateDeviceTemplateRequest 修改模板，只修改请求中出现的字段；parent_id 为 0 时取消继承
+type UpdateDeviceTemplateRequest struct {
+	Name        *string                `json:"name,omitempty"`
+	Type        *string                `json:"type,omitempty"`
+	Vendor      *string                `json:"vendor,omitempty"`
+	Description *string                `json:"description,omitempty"`
+	ParentID    *uint                  `json:"parent_id,omitempty"`
+	MIBs        []MIB                  `json:"mibs,omitempty"`
+	OIDs        *[]string              `json:"oids,omitempty"`
+	ExcludeOIDs *[]string              `json:"exclude_oids,omitempty"`
+	Config      map[string]interface{} `json:"config,omitempty"`
+}
+
+// ApplyDeviceTemplateRequest 批量重新生成采集配置
+type ApplyDeviceTemplateRequest struct {
+	ConfigTypes []string `json:"config_types"` // snmp_exporter、categraf、telegraf，默认 snmp_exporter
+}
+
+// DeviceTemplateApplyResult 批量重新生成采集配置的结果
+type DeviceTemplateApplyResult struct {
+	TemplateID uint     `json:"template_id"`
+	Templates  int      `json:"templates"` // 包括子模板
+	Devices    int      `json:"devices"`
+	Updated    int      `json:"updated"`
+	Unchanged  int      `json:"unchanged"`
+	Failed     int      `json:"failed"`
+	Errors     []string `json:"errors,omitempty"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SNMPDiscoveryTask SNMP 网段扫描发现任务
+type SNMPDiscoveryTask struct {
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	Name          string `json:"name" gorm:"size:255;not null"`
+	IPRange       string `json:"ip_range" gorm:"size:255;not null"` // CIDR、起止范围、单个 IP 或主机名
+	Port          int    `json:"port" gorm:"default:161"`
+	Transport     string `json:"transport" gorm:"size:10;default:'udp'"`
+	Credentials   string `json:"-" gorm:"type:text;serializer:secret"` // []SNMPProbeCandidate 的 JSON，加密存储，不在接口中返回
+	TimeoutMs     int    `json:"timeout_ms" gorm:"default:1000"`
+	Retries       int    `json:"retries" gorm:"default:0"`
+	Concurrency   int    `json:"concurrency" gorm:"default:50"`
+	CreateDevices bool   `json:"create_devices"` // 为新发现的代理创建 Device 记录
+
+	// 任务状态
+	Status   string `json:"status" gorm:"size:20;default:'pending'"` // pending, running, completed, failed
+	Progress int    `json:"progress" gorm:"default:0"`               // 0-100
+	Error    string `json:"error" gorm:"type:text"`
+
+	// 结果统计
+	TotalHosts     int `json:"total_hosts" gorm:"default:0"`
+	ScannedHosts   int `json:"scanned_hosts" gorm:"default:0"`
+	RespondedHosts int `json:"responded_hosts" gorm:"default:0"`
+	CreatedDevices int `json:"created_devices" gorm:"default:0"`
+	UpdatedDevices int `json:"updated_devices" gorm:"default:0"`
+
+	StartedAt   *time.Time     `json:"started_at"`
+	CompletedAt *time.Time     `json:"completed_at"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// SNMPDiscoveryResult 发现任务中单个 IP 的结果
+type SNMPDiscoveryResult struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	TaskID         uint      `json:"task_id" gorm:"not null;index"`
+	IP             string    `json:"ip" gorm:"size:45;not null"`
+	Responded      bool      `json:"responded" gorm:"index"`
+	Version        string    `json:"version"`
+	CredentialName string    `json:"credential_name"`
+	SysDescr       string    `json:"sys_descr" gorm:"type:text"`
+	SysObjectID    string    `json:"sys_object_id"`
+	SysName        string    `json:"sys_name"`
+	SysLocation    string    `json:"sys_location"`
+	SysContact     string    `json:"sys_contact"`
+	Vendor         string    `json:"vendor"`
+	Model          string    `json:"model"`
+	DeviceType     string    `json:"device_type"`
+	OSFamily       string    `json:"os_family"`
+	TemplateID     *uint     `json:"template_id"`
+	DeviceID       *uint     `json:"device_id"`
+	Action         string    `json:"action"` // created, updated, discovered, none
+	Error          string    `json:"error" gorm:"type:text"`
+	Duration       int64     `json:"duration"` // 毫秒
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreateSNMPDiscoveryRequest 创建 SNMP 发现任务请求
+type CreateSNMPDiscoveryRequest struct {
+	Name          string               `json:"name"`
+	IPRange       string               `json:"ip_range" binding:"required"`
+	Port          int                  `json:"port"`
+	Transport     string               `json:"transport"`
+	Credentials   []SNMPProbeCandidate `json:"credentials" binding:"required,min=1"`
+	TimeoutMs     int                  `json:"timeout_ms"`
+	Retries       int                  `json:"retries"`
+	Concurrency   int                  `json:"concurrency"`
+	CreateDevices bool                 `json:"create_devices"`
+	Start         bool                 `json:"start"` // 创建后立即开始
+}
+
+func (SNMPDiscoveryTask) TableName() string {
+	return "snmp_discovery_tasks"
+}
+
+func (SNMPDiscoveryResult) TableName() string {
+	return "snmp_discovery_results"
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeviceFingerprint 设备指纹规则：按 sysObjectID 前缀和 sysDescr 正则识别厂商、型号、系统和默认模板
+type DeviceFingerprint struct {
+	ID                uint            `json:"id" gorm:"primaryKey"`
+	Name              string          `json:"name" gorm:"not null;uniqueIndex"`
+	SysObjectIDPrefix string          `json:"sys_object_id_prefix" gorm:"index"`
+	SysDescrPattern   string          `json:"sys_descr_pattern"` // 正则，型号可用 $1 引用捕获组
+	Vendor            string          `json:"vendor"`
+	Model             string          `json:"model"`
+	OSFamily          string          `json:"os_family"`
+	DeviceType        string          `json:"device_type"`
+	TemplateID        *uint           `json:"template_id"`
+	Template          *DeviceTemplate `json:"template,omitempty" gorm:"foreignKey:TemplateID"`
+	Priority          int             `json:"priority" gorm:"default:0"` // 越大越优先
+	Disabled          bool            `json:"disabled"`
+	Builtin           bool            `json:"builtin"`
+	Description       string          `json:"description"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt  `json:"deleted_at" gorm:"index"`
+}
+
+// FingerprintEntry 导入导出使用的指纹格式，模板按名称引用以便跨环境迁移
+type FingerprintEntry struct {
+	Name              string `json:"name" yaml:"name"`
+	SysObjectIDPrefix string `json:"sys_object_id_prefix,omitempty" yaml:"sys_object_id_prefix,omitempty"`
+	SysDescrPattern   string `json:"sys_descr_pattern,omitempty" yaml:"sys_descr_pattern,omitempty"`
+	Vendor            string `json:"vendor,omitempty" yaml:"vendor,omitempty"`
+	Model             string `json:"model,omitempty" yaml:"model,omitempty"`
+	OSFamily          string `json:"os_family,omitempty" yaml:"os_family,omitempty"`
+	DeviceType        string `json:"device_type,omitempty" yaml:"device_type,omitempty"`
+	Template          string `json:"template,omitempty" yaml:"template,omitempty"`
+	Priority          int    `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Disabled          bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	Description       string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// ImportFingerprintsRequest 批量导入指纹，replace 为 true 时先删除全部非内置规则
+type ImportFingerprintsRequest struct {
+	Fingerprints []FingerprintEntry `json:"fingerprints" yaml:"fingerprints"`
+	Replace      bool               `json:"replace" yaml:"replace"`
+}
+
+// ImportFingerprintsResult 导入结果
+type ImportFingerprintsResult struct {
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Deleted int64    `json:"deleted"`
+	Errors  []string `json:"errors"`
+}
+
+// ClassifyRequest 按系统信息试算指纹匹配结果
+type ClassifyRequest struct {
+	SysObjectID string `json:"sys_object_id"`
+	SysDescr    string `json:"sys_descr"`
+}
+
+// FingerprintMatch 指纹识别结果
+type FingerprintMatch struct {
+	FingerprintID   *uint  `json:"fingerprint_id"`
+	FingerprintName string `json:"fingerprint_name"`
+	Vendor          string `json:"vendor"`
+	Model           string `json:"model"`
+	OSFamily        string `json:"os_family"`
+	DeviceType      string `json:"device_type"`
+	TemplateID      *uint  `json:"template_id"`
+	Source          string `json:"source"` // registry, builtin
+}
+
+func (DeviceFingerprint) TableName() string {
+	return "device_fingerprints"
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// DeviceStatusEvent 设备状态变化记录
+type DeviceStatusEvent struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DeviceID   uint      `json:"device_id" gorm:"not null;index:idx_status_event_device_time"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason" gorm:"type:text"`
+	Source     string    `json:"source"` // health_check, poll, manual
+	Flapping   bool      `json:"flapping"`
+	Suppressed bool      `json:"suppressed"`          // 发生在维护窗口内，不参与抖动判断
+	WindowID   *uint     `json:"window_id,omitempty"` // 抑制该事件的维护窗口
+	CreatedAt  time.Time `json:"created_at" gorm:"index:idx_status_event_device_time"`
+}
+
+// DeviceAvailability 设备在统计区间内的可用性
+type DeviceAvailability struct {
+	DeviceID       uint      `json:"device_id"`
+	DeviceName     string    `json:"device_name"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OnlineSeconds  int64     `json:"online_seconds"`
+	OfflineSeconds int64     `json:"offline_seconds"`
+	UnknownSeconds int64     `json:"unknown_seconds"`
+	Availability   *float64  `json:"availability"` // 在线时长占已知状态时长的百分比，无数据时为空
+	Transitions    int       `json:"transitions"`
+	Flapping       bool      `json:"flapping"`
+}
+
+// HealthCheckStatus 健康检查器运行状态
+type HealthCheckStatus struct {
+	Running     bool       `json:"running"`
+	Interval    int        `json:"interval"` // 秒
+	Workers     int        `json:"workers"`
+	Checking    bool       `json:"checking"`
+	LastRun     *time.Time `json:"last_run"`
+	LastChecked int        `json:"last_checked"`
+	LastOnline  int        `json:"last_online"`
+	LastOffline int        `json:"last_offline"`
+}
+
+func (DeviceStatusEvent) TableName() string {
+	return "device_status_events"
+}
@@ -20,8 +20,8 @@ type Host struct {
 	// 连接信息
 	Username    string         `json:"username" gorm:"size:100"`
 	AuthType    string         `json:"auth_type" gorm:"size:20;default:'password'"` // password, key
-	Password    string         `json:"password,omitempty" gorm:"size:255"` // 加密存储
-	PrivateKey  string         `json:"private_key,omitempty" gorm:"type:text"` // 加密存储
+	Password    string         `json:"password,omitempty" gorm:"type:text;serializer:secret"` // 加密存储
+	PrivateKey  string         `json:"private_key,omitempty" gorm:"type:text;serializer:secret"` // 加密存储
 	
 	// 系统信息
 	CPUCores    int            `json:"cpu_cores"`
@@ -85,8 +85,8 @@ type HostDiscoveryTask struct {
 	
 	// 认证配置
 	Username    string         `json:"username" gorm:"size:100"`
-	Password    string         `json:"password" gorm:"size:255"` // 加密存储
-	PrivateKey  string         `json:"private_key" gorm:"type:text"` // 加密存储
+	Password    string         `json:"password" gorm:"type:text;serializer:secret"` // 加密存储
+	PrivateKey  string         `json:"private_key" gorm:"type:text;serializer:secret"` // 加密存储
 	
 	// 任务状态
 	Status      string         `json:"status" gorm:"size:20;default:'pending'"` // pending, running, completed, failed
@@ -116,9 +116,9 @@ type HostCredential struct {
 	// 认证信息
 	Username    string         `json:"username" gorm:"size:100;not null"`
 	AuthType    string         `json:"auth_type" gorm:"size:20;not null"` // password, key
-	Password    string         `json:"password,omitempty" gorm:"size:255"` // 加密存储
-	PrivateKey  string         `json:"private_key,omitempty" gorm:"type:text"` // 加密存储
-	Passphrase  string         `json:"passphrase,omitempty" gorm:"size:255"` // 私钥密码，加密存储
+	Password    string         `json:"password,omitempty" gorm:"type:text;serializer:secret"` // 加密存储
+	PrivateKey  string         `json:"private_key,omitempty" gorm:"type:text;serializer:secret"` // 加密存储
+	Passphrase  string         `json:"passphrase,omitempty" gorm:"type:text;serializer:secret"` // 私钥密码，加密存储
 	
 	// 使用范围
 	IPRanges    string         `json:"ip_ranges" gorm:"type:text"` // JSON 格式存储 IP 范围
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// DeviceInterface IF-MIB ifTable/ifXTable 中的接口；接口消失后保留记录（present=false）以保留标签
+type DeviceInterface struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	DeviceID    uint       `json:"device_id" gorm:"not null;index:idx_interface_device_name"`
+	Device      *Device    `json:"device,omitempty" gorm:"foreignKey:DeviceID"`
+	IfIndex     int        `json:"if_index" gorm:"index"`
+	Name        string     `json:"name" gorm:"index:idx_interface_device_name"` // ifName，缺失时使用 ifDescr
+	Descr       string     `json:"descr"`
+	Alias       string     `json:"alias"`
+	Type        string     `json:"type"`
+	Speed       uint64     `json:"speed"` // bit/s
+	MTU         int        `json:"mtu"`
+	MAC         string     `json:"mac"`
+	AdminStatus string     `json:"admin_status"` // up, down, testing
+	OperStatus  string     `json:"oper_status"`  // up, down, testing, unknown, dormant, notPresent, lowerLayerDown
+	LastChange  int64      `json:"last_change"`  // ifLastChange，TimeTicks
+	Tags        []string   `json:"tags" gorm:"type:jsonb;serializer:json"`
+	Present     bool       `json:"present" gorm:"index"`
+	LastSeen    *time.Time `json:"last_seen"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// InterfaceEvent 接口变化记录
+type InterfaceEvent struct {
+	ID          uint                       `json:"id" gorm:"primaryKey"`
+	DeviceID    uint                       `json:"device_id" gorm:"not null;index"`
+	InterfaceID uint                       `json:"interface_id" gorm:"index"`
+	IfIndex     int                        `json:"if_index"`
+	Name        string                     `json:"name"`
+	EventType   string                     `json:"event_type" gorm:"index"` // added, removed, renumbered, status_changed, changed
+	Changes     map[string]InventoryChange `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt   time.Time                  `json:"created_at" gorm:"index"`
+}
+
+// InterfaceSyncResult 一次接口采集的结果
+type InterfaceSyncResult struct {
+	DeviceID   uint             `json:"device_id"`
+	Interfaces int              `json:"interfaces"`
+	Baseline   bool             `json:"baseline"` // 首次采集，不产生事件
+	Events     []InterfaceEvent `json:"events"`
+	Duration   int64            `json:"duration"` // 毫秒
+}
+
+// UpdateInterfaceTagsRequest 设置接口标签，如 uplink、customer、critical
+type UpdateInterfaceTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func (DeviceInterface) TableName() string {
+	return "device_interfaces"
+}
+
+func (InterfaceEvent) TableName() string {
+	return "interface_events"
+}
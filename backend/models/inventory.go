@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+// InventoryItem ENTITY-MIB entPhysicalTable 中的一个物理组件
+type InventoryItem struct {
+	ID            uint             `json:"id" gorm:"primaryKey"`
+	DeviceID      uint             `json:"device_id" gorm:"not null;uniqueIndex:idx_inventory_device_index"`
+	PhysicalIndex int              `json:"physical_index" gorm:"not null;uniqueIndex:idx_inventory_device_index"`
+	ParentIndex   int              `json:"parent_index"` // entPhysicalContainedIn，0 表示顶层
+	ParentRelPos  int              `json:"parent_rel_pos"`
+	Class         string           `json:"class"` // chassis, module, powerSupply, fan, port, ...
+	Name          string           `json:"name"`
+	Description   string           `json:"description" gorm:"type:text"`
+	VendorType    string           `json:"vendor_type"`
+	HardwareRev   string           `json:"hardware_rev"`
+	FirmwareRev   string           `json:"firmware_rev"`
+	SoftwareRev   string           `json:"software_rev"`
+	SerialNumber  string           `json:"serial_number" gorm:"index"`
+	Manufacturer  string           `json:"manufacturer"`
+	ModelName     string           `json:"model_name"`
+	Alias         string           `json:"alias"`
+	AssetID       string           `json:"asset_id"`
+	IsFRU         bool             `json:"is_fru"`
+	CollectedAt   time.Time        `json:"collected_at"`
+	Children      []*InventoryItem `json:"children,omitempty" gorm:"-"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+}
+
+// InventoryEvent 两次采集之间的组件变化
+type InventoryEvent struct {
+	ID            uint                       `json:"id" gorm:"primaryKey"`
+	DeviceID      uint                       `json:"device_id" gorm:"not null;index"`
+	PhysicalIndex int                        `json:"physical_index"`
+	EventType     string                     `json:"event_type" gorm:"index"` // added, removed, replaced, changed
+	Class         string                     `json:"class"`
+	Name          string                     `json:"name"`
+	OldSerial     string                     `json:"old_serial"`
+	NewSerial     string                     `json:"new_serial"`
+	OldModel      string                     `json:"old_model"`
+	NewModel      string                     `json:"new_model"`
+	Changes       map[string]InventoryChange `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt     time.Time                  `json:"created_at" gorm:"index"`
+}
+
+// InventoryChange 单个字段的变化
+type InventoryChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// InventoryCollectResult 一次库存采集的结果
+type InventoryCollectResult struct {
+	DeviceID uint             `json:"device_id"`
+	Items    int              `json:"items"`
+	Baseline bool             `json:"baseline"` // 首次采集，不产生事件
+	Events   []InventoryEvent `json:"events"`
+	Duration int64            `json:"duration"` // 毫秒
+}
+
+func (InventoryItem) TableName() string {
+	return "inventory_items"
+}
+
+func (InventoryEvent) TableName() string {
+	return "inventory_events"
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// MaintenanceWindow 维护窗口。生效期间范围内设备的状态变化事件标记为已抑制，不参与抖动判断，
+// 并在 Alertmanager 中为范围内的设备和主机创建静默，静默随周期结束自动过期
+type MaintenanceWindow struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"not null"`
+	Description string     `json:"description" gorm:"type:text"`
+	StartsAt    time.Time  `json:"starts_at" gorm:"not null;index"`  // 第一个周期的开始时间
+	EndsAt      time.Time  `json:"ends_at" gorm:"not null"`          // 第一个周期的结束时间，循环窗口每个周期时长相同
+	Recurrence  string     `json:"recurrence" gorm:"default:'none'"` // none, daily, weekly, monthly
+	RepeatUntil *time.Time `json:"repeat_until"`                     // 循环窗口最后一个周期的开始时间上限，为空表示一直循环
+	DeviceIDs   []uint     `json:"device_ids" gorm:"type:jsonb;serializer:json"`
+	GroupIDs    []string   `json:"group_ids" gorm:"type:jsonb;serializer:json"`
+	HostIDs     []uint     `json:"host_ids" gorm:"type:jsonb;serializer:json"`
+	Enabled     bool       `json:"enabled" gorm:"index"`
+	CreatedBy   string     `json:"created_by"`
+
+	// 当前周期在 Alertmanager 中的静默
+	SilenceID     string     `json:"silence_id"`
+	SilenceEndsAt *time.Time `json:"silence_ends_at"`
+	SilenceError  string     `json:"silence_error,omitempty" gorm:"type:text"`
+
+	// 当前或下一个周期，查询时计算
+	Active       bool       `json:"active" gorm:"-"`
+	NextStartsAt *time.Time `json:"next_starts_at,omitempty" gorm:"-"`
+	NextEndsAt   *time.Time `json:"next_ends_at,omitempty" gorm:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MaintenanceWindowRequest 创建或更新维护窗口
+type MaintenanceWindowRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Description string     `json:"description"`
+	StartsAt    time.Time  `json:"starts_at" binding:"required"`
+	EndsAt      time.Time  `json:"ends_at" binding:"required"`
+	Recurrence  string     `json:"recurrence"`
+	RepeatUntil *time.Time `json:"repeat_until"`
+	DeviceIDs   []uint     `json:"device_ids"`
+	GroupIDs    []string   `json:"group_ids"`
+	HostIDs     []uint     `json:"host_ids"`
+	Enabled     *bool      `json:"enabled"` // 默认启用
+	CreatedBy   string     `json:"created_by"`
+}
+
+// DeviceMaintenance 设备所在的维护窗口，附加在设备详情中
+type DeviceMaintenance struct {
+	WindowID uint      `json:"window_id"`
+	Name     string    `json:"name"`
+	Active   bool      `json:"active"`
+	StartsAt time.Time `json:"starts_at"` // 当前或下一个周期
+	EndsAt   time.Time `json:"ends_at"`
+}
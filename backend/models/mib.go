@@ -40,6 +40,9 @@ type OID struct {
 	Syntax      string         `json:"syntax"`
 	Units       string         `json:"units"`
 	ParentOID   string         `json:"parent_oid"`
+	Indexes     []string       `json:"indexes,omitempty" gorm:"type:jsonb;serializer:json"`     // 表 Entry 的 INDEX 对象，IMPLIED 索引带 "IMPLIED " 前缀
+	Augments    string         `json:"augments,omitempty"`                                      // AUGMENTS 的表 Entry，索引与其相同
+	EnumValues  map[int]string `json:"enum_values,omitempty" gorm:"type:jsonb;serializer:json"` // INTEGER/BITS 的枚举值
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
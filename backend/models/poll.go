@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// PollValue 设备模板 OID 的最新采集值，每个设备的每个 OID 实例只保留一行
+type PollValue struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	DeviceID    uint      `json:"device_id" gorm:"not null;uniqueIndex:idx_poll_values_device_oid"`
+	OID         string    `json:"oid" gorm:"not null;uniqueIndex:idx_poll_values_device_oid"`
+	Name        string    `json:"name"`
+	Type        string    `json:"type"`
+	Value       string    `json:"value" gorm:"type:text"`
+	CollectedAt time.Time `json:"collected_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// PollRecord 单次轮询的历史记录
+type PollRecord struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	DeviceID   uint      `json:"device_id" gorm:"not null;index"`
+	StartedAt  time.Time `json:"started_at" gorm:"index"`
+	Duration   int64     `json:"duration"` // 毫秒
+	Success    bool      `json:"success"`
+	OIDCount   int       `json:"oid_count"`
+	ValueCount int       `json:"value_count"`
+	Error      string    `json:"error" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PollingStatus 轮询调度器运行状态
+type PollingStatus struct {
+	Running          bool      `json:"running"`
+	Workers          int       `json:"workers"`
+	DefaultInterval  int       `json:"default_interval"` // 秒
+	ScheduledDevices int       `json:"scheduled_devices"`
+	InFlight         int       `json:"in_flight"`
+	QueueLength      int       `json:"queue_length"`
+	LastRefresh      time.Time `json:"last_refresh"`
+}
+
+func (PollValue) TableName() string {
+	return "poll_values"
+}
+
+func (PollRecord) TableName() string {
+	return "poll_records"
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+
+	"mib-platform/utils"
+)
+
+func init() {
+	schema.RegisterSerializer("secret", SecretSerializer{})
+}
+
+// SecretSerializer 敏感字段的序列化器，写入前用主密钥加密，读取后解密，
+// 字段使用 `gorm:"serializer:secret"` 声明。注意 map 形式的 Updates 不经过序列化器，需要自行调用 utils.EncryptSecret
+type SecretSerializer struct{}
+
+func (SecretSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("failed to scan secret field %s: unsupported type %T", field.Name, dbValue)
+	}
+
+	plaintext, err := utils.DecryptSecret(value)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %v", field.Name, err)
+	}
+	field.ReflectValueOf(ctx, dst).SetString(plaintext)
+	return nil
+}
+
+func (SecretSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plaintext, _ := fieldValue.(string)
+	return utils.EncryptSecret(plaintext)
+}
+
+// 以下 MarshalJSON 在接口返回时用 utils.SecretMask 替换非空的敏感字段，服务内部读取到的仍是明文
+
+func (c SNMPCredential) MarshalJSON() ([]byte, error) {
+	type credential SNMPCredential
+	masked := credential(c)
+	masked.Community = utils.MaskSecret(c.Community)
+	masked.AuthKey = utils.MaskSecret(c.AuthKey)
+	masked.PrivKey = utils.MaskSecret(c.PrivKey)
+	return json.Marshal(masked)
+}
+
+func (p SNMPCredentialProfile) MarshalJSON() ([]byte, error) {
+	type profile SNMPCredentialProfile
+	masked := profile(p)
+	masked.Community = utils.MaskSecret(p.Community)
+	masked.AuthKey = utils.MaskSecret(p.AuthKey)
+	masked.PrivKey = utils.MaskSecret(p.PrivKey)
+	return json.Marshal(masked)
+}
+
+func (h Host) MarshalJSON() ([]byte, error) {
+	type host Host
+	masked := host(h)
+	masked.Password = utils.MaskSecret(h.Password)
+	masked.PrivateKey = utils.MaskSecret(h.PrivateKey)
+	return json.Marshal(masked)
+}
+
+func (c HostCredential) MarshalJSON() ([]byte, error) {
+	type credential HostCredential
+	masked := credential(c)
+	masked.Password = utils.MaskSecret(c.Password)
+	masked.PrivateKey = utils.MaskSecret(c.PrivateKey)
+	masked.Passphrase = utils.MaskSecret(c.Passphrase)
+	return json.Marshal(masked)
+}
+
+func (t HostDiscoveryTask) MarshalJSON() ([]byte, error) {
+	type task HostDiscoveryTask
+	masked := task(t)
+	masked.Password = utils.MaskSecret(t.Password)
+	masked.PrivateKey = utils.MaskSecret(t.PrivateKey)
+	return json.Marshal(masked)
+}
+
+// SecretColumnStatus 单个敏感字段的密文分布
+type SecretColumnStatus struct {
+	Table     string         `json:"table"`
+	Column    string         `json:"column"`
+	Total     int            `json:"total"`
+	ByKey     map[string]int `json:"by_key"`    // 密钥 ID -> 数量
+	Plaintext int            `json:"plaintext"` // 未加密或旧版格式，需要轮换
+	Stale     int            `json:"stale"`     // 不是用当前主密钥加密的数量
+}
+
+// SecretKeyStatus 主密钥与已存储密文的状态
+type SecretKeyStatus struct {
+	CurrentKeyID string               `json:"current_key_id"`
+	KeyIDs       []string             `json:"key_ids"`
+	Stale        int                  `json:"stale"`
+	Columns      []SecretColumnStatus `json:"columns"`
+}
+
+// SecretRotationResult 密钥轮换结果
+type SecretRotationResult struct {
+	KeyID      string    `json:"key_id"`
+	Scanned    int       `json:"scanned"`
+	Rotated    int       `json:"rotated"`
+	Skipped    int       `json:"skipped"` // 轮换期间被并发修改的行，下次轮换时处理
+	Failed     int       `json:"failed"`
+	Errors     []string  `json:"errors,omitempty"`
+	Duration   string    `json:"duration"`
+	FinishedAt time.Time `json:"finished_at"`
+}
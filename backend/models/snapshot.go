@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SNMPSnapshot 设备 SNMP 遍历快照，遍历结果以 gzip 压缩的 JSON 保存
+type SNMPSnapshot struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	DeviceID   uint           `json:"device_id" gorm:"not null;index"`
+	Device     *Device        `json:"device,omitempty" gorm:"foreignKey:DeviceID"`
+	Name       string         `json:"name"`
+	RootOID    string         `json:"root_oid"`
+	OIDCount   int            `json:"oid_count"`
+	RawSize    int            `json:"raw_size"` // 压缩前字节数
+	Size       int            `json:"size"`     // 压缩后字节数
+	Duration   int64          `json:"duration"` // 毫秒
+	Data       []byte         `json:"-" gorm:"type:bytea"`
+	CreatedBy  string         `json:"created_by"`
+	CapturedAt time.Time      `json:"captured_at" gorm:"index"`
+	CreatedAt  time.Time      `json:"created_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+}
+
+// SNMPSnapshotEntry 快照中的单个 OID
+type SNMPSnapshotEntry struct {
+	OID   string `json:"oid"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// CreateSnapshotRequest 创建快照请求，RootOID 为空时遍历整棵 MIB 树
+type CreateSnapshotRequest struct {
+	Name      string `json:"name"`
+	RootOID   string `json:"root_oid"`
+	CreatedBy string `json:"created_by"`
+}
+
+// SNMPSnapshotDiffEntry 快照差异条目
+type SNMPSnapshotDiffEntry struct {
+	OID      string `json:"oid"`
+	Name     string `json:"name,omitempty"`
+	Type     string `json:"type"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+}
+
+// SNMPSnapshotDiff 两个快照之间的差异
+type SNMPSnapshotDiff struct {
+	From            *SNMPSnapshot           `json:"from"`
+	To              *SNMPSnapshot           `json:"to"`
+	Added           []SNMPSnapshotDiffEntry `json:"added"`
+	Removed         []SNMPSnapshotDiffEntry `json:"removed"`
+	Changed         []SNMPSnapshotDiffEntry `json:"changed"`
+	Unchanged       int                     `json:"unchanged"`
+	VolatileSkipped int                     `json:"volatile_skipped"`
+}
+
+func (SNMPSnapshot) TableName() string {
+	return "snmp_snapshots"
+}
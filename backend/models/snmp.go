@@ -3,20 +3,22 @@ package models
 import "time"
 
 type SNMPRequest struct {
-	Target    string            `json:"target" binding:"required"`
-	Port      int               `json:"port"`
-	Version   string            `json:"version" binding:"required"`
-	Community string            `json:"community"`
-	Username  string            `json:"username"`
-	AuthProto string            `json:"auth_proto"`
-	AuthKey   string            `json:"auth_key"`
-	PrivProto string            `json:"priv_proto"`
-	PrivKey   string            `json:"priv_key"`
-	OID       string            `json:"oid" binding:"required"`
-	Timeout   int               `json:"timeout"`
-	Retries   int               `json:"retries"`
-	MaxOIDs   int               `json:"max_oids"`
-	Context   map[string]string `json:"context"`
+	Target      string            `json:"target" binding:"required"`
+	Port        int               `json:"port"`
+	Transport   string            `json:"transport"` // udp, udp6, tcp, tcp6，默认 udp
+	Version     string            `json:"version" binding:"required"`
+	Community   string            `json:"community"`
+	Username    string            `json:"username"`
+	AuthProto   string            `json:"auth_proto"`
+	AuthKey     string            `json:"auth_key"`
+	PrivProto   string            `json:"priv_proto"`
+	PrivKey     string            `json:"priv_key"`
+	ContextName string            `json:"context_name"` // SNMPv3 上下文名称
+	OID         string            `json:"oid" binding:"required"`
+	Timeout     int               `json:"timeout"`
+	Retries     int               `json:"retries"`
+	MaxOIDs     int               `json:"max_oids"`
+	Context     map[string]string `json:"context"`
 }
 
 type SNMPResponse struct {
@@ -37,8 +39,76 @@ type SNMPResult struct {
 
 type SNMPSetRequest struct {
 	SNMPRequest
-	Value interface{} `json:"value" binding:"required"`
-	Type  string      `json:"type" binding:"required"`
+	Value    interface{} `json:"value" binding:"required"`
+	Type     string      `json:"type" binding:"required"`
+	DryRun   bool        `json:"dry_run"`
+	Operator string      `json:"operator"`
+}
+
+// SNMPSetValidation SET 操作的 MIB 校验结果
+type SNMPSetValidation struct {
+	Valid    bool     `json:"valid"`
+	Object   string   `json:"object"`
+	MIBOID   string   `json:"mib_oid"`
+	Access   string   `json:"access"`
+	Syntax   string   `json:"syntax"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+// SNMPSetResponse SET 操作响应，包含校验结果、写入前的值和审计记录 ID
+type SNMPSetResponse struct {
+	SNMPResponse
+	DryRun     bool               `json:"dry_run"`
+	AuditID    uint               `json:"audit_id"`
+	Previous   *SNMPResult        `json:"previous,omitempty"`
+	Validation *SNMPSetValidation `json:"validation"`
+}
+
+// SNMPRollbackRequest 回滚请求，凭据为空时使用目标设备上保存的凭据
+type SNMPRollbackRequest struct {
+	Transport string `json:"transport"`
+	Version   string `json:"version"`
+	Community string `json:"community"`
+	Username  string `json:"username"`
+	AuthProto string `json:"auth_proto"`
+	AuthKey   string `json:"auth_key"`
+	PrivProto string `json:"priv_proto"`
+	PrivKey   string `json:"priv_key"`
+	Timeout   int    `json:"timeout"`
+	Retries   int    `json:"retries"`
+	DryRun    bool   `json:"dry_run"`
+	Operator  string `json:"operator"`
+}
+
+// SNMPSetAudit SNMP SET 操作审计记录
+type SNMPSetAudit struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	DeviceID      *uint      `json:"device_id" gorm:"index"`
+	Target        string     `json:"target" gorm:"not null;index"`
+	Port          int        `json:"port"`
+	Transport     string     `json:"transport"`
+	Version       string     `json:"version"`
+	OID           string     `json:"oid" gorm:"not null;index"`
+	ObjectName    string     `json:"object_name"`
+	Type          string     `json:"type"`
+	Value         string     `json:"value" gorm:"type:text"`
+	HasPrevious   bool       `json:"has_previous"`
+	PreviousType  string     `json:"previous_type"`
+	PreviousValue string     `json:"previous_value" gorm:"type:text"`
+	DryRun        bool       `json:"dry_run"`
+	Status        string     `json:"status" gorm:"index"` // success, failed, rejected, dry_run, rolled_back
+	Error         string     `json:"error" gorm:"type:text"`
+	Operator      string     `json:"operator"` // 请求未提供时为 unknown
+	RollbackOfID  *uint      `json:"rollback_of_id"`
+	RolledBackAt  *time.Time `json:"rolled_back_at"`
+	Duration      string     `json:"duration"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (SNMPSetAudit) TableName() string {
+	return "snmp_set_audits"
 }
 
 type BulkOperation struct {
@@ -53,3 +123,97 @@ type BulkOperation struct {
 	EndTime   *time.Time             `json:"end_time"`
 	Config    map[string]interface{} `json:"config"`
 }
+
+// SNMPProbeCandidate 待探测的候选凭据，Version 为空时 community 依次尝试 v2c、v1
+type SNMPProbeCandidate struct {
+	Name      string `json:"name"`
+	Priority  int    `json:"priority"` // 数值越小越先尝试
+	Version   string `json:"version"`
+	Community string `json:"community"`
+	Username  string `json:"username"`
+	AuthProto string `json:"auth_proto"`
+	AuthKey   string `json:"auth_key"`
+	PrivProto string `json:"priv_proto"`
+	PrivKey   string `json:"priv_key"`
+}
+
+// SNMPProbeRequest 凭据探测请求，DeviceID 和 Target 二选一
+type SNMPProbeRequest struct {
+	DeviceID    *uint                `json:"device_id"`
+	Target      string               `json:"target"`
+	Port        int                  `json:"port"`
+	Transport   string               `json:"transport"`
+	Candidates  []SNMPProbeCandidate `json:"candidates" binding:"required,min=1"`
+	TimeoutMs   int                  `json:"timeout_ms"` // 单次尝试超时，默认 1000ms
+	Retries     int                  `json:"retries"`
+	StopOnFirst bool                 `json:"stop_on_first"`
+	Save        bool                 `json:"save"` // 将首个成功的凭据保存到设备
+}
+
+// SNMPProbeResult 单个候选凭据在某个版本上的探测结果，不回显密钥
+type SNMPProbeResult struct {
+	Candidate   int    `json:"candidate"` // 候选凭据在请求中的下标
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Username    string `json:"username,omitempty"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+	SysName     string `json:"sys_name,omitempty"`
+	SysObjectID string `json:"sys_object_id,omitempty"`
+	Duration    int64  `json:"duration"` // 毫秒
+}
+
+// SNMPProbeResponse 凭据探测结果
+type SNMPProbeResponse struct {
+	Target       string            `json:"target"`
+	DeviceID     *uint             `json:"device_id,omitempty"`
+	Results      []SNMPProbeResult `json:"results"`
+	Winner       *SNMPProbeResult  `json:"winner,omitempty"`
+	CredentialID *uint             `json:"credential_id,omitempty"`
+	SaveError    string            `json:"save_error,omitempty"`
+}
+
+// SNMPV3DiagnosticRequest v3 诊断请求，指定 DeviceID 且未提供用户名时使用设备保存的 v3 凭据
+type SNMPV3DiagnosticRequest struct {
+	DeviceID  *uint  `json:"device_id"`
+	Target    string `json:"target"`
+	Port      int    `json:"port"`
+	Transport string `json:"transport"`
+	Username  string `json:"username"`
+	AuthProto string `json:"auth_proto"`
+	AuthKey   string `json:"auth_key"`
+	PrivProto string `json:"priv_proto"`
+	PrivKey   string `json:"priv_key"`
+	Timeout   int    `json:"timeout"`
+	Retries   int    `json:"retries"`
+}
+
+// SNMPEngineInfo 引擎发现得到的权威引擎信息
+type SNMPEngineInfo struct {
+	EngineID   string `json:"engine_id"`  // 十六进制
+	Enterprise uint32 `json:"enterprise"` // RFC 3411 格式的企业号，非该格式时为 0
+	Format     string `json:"format"`     // ipv4, ipv6, mac, text, octets, enterprise, legacy
+	Boots      uint32 `json:"boots"`
+	Time       uint32 `json:"time"` // 自上次 boots 增加以来的秒数
+}
+
+// SNMPUSMReport 代理返回的 USM 报告 PDU
+type SNMPUSMReport struct {
+	OID     string `json:"oid"`
+	Name    string `json:"name"`
+	Counter uint64 `json:"counter"`
+}
+
+// SNMPV3Diagnosis v3 诊断结果
+type SNMPV3Diagnosis struct {
+	Target        string          `json:"target"`
+	Username      string          `json:"username"`
+	SecurityLevel string          `json:"security_level"` // noAuthNoPriv, authNoPriv, authPriv
+	Engine        *SNMPEngineInfo `json:"engine,omitempty"`
+	Success       bool            `json:"success"`
+	Status        string          `json:"status"` // ok, discovery_failed, unknown_user, wrong_digest, decryption_error, not_in_time_window, unsupported_security_level, timeout, error
+	Message       string          `json:"message"`
+	Report        *SNMPUSMReport  `json:"report,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Duration      int64           `json:"duration"` // 毫秒
+}
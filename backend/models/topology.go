@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// DeviceNeighbor 设备通过 LLDP 或 CDP 学到的邻居，每次采集整体替换
+type DeviceNeighbor struct {
+	ID                uint      `json:"id" gorm:"primaryKey"`
+	DeviceID          uint      `json:"device_id" gorm:"not null;index"`
+	Protocol          string    `json:"protocol"` // lldp, cdp
+	LocalPortNum      int       `json:"local_port_num"`
+	LocalPort         string    `json:"local_port"`
+	LocalInterfaceID  *uint     `json:"local_interface_id"`
+	RemoteChassisID   string    `json:"remote_chassis_id"`
+	RemoteSysName     string    `json:"remote_sys_name"`
+	RemoteSysDescr    string    `json:"remote_sys_descr" gorm:"type:text"`
+	RemotePortID      string    `json:"remote_port_id"`
+	RemotePortDescr   string    `json:"remote_port_descr"`
+	RemotePlatform    string    `json:"remote_platform"`
+	RemoteAddress     string    `json:"remote_address"`
+	RemoteDeviceID    *uint     `json:"remote_device_id" gorm:"index"`
+	RemoteInterfaceID *uint     `json:"remote_interface_id"`
+	LastSeen          time.Time `json:"last_seen"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// TopologyLinkEvent 链路出现或消失
+type TopologyLinkEvent struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	DeviceID       uint      `json:"device_id" gorm:"not null;index"`
+	EventType      string    `json:"event_type" gorm:"index"` // appeared, disappeared
+	Protocol       string    `json:"protocol"`
+	LocalPort      string    `json:"local_port"`
+	RemoteName     string    `json:"remote_name"`
+	RemotePort     string    `json:"remote_port"`
+	RemoteDeviceID *uint     `json:"remote_device_id"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+}
+
+// NeighborCollectResult 一次邻居采集的结果
+type NeighborCollectResult struct {
+	DeviceID  uint                `json:"device_id"`
+	Neighbors []DeviceNeighbor    `json:"neighbors"`
+	Protocols []string            `json:"protocols"` // 本次有数据的协议
+	Events    []TopologyLinkEvent `json:"events"`
+	Duration  int64               `json:"duration"` // 毫秒
+}
+
+// TopologyNode 拓扑节点，未纳管的邻居 known 为 false
+type TopologyNode struct {
+	ID       string `json:"id"`
+	DeviceID *uint  `json:"device_id,omitempty"`
+	Label    string `json:"label"`
+	IP       string `json:"ip,omitempty"`
+	Vendor   string `json:"vendor,omitempty"`
+	Model    string `json:"model,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Known    bool   `json:"known"`
+}
+
+// TopologyLink 两个节点之间的链路，两端各自上报的同一链路只保留一条
+type TopologyLink struct {
+	Source     string   `json:"source"`
+	Target     string   `json:"target"`
+	SourcePort string   `json:"source_port"`
+	TargetPort string   `json:"target_port"`
+	Protocols  []string `json:"protocols"`
+}
+
+// TopologyGraph 拓扑图
+type TopologyGraph struct {
+	Nodes []TopologyNode `json:"nodes"`
+	Links []TopologyLink `json:"links"`
+}
+
+func (DeviceNeighbor) TableName() string {
+	return "device_neighbors"
+}
+
+func (TopologyLinkEvent) TableName() string {
+	return "topology_link_events"
+}
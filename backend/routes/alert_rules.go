@@ -65,6 +65,8 @@ func RegisterAlertRulesRoutes(r *gin.Engine, controller *controllers.AlertRulesC
 		deviceGroups.POST("/:id/devices", controller.AddDevicesToGroup)    // 添加设备到分组
 		deviceGroups.DELETE("/:id/devices", controller.RemoveDevicesFromGroup) // 从分组移除设备
 		deviceGroups.GET("/:id/devices", controller.GetDeviceGroupDevices)    // 获取分组下的设备
+		deviceGroups.POST("/:id/refresh", controller.RefreshDeviceGroup)      // 按选择器重新计算成员
+		deviceGroups.POST("/preview", controller.PreviewDeviceGroup)          // 预览选择器匹配的设备
 
 		// 批量操作
 		deviceGroups.POST("/batch", controller.BatchCreateDeviceGroups) // 批量创建设备分组
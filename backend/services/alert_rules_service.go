@@ -397,6 +397,10 @@ func (s *AlertRulesService) GetDeviceGroupByID(id string) (*models.DeviceGroup,
 
 // CreateDeviceGroup 创建设备分组
 func (s *AlertRulesService) CreateDeviceGroup(req *models.CreateDeviceGroupRequest) (*models.DeviceGroup, error) {
+	if _, err := compileDeviceSelector(req.Selector); err != nil {
+		return nil, fmt.Errorf("选择器无效: %w", err)
+	}
+
 	group := &models.DeviceGroup{
 		ID:          uuid.New().String(),
 		Name:        req.Name,
@@ -419,6 +423,13 @@ func (s *AlertRulesService) CreateDeviceGroup(req *models.CreateDeviceGroupReque
 		return nil, fmt.Errorf("创建设备分组失败: %w", err)
 	}
 
+	// 有选择器时立即计算成员
+	if req.Selector != nil {
+		if _, err := syncDeviceGroup(s.db, group); err != nil {
+			return nil, fmt.Errorf("计算分组成员失败: %w", err)
+		}
+	}
+
 	s.logger.Info("创建设备分组成功", "group_id", group.ID, "name", group.Name)
 	return group, nil
 }
@@ -442,6 +453,9 @@ func (s *AlertRulesService) UpdateDeviceGroup(id string, req *models.UpdateDevic
 		updates["tags"] = models.JSON(tagsJSON)
 	}
 	if req.Selector != nil {
+		if _, err := compileDeviceSelector(req.Selector); err != nil {
+			return nil, fmt.Errorf("选择器无效: %w", err)
+		}
 		selectorJSON, _ := json.Marshal(req.Selector)
 		updates["selector"] = models.JSON(selectorJSON)
 	}
@@ -452,6 +466,14 @@ func (s *AlertRulesService) UpdateDeviceGroup(id string, req *models.UpdateDevic
 		return nil, fmt.Errorf("更新设备分组失败: %w", err)
 	}
 
+	// 选择器变化后重新计算成员，清空选择器会移除之前按选择器加入的设备
+	if req.Selector != nil {
+		group.Selector = updates["selector"].(models.JSON)
+		if _, err := syncDeviceGroup(s.db, group); err != nil {
+			return nil, fmt.Errorf("计算分组成员失败: %w", err)
+		}
+	}
+
 	s.logger.Info("更新设备分组成功", "group_id", group.ID, "name", group.Name)
 	return group, nil
 }
@@ -486,6 +508,31 @@ func (s *AlertRulesService) DeleteDeviceGroup(id string) error {
 	return nil
 }
 
+// PreviewDeviceGroup 预览选择器匹配的设备，不保存
+func (s *AlertRulesService) PreviewDeviceGroup(selector map[string]interface{}) (*models.DeviceGroupPreview, error) {
+	preview, err := previewDeviceSelector(s.db, selector)
+	if err != nil {
+		return nil, fmt.Errorf("选择器无效: %w", err)
+	}
+	return preview, nil
+}
+
+// RefreshDeviceGroup 按选择器重新计算分组成员
+func (s *AlertRulesService) RefreshDeviceGroup(id string) (*models.DeviceGroupSyncResult, error) {
+	group, err := s.GetDeviceGroupByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := syncDeviceGroup(s.db, group)
+	if err != nil {
+		return nil, fmt.Errorf("计算分组成员失败: %w", err)
+	}
+
+	s.logger.Info("重新计算设备分组成员", "group_id", group.ID, "matched", result.Matched, "added", result.Added, "removed", result.Removed)
+	return result, nil
+}
+
 // AddDevicesToGroup 添加设备到分组
 func (s *AlertRulesService) AddDevicesToGroup(groupID string, deviceIDs []string) error {
 	// 检查分组是否存在
@@ -529,8 +576,45 @@ func (s *AlertRulesService) RemoveDevicesFromGroup(groupID string, deviceIDs []s
 	return nil
 }
 
-// GetAlertmanagerConfig 获取Alertmanager配置
+// alertmanagerSecretFields Alertmanager 配置中加密存储、接口中返回掩码的字段（global 和 receivers 下的同名字段）
+var alertmanagerSecretFields = map[string]bool{
+	"smtp_auth_password": true,
+	"smtp_auth_secret":   true,
+	"auth_password":      true,
+	"auth_secret":        true,
+	"password":           true,
+	"bearer_token":       true,
+	"api_key":            true,
+	"api_secret":         true,
+	"routing_key":        true,
+	"service_key":        true,
+	"bot_token":          true,
+	"token":              true,
+	"user_key":           true,
+	"wechat_api_secret":  true,
+	"opsgenie_api_key":   true,
+	"victorops_api_key":  true,
+}
+
+// GetAlertmanagerConfig 获取Alertmanager配置，敏感字段返回掩码
 func (s *AlertRulesService) GetAlertmanagerConfig() (*models.AlertmanagerConfig, error) {
+	config, err := s.activeAlertmanagerConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	mask := func(value, _ string) (string, error) { return utils.MaskSecret(value), nil }
+	if config.Global, err = transformSecretJSON(config.Global, nil, alertmanagerSecretFields, mask); err != nil {
+		return nil, fmt.Errorf("解析Alertmanager配置失败: %w", err)
+	}
+	if config.Receivers, err = transformSecretJSON(config.Receivers, nil, alertmanagerSecretFields, mask); err != nil {
+		return nil, fmt.Errorf("解析Alertmanager配置失败: %w", err)
+	}
+	return config, nil
+}
+
+// activeAlertmanagerConfig 获取当前生效的配置，敏感字段保持数据库中的密文
+func (s *AlertRulesService) activeAlertmanagerConfig() (*models.AlertmanagerConfig, error) {
 	var config models.AlertmanagerConfig
 	if err := s.db.Where("status = ?", "active").First(&config).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -544,15 +628,30 @@ func (s *AlertRulesService) GetAlertmanagerConfig() (*models.AlertmanagerConfig,
 
 // UpdateAlertmanagerConfig 更新Alertmanager配置
 func (s *AlertRulesService) UpdateAlertmanagerConfig(req *models.UpdateAlertmanagerConfigRequest) (*models.AlertmanagerConfig, error) {
-	config, err := s.GetAlertmanagerConfig()
+	config, err := s.activeAlertmanagerConfig()
 	if err != nil {
 		return nil, err
 	}
 
+	// 敏感字段加密存储；提交回来的掩码还原为原来的密文
+	seal := func(value, prev string) (string, error) {
+		if value == utils.SecretMask {
+			return prev, nil
+		}
+		if utils.IsEncryptedSecret(value) {
+			return value, nil
+		}
+		return utils.EncryptSecret(value)
+	}
+
 	updates := make(map[string]interface{})
 	if req.Global != nil {
 		globalJSON, _ := json.Marshal(req.Global)
-		updates["global"] = models.JSON(globalJSON)
+		sealed, err := transformSecretJSON(models.JSON(globalJSON), config.Global, alertmanagerSecretFields, seal)
+		if err != nil {
+			return nil, fmt.Errorf("加密Alertmanager配置失败: %w", err)
+		}
+		updates["global"] = sealed
 	}
 	if req.Route != nil {
 		routeJSON, _ := json.Marshal(req.Route)
@@ -560,7 +659,11 @@ func (s *AlertRulesService) UpdateAlertmanagerConfig(req *models.UpdateAlertmana
 	}
 	if req.Receivers != nil {
 		receiversJSON, _ := json.Marshal(req.Receivers)
-		updates["receivers"] = models.JSON(receiversJSON)
+		sealed, err := transformSecretJSON(models.JSON(receiversJSON), config.Receivers, alertmanagerSecretFields, seal)
+		if err != nil {
+			return nil, fmt.Errorf("加密Alertmanager配置失败: %w", err)
+		}
+		updates["receivers"] = sealed
 	}
 	if req.InhibitRules != nil {
 		inhibitRulesJSON, _ := json.Marshal(req.InhibitRules)
@@ -579,7 +682,7 @@ func (s *AlertRulesService) UpdateAlertmanagerConfig(req *models.UpdateAlertmana
 	}
 
 	s.logger.Info("更新Alertmanager配置成功", "config_id", config.ID)
-	return config, nil
+	return s.GetAlertmanagerConfig()
 }
 
 // SyncConfig 同步配置到Prometheus/Alertmanager
@@ -658,19 +761,69 @@ func (s *AlertRulesService) GetSyncHistory(page, limit int) ([]models.SyncHistor
 	return history, total, nil
 }
 
-// DiscoverDevices 设备自动发现
+// DiscoverDevices 汇总 SNMP 发现任务登记的设备：time_range（默认 1h）内首次发现的为新设备，
+// 此前已发现且期间再次响应的为更新设备，超过 time_range 未响应的标记为离线；
+// force 为 true 时先重新执行已结束的发现任务，扫描是异步的，新结果在任务完成后再次查询可见
 func (s *AlertRulesService) DiscoverDevices(req *models.DiscoverDevicesRequest) (*models.DiscoverDevicesResponse, error) {
-	// TODO: 实现设备发现逻辑
-	// 1. 从VictoriaMetrics查询up指标
-	// 2. 解析instance和job标签
-	// 3. 通过SNMP获取设备信息
-	// 4. 更新设备状态
+	window := time.Hour
+	if req.TimeRange != "" {
+		d, err := time.ParseDuration(req.TimeRange)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("无效的时间范围: %s", req.TimeRange)
+		}
+		window = d
+	}
+
+	if req.Force {
+		var tasks []models.SNMPDiscoveryTask
+		if err := s.db.Where("status IN ?", []string{"completed", "failed"}).Find(&tasks).Error; err != nil {
+			return nil, fmt.Errorf("获取发现任务失败: %w", err)
+		}
+		// 发现任务只用到数据库，不需要 redis
+		discovery := NewSNMPDiscoveryService(s.db, nil)
+		for _, task := range tasks {
+			if err := discovery.StartTask(task.ID); err != nil {
+				s.logger.Error("重新执行发现任务失败", "task", task.ID, "error", err)
+			}
+		}
+	}
+
+	query := s.db.Model(&models.DiscoveredDevice{})
+	if req.JobFilter != "" {
+		query = query.Where("job = ?", req.JobFilter)
+	}
+	var devices []models.DiscoveredDevice
+	if err := query.Order("last_seen DESC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("获取发现设备失败: %w", err)
+	}
 
 	response := &models.DiscoverDevicesResponse{
 		NewDevices:     make([]models.DiscoveredDevice, 0),
 		UpdatedDevices: make([]models.DiscoveredDevice, 0),
 		OfflineDevices: make([]models.DiscoveredDevice, 0),
+		TotalScanned:   len(devices),
+	}
+	since := time.Now().Add(-window)
+	for _, device := range devices {
+		switch {
+		case device.LastSeen.Before(since):
+			if device.Status != "offline" {
+				// UpdateColumn 不会刷新 last_seen
+				if err := s.db.Model(&device).UpdateColumn("status", "offline").Error; err != nil {
+					return nil, fmt.Errorf("更新发现设备状态失败: %w", err)
+				}
+				device.Status = "offline"
+			}
+			response.OfflineDevices = append(response.OfflineDevices, device)
+		case device.FirstSeen.Before(since):
+			response.UpdatedDevices = append(response.UpdatedDevices, device)
+		default:
+			response.NewDevices = append(response.NewDevices, device)
+		}
 	}
+	response.NewCount = len(response.NewDevices)
+	response.UpdatedCount = len(response.UpdatedDevices)
+	response.OfflineCount = len(response.OfflineDevices)
 
 	s.logger.Info("设备发现完成", "new", response.NewCount, "updated", response.UpdatedCount, "offline", response.OfflineCount)
 	return response, nil
@@ -819,7 +972,7 @@ func (s *AlertRulesService) syncPrometheusRules(force bool) (string, error) {
 
 // syncAlertmanagerConfig 同步Alertmanager配置
 func (s *AlertRulesService) syncAlertmanagerConfig(force bool) (string, error) {
-	// TODO: 实现Alertmanager配置同步
+	// TODO: 实现Alertmanager配置同步；global 和 receivers 中的敏感字段是密文，生成配置文件前需用 utils.DecryptSecret 解密
 	return "alertmanager-hash", nil
 }
 
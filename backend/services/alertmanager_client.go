@@ -0,0 +1,88 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// alertmanagerClient Alertmanager v2 API 的静默接口
+type alertmanagerClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+type alertmanagerMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+type alertmanagerSilence struct {
+	Matchers  []alertmanagerMatcher `json:"matchers"`
+	StartsAt  time.Time             `json:"startsAt"`
+	EndsAt    time.Time             `json:"endsAt"`
+	CreatedBy string                `json:"createdBy"`
+	Comment   string                `json:"comment"`
+}
+
+// newAlertmanagerClient baseURL 为空时返回 nil，表示未配置 Alertmanager
+func newAlertmanagerClient(baseURL string) *alertmanagerClient {
+	if baseURL == "" {
+		return nil
+	}
+	return &alertmanagerClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// createSilence 创建静默并返回静默 ID
+func (c *alertmanagerClient) createSilence(silence *alertmanagerSilence) (string, error) {
+	body, err := json.Marshal(silence)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient.Post(c.baseURL+"/api/v2/silences", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create silence: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to create silence: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var result struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse silence response: %v", err)
+	}
+	return result.SilenceID, nil
+}
+
+// expireSilence 立即结束静默，静默不存在时视为成功
+func (c *alertmanagerClient) expireSilence(id string) error {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+"/api/v2/silence/"+url.PathEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to expire silence %s: %v", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to expire silence %s: %s: %s", id, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"golang.org/x/crypto/ssh"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+var ErrConfigBackupNotConfigured = errors.New("config backup is not configured for device")
+
+const (
+	configBackupTimeout = 60 * time.Second
+	configDiffContext   = 3
+)
+
+// configBackupProfile 厂商的配置备份方式。命令通过 SSH exec 通道执行，不分配终端，设备不会分页输出
+type configBackupProfile struct {
+	name    string
+	vendors []string // 与设备厂商或系统类型（小写）做包含匹配
+	command string
+	ignore  []*regexp.Regexp // 每次输出都会变化的行（时间戳、字节数等），比较版本时忽略
+	secrets []*regexp.Regexp // 第一个分组之后的值替换为掩码
+	keep    []*regexp.Regexp // 与 secrets 匹配但不是密钥的内容，保持原样
+}
+
+var (
+	// 终端控制字符和残留的分页提示
+	configNoisePattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]| ?-{2,} ?[Mm]ore ?-{2,}[ \x08]*`)
+
+	huaweiSecretPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`((?:^|\s)(?:cipher|simple|irreversible-cipher|hash) )\S+`),
+		regexp.MustCompile(`(snmp-agent community (?:read|write) (?:(?:cipher|simple) )?)\S+`),
+	}
+
+	configBackupProfiles = []configBackupProfile{
+		{
+			name:    "cisco",
+			vendors: []string{"cisco", "ios", "nx-os"},
+			command: "show running-config",
+			ignore: []*regexp.Regexp{
+				regexp.MustCompile(`^Building configuration`),
+				regexp.MustCompile(`^Current configuration :`),
+				regexp.MustCompile(`^! (?:Last configuration change|NVRAM config last updated|No configuration change)`),
+				regexp.MustCompile(`^!(?:Time:|Running configuration last done)`),
+				regexp.MustCompile(`^ntp clock-period`),
+			},
+			secrets: []*regexp.Regexp{
+				regexp.MustCompile(`(?m)^(\s*enable (?:secret|password)(?: level \d+)?(?: \d)? )\S+`),
+				regexp.MustCompile(`(?m)^(\s*username \S+(?: privilege \d+)? (?:secret|password)(?: \d)? )\S+`),
+				regexp.MustCompile(`(?m)^(\s*password(?: \d)? )\S+`),
+				regexp.MustCompile(`(?m)^(\s*snmp-server community )\S+`),
+				regexp.MustCompile(`(?m)^(\s*(?:tacacs-server|radius-server) .*key(?: \d)? )\S+`),
+				regexp.MustCompile(`(?m)^(\s*key-string(?: \d)? )\S+`),
+				regexp.MustCompile(`(?m)^(\s*key (?:\d )?)\S+`),
+				regexp.MustCompile(`(?m)^(\s*crypto isakmp key(?: \d)? )\S+`),
+				regexp.MustCompile(`(?m)(\spre-shared-key(?: local| remote)?(?: \d)? )\S+`),
+				regexp.MustCompile(`(?m)(\sip ospf (?:authentication-key|message-digest-key \d+ md5)(?: \d)? )\S+`),
+				regexp.MustCompile(`(?m)(\sneighbor \S+ password(?: \d)? )\S+`),
+				regexp.MustCompile(`(\s(?:auth (?:md5|sha)|priv (?:des|3des|aes(?: \d+)?)) )\S+`),
+			},
+			// key chain <name> 和 key chain 中的 key <id> 不是密钥
+			keep: []*regexp.Regexp{
+				regexp.MustCompile(`^\s*key (?:\d+|chain)$`),
+			},
+		},
+		{
+			name:    "huawei",
+			vendors: []string{"huawei", "vrp"},
+			command: "display current-configuration",
+			ignore: []*regexp.Regexp{
+				regexp.MustCompile(`^!(?:Software Version|Last configuration was|Time:)`),
+			},
+			secrets: huaweiSecretPatterns,
+		},
+		{
+			name:    "h3c",
+			vendors: []string{"h3c", "comware"},
+			command: "display current-configuration",
+			secrets: huaweiSecretPatterns,
+		},
+		{
+			name:    "juniper",
+			vendors: []string{"juniper", "junos"},
+			command: "show configuration | display set | no-more",
+			ignore: []*regexp.Regexp{
+				regexp.MustCompile(`^## Last (?:commit|changed):`),
+			},
+			secrets: []*regexp.Regexp{
+				regexp.MustCompile(`((?:encrypted-password|authentication-key|privacy-key|secret|simple-password|ascii-text|hexadecimal|md5 \d+ key) )(?:"[^"]*"|\S+)`),
+				regexp.MustCompile(`((?:^|\s)set snmp community )\S+`),
+			},
+		},
+	}
+)
+
+// ConfigBackupService 通过 SSH 定时备份网络设备运行配置，保存变化的版本并生成 diff
+type ConfigBackupService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	hostService *HostService
+	collector   *deviceCollector
+}
+
+// NewConfigBackupService 创建配置备份服务，interval 单位为秒，0 表示不定时备份
+func NewConfigBackupService(db *gorm.DB, redis *redis.Client, logger utils.Logger, interval int) *ConfigBackupService {
+	s := &ConfigBackupService{
+		db:          db,
+		redis:       redis,
+		hostService: NewHostService(db, redis),
+	}
+	s.collector = newDeviceCollector("Config backup", db, logger, interval, func(deviceID uint) error {
+		_, err := s.BackupDevice(deviceID)
+		return err
+	})
+	s.collector.targets = func() ([]uint, error) {
+		var ids []uint
+		err := db.Model(&models.Device{}).Where("ssh_credential_id IS NOT NULL").Pluck("id", &ids).Error
+		return ids, err
+	}
+	return s
+}
+
+// Start 启动定时备份
+func (s *ConfigBackupService) Start() {
+	s.collector.Start()
+}
+
+// Stop 停止定时备份
+func (s *ConfigBackupService) Stop() {
+	s.collector.Stop()
+}
+
+// GetProfiles 返回内置的厂商备份配置
+func (s *ConfigBackupService) GetProfiles() []models.ConfigBackupProfile {
+	profiles := make([]models.ConfigBackupProfile, 0, len(configBackupProfiles))
+	for _, p := range configBackupProfiles {
+		profiles = append(profiles, models.ConfigBackupProfile{Name: p.name, Vendors: p.vendors, Command: p.command})
+	}
+	return profiles
+}
+
+// BackupDevice 登录设备读取运行配置，与最新版本不同时保存新版本并记录变更事件，失败时记录 failed 事件
+func (s *ConfigBackupService) BackupDevice(deviceID uint) (*models.ConfigBackupResult, error) {
+	device, err := NewDeviceService(s.db, s.redis).GetDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	profile, content, err := s.fetchConfig(device)
+	if err != nil {
+		if !errors.Is(err, ErrConfigBackupNotConfigured) {
+			s.db.Create(&models.DeviceConfigEvent{DeviceID: deviceID, EventType: "failed", Error: err.Error()})
+		}
+		return nil, err
+	}
+
+	stable := profile.stableLines(content)
+	digest := sha256.Sum256([]byte(strings.Join(stable, "\n")))
+	hash := hex.EncodeToString(digest[:])
+	now := time.Now()
+	result := &models.ConfigBackupResult{DeviceID: deviceID}
+
+	latest, err := s.latestBackup(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.Hash == hash {
+		if err := s.db.Model(latest).Update("last_checked_at", now).Error; err != nil {
+			return nil, fmt.Errorf("failed to update config backup: %v", err)
+		}
+		latest.Content = ""
+		result.Backup = latest
+		result.Duration = time.Since(start).Milliseconds()
+		return result, nil
+	}
+
+	backup := &models.DeviceConfigBackup{
+		DeviceID:      deviceID,
+		Version:       1,
+		Profile:       profile.name,
+		Command:       profile.command,
+		Content:       content,
+		Hash:          hash,
+		Lines:         len(splitLines(content)),
+		LastCheckedAt: now,
+	}
+	var event *models.DeviceConfigEvent
+	if latest != nil {
+		backup.Version = latest.Version + 1
+		event = &models.DeviceConfigEvent{
+			DeviceID:    deviceID,
+			EventType:   "changed",
+			FromVersion: latest.Version,
+			ToVersion:   backup.Version,
+		}
+		event.Diff, event.Added, event.Removed = unifiedDiff(
+			fmt.Sprintf("version %d", latest.Version), fmt.Sprintf("version %d", backup.Version),
+			profile.stableLines(latest.Content), stable, configDiffContext)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(backup).Error; err != nil {
+			return err
+		}
+		if event != nil {
+			return tx.Create(event).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save config backup: %v", err)
+	}
+
+	backup.Content = ""
+	result.Backup = backup
+	result.Changed = true
+	result.Event = event
+	result.Duration = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// fetchConfig 通过 SSH 执行厂商命令，返回清理并掩码后的配置
+func (s *ConfigBackupService) fetchConfig(device *models.Device) (*configBackupProfile, string, error) {
+	if device.SSHCredentialID == nil {
+		return nil, "", fmt.Errorf("%w: %s has no SSH credential", ErrConfigBackupNotConfigured, device.Name)
+	}
+	profile, err := findBackupProfile(device)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var credential models.HostCredential
+	if err := s.db.First(&credential, *device.SSHCredentialID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", fmt.Errorf("%w: SSH credential %d not found", ErrConfigBackupNotConfigured, *device.SSHCredentialID)
+		}
+		return nil, "", err
+	}
+
+	port := device.SSHPort
+	if port == 0 {
+		port = 22
+	}
+	client, err := s.hostService.createSSHClient(device.IPAddress, port, credential.Username, credential.Password, credential.PrivateKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to connect to %s: %v", device.IPAddress, err)
+	}
+	defer client.Close()
+
+	output, err := runBackupCommand(client, profile.command)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to run %q on %s: %v", profile.command, device.IPAddress, err)
+	}
+
+	content := profile.clean(output)
+	if content == "" {
+		return nil, "", fmt.Errorf("%q returned no configuration on %s", profile.command, device.IPAddress)
+	}
+	return profile, content, nil
+}
+
+// runBackupCommand 执行命令，超时后关闭连接，避免设备无响应时阻塞定时备份
+func runBackupCommand(client *ssh.Client, command string) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	timer := time.AfterFunc(configBackupTimeout, func() {
+		client.Close()
+	})
+
+	output, err := session.CombinedOutput(command)
+	if !timer.Stop() {
+		return "", fmt.Errorf("timed out after %s", configBackupTimeout)
+	}
+	// 部分设备输出配置后以非零状态退出，有输出时仍然保存
+	var exitErr *ssh.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || len(output) == 0) {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// findBackupProfile 优先使用设备指定的 backup_profile，否则按厂商和系统类型匹配
+func findBackupProfile(device *models.Device) (*configBackupProfile, error) {
+	if device.BackupProfile != "" {
+		for i := range configBackupProfiles {
+			if strings.EqualFold(configBackupProfiles[i].name, device.BackupProfile) {
+				return &configBackupProfiles[i], nil
+			}
+		}
+		return nil, fmt.Errorf("%w: unknown backup profile %s", ErrConfigBackupNotConfigured, device.BackupProfile)
+	}
+
+	identity := strings.ToLower(device.Vendor + " " + device.OSFamily)
+	for i := range configBackupProfiles {
+		for _, vendor := range configBackupProfiles[i].vendors {
+			if strings.Contains(identity, vendor) {
+				return &configBackupProfiles[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%w: no backup profile matches vendor %q, set backup_profile on the device", ErrConfigBackupNotConfigured, device.Vendor)
+}
+
+// clean 去掉控制字符、行尾空白和首尾空行，并掩码敏感值
+func (p *configBackupProfile) clean(output string) string {
+	output = configNoisePattern.ReplaceAllString(strings.ReplaceAll(output, "\r\n", "\n"), "")
+	lines := strings.Split(output, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	content := strings.Trim(strings.Join(lines, "\n"), "\n")
+
+	for _, pattern := range p.secrets {
+		pattern := pattern
+		content = pattern.ReplaceAllStringFunc(content, func(match string) string {
+			if matchesAny(p.keep, match) {
+				return match
+			}
+			return pattern.ReplaceAllString(match, "${1}"+utils.SecretMask)
+		})
+	}
+	if content == "" {
+		return ""
+	}
+	return content + "\n"
+}
+
+// stableLines 返回参与比较的行，忽略每次备份都会变化的行
+func (p *configBackupProfile) stableLines(content string) []string {
+	lines := splitLines(content)
+	stable := lines[:0:0]
+	for _, line := range lines {
+		if !matchesAny(p.ignore, line) {
+			stable = append(stable, line)
+		}
+	}
+	return stable
+}
+
+func matchesAny(patterns []*regexp.Regexp, line string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ConfigBackupService) latestBackup(deviceID uint) (*models.DeviceConfigBackup, error) {
+	var backup models.DeviceConfigBackup
+	err := s.db.Where("device_id = ?", deviceID).Order("version DESC").First(&backup).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// GetBackups 分页获取设备的备份版本，不包含配置内容
+func (s *ConfigBackupService) GetBackups(deviceID uint, page, limit int) ([]models.DeviceConfigBackup, int64, error) {
+	var backups []models.DeviceConfigBackup
+	var total int64
+
+	query := s.db.Model(&models.DeviceConfigBackup{}).Where("device_id = ?", deviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Omit("content").Order("version DESC").Offset(offset).Limit(limit).Find(&backups).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return backups, total, nil
+}
+
+// GetBackup 获取指定版本的配置内容，version 为 0 时返回最新版本
+func (s *ConfigBackupService) GetBackup(deviceID uint, version int) (*models.DeviceConfigBackup, error) {
+	var backup models.DeviceConfigBackup
+	query := s.db.Where("device_id = ?", deviceID)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+	if err := query.Order("version DESC").First(&backup).Error; err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
+
+// DiffBackups 比较两个版本，to 为 0 时取最新版本，from 为 0 时取 to 的上一个版本；
+// 第一个版本没有上一个版本，与空配置比较
+func (s *ConfigBackupService) DiffBackups(deviceID uint, from, to int) (*models.DeviceConfigDiff, error) {
+	target, err := s.GetBackup(deviceID, to)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		from = target.Version - 1
+	}
+	base := &models.DeviceConfigBackup{}
+	if from > 0 {
+		if base, err = s.GetBackup(deviceID, from); err != nil {
+			return nil, err
+		}
+	}
+
+	profile := &configBackupProfile{name: target.Profile}
+	for i := range configBackupProfiles {
+		if configBackupProfiles[i].name == target.Profile {
+			profile = &configBackupProfiles[i]
+		}
+	}
+
+	result := &models.DeviceConfigDiff{DeviceID: deviceID, FromVersion: base.Version, ToVersion: target.Version}
+	result.Diff, result.Added, result.Removed = unifiedDiff(
+		fmt.Sprintf("version %d", base.Version), fmt.Sprintf("version %d", target.Version),
+		profile.stableLines(base.Content), profile.stableLines(target.Content), configDiffContext)
+	return result, nil
+}
+
+// GetEvents 获取配置备份事件，deviceID 为 0 时查询全部设备
+func (s *ConfigBackupService) GetEvents(deviceID uint, eventType string, page, limit int) ([]models.DeviceConfigEvent, int64, error) {
+	var events []models.DeviceConfigEvent
+	var total int64
+
+	query := s.db.Model(&models.DeviceConfigEvent{})
+	if deviceID != 0 {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
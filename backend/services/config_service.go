@@ -11,8 +11,10 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/pelletier/go-toml/v2"
 	"gopkg.in/yaml.v3"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"mib-platform/models"
 )
@@ -59,20 +61,53 @@ func (s *ConfigService) GetConfig(id uint) (*models.Config, error) {
 	return &config, nil
 }
 
+// CreateConfig 创建配置，有内容时记录第一个版本
 func (s *ConfigService) CreateConfig(config *models.Config) error {
-	return s.db.Create(config).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(config).Error; err != nil {
+			return err
+		}
+		if config.Content == "" {
+			return nil
+		}
+		version := &models.ConfigVersion{ConfigID: config.ID, Version: config.Version, Content: config.Content}
+		if err := recordConfigVersion(tx, version); err != nil {
+			return err
+		}
+		config.Version = version.Version
+		return nil
+	})
 }
 
-func (s *ConfigService) UpdateConfig(id uint, updates *models.Config) (*models.Config, error) {
+// UpdateConfig 修改配置，内容变化时记录新版本
+func (s *ConfigService) UpdateConfig(id uint, req *models.UpdateConfigRequest) (*models.Config, error) {
 	var config models.Config
-	if err := s.db.First(&config, id).Error; err != nil {
-		return nil, err
-	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&config, id).Error; err != nil {
+			return err
+		}
+		previous := config.Content
 
-	if err := s.db.Model(&config).Updates(updates).Error; err != nil {
+		if err := tx.Model(&config).Omit(clause.Associations).Updates(&req.Config).Error; err != nil {
+			return err
+		}
+		if req.Content != "" && req.Content != previous {
+			err := recordConfigVersion(tx, &models.ConfigVersion{
+				ConfigID:  id,
+				Version:   req.Version,
+				Content:   req.Content,
+				Changes:   req.Changes,
+				CreatedBy: req.Author,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return tx.First(&config, id).Error
+	})
+	if err != nil {
 		return nil, err
 	}
-
 	return &config, nil
 }
 
@@ -96,89 +131,145 @@ type DeviceInfo struct {
 	Version   string `json:"version"`
 	Name      string `json:"name,omitempty"`
 	Port      int    `json:"port,omitempty"`
+	Transport string `json:"transport,omitempty"` // udp, udp6, tcp, tcp6
+
+	// SNMPv3 认证信息
+	Username      string `json:"username,omitempty"`
+	SecurityLevel string `json:"security_level,omitempty"` // noAuthNoPriv, authNoPriv, authPriv，为空时按是否提供密钥推断
+	AuthProto     string `json:"auth_proto,omitempty"`
+	AuthKey       string `json:"auth_key,omitempty"`
+	PrivProto     string `json:"priv_proto,omitempty"`
+	PrivKey       string `json:"priv_key,omitempty"`
+	ContextName   string `json:"context_name,omitempty"`
 }
 
-// SNMP Exporter 配置结构
+// SNMP Exporter 配置结构。0.23 之后的格式把认证信息放在顶层 auths 中，模块不再包含 auth 和 version
 type SNMPExporterConfig struct {
+	Auths   map[string]SNMPAuth   `yaml:"auths,omitempty"`
 	Modules map[string]SNMPModule `yaml:"modules"`
 }
 
 type SNMPModule struct {
 	Walk    []string          `yaml:"walk"`
 	Metrics []SNMPMetric      `yaml:"metrics"`
-	Auth    SNMPAuth          `yaml:"auth"`
-	Version int               `yaml:"version"`
+	Auth    *SNMPAuth         `yaml:"auth,omitempty"`    // 仅旧格式
+	Version int               `yaml:"version,omitempty"` // 仅旧格式
 	Timeout string            `yaml:"timeout,omitempty"`
 	Retries int               `yaml:"retries,omitempty"`
 }
 
 type SNMPMetric struct {
-	Name    string            `yaml:"name"`
-	OID     string            `yaml:"oid"`
-	Type    string            `yaml:"type"`
-	Help    string            `yaml:"help"`
-	Indexes []SNMPIndex       `yaml:"indexes,omitempty"`
-	Lookups []SNMPLookup      `yaml:"lookups,omitempty"`
+	Name           string                         `yaml:"name"`
+	OID            string                         `yaml:"oid"`
+	Type           string                         `yaml:"type"`
+	Help           string                         `yaml:"help"`
+	Indexes        []SNMPIndex                    `yaml:"indexes,omitempty"`
+	Lookups        []SNMPLookup                   `yaml:"lookups,omitempty"`
+	RegexpExtracts map[string][]SNMPRegexpExtract `yaml:"regex_extracts,omitempty"`
+	EnumValues     map[int]string                 `yaml:"enum_values,omitempty"`
 }
 
 type SNMPIndex struct {
 	LabelName string `yaml:"labelname"`
 	Type      string `yaml:"type"`
+	FixedSize int    `yaml:"fixed_size,omitempty"`
+	Implied   bool   `yaml:"implied,omitempty"`
 }
 
+// SNMPLookup 用索引查询同表的其他列作为标签；labels 为空且没有 oid 时表示删除该标签
 type SNMPLookup struct {
 	Labels    []string `yaml:"labels"`
 	LabelName string   `yaml:"labelname"`
-	OID       string   `yaml:"oid"`
-	Type      string   `yaml:"type"`
+	OID       string   `yaml:"oid,omitempty"`
+	Type      string   `yaml:"type,omitempty"`
 }
 
-type SNMPAuth struct {
-	Community string `yaml:"community"`
+// SNMPRegexpExtract 从字符串值中用正则提取数值，value 可以引用捕获组
+type SNMPRegexpExtract struct {
+	Value string `yaml:"value" json:"value"`
+	Regex string `yaml:"regex" json:"regex"`
 }
 
-// Categraf SNMP 配置结构
+// SNMPAuth snmp_exporter 的认证配置，version 只在新格式的 auths 中使用
+type SNMPAuth struct {
+	Community     string `yaml:"community,omitempty"`
+	SecurityLevel string `yaml:"security_level,omitempty"`
+	Username      string `yaml:"username,omitempty"`
+	Password      string `yaml:"password,omitempty"`
+	AuthProtocol  string `yaml:"auth_protocol,omitempty"`
+	PrivProtocol  string `yaml:"priv_protocol,omitempty"`
+	PrivPassword  string `yaml:"priv_password,omitempty"`
+	ContextName   string `yaml:"context_name,omitempty"`
+	Version       int    `yaml:"version,omitempty"`
+}
+
+// Telegraf inputs.snmp 配置结构，field 和 table 的格式与 Categraf 相同
+type TelegrafConfig struct {
+	Inputs struct {
+		SNMP []TelegrafSNMPInput `toml:"snmp"`
+	} `toml:"inputs"`
+}
+
+type TelegrafSNMPInput struct {
+	Agents       []string            `toml:"agents"`
+	AgentHostTag string              `toml:"agent_host_tag,omitempty"`
+	Version      int                 `toml:"version"`
+	Community    string              `toml:"community,omitempty"`
+	SecName      string              `toml:"sec_name,omitempty"`
+	SecLevel     string              `toml:"sec_level,omitempty"`
+	AuthProtocol string              `toml:"auth_protocol,omitempty"`
+	AuthPassword string              `toml:"auth_password,omitempty"`
+	PrivProtocol string              `toml:"priv_protocol,omitempty"`
+	PrivPassword string              `toml:"priv_password,omitempty"`
+	ContextName  string              `toml:"context_name,omitempty"`
+	Timeout      string              `toml:"timeout"`
+	Retries      int                 `toml:"retries"`
+	Fields       []CategrafSNMPField `toml:"field,omitempty"`
+	Tables       []CategrafSNMPTable `toml:"table,omitempty"`
+}
+
+// Categraf SNMP 配置结构，与 categraf inputs/snmp 的 snmp.toml 一致
 type CategrafSNMPConfig struct {
 	Instances []CategrafSNMPInstance `toml:"instances"`
 }
 
 type CategrafSNMPInstance struct {
-	Agents    []string              `toml:"agents"`
-	Version   int                   `toml:"version"`
-	Community string                `toml:"community"`
-	Fields    []CategrafSNMPField   `toml:"field"`
-	Tables    []CategrafSNMPTable   `toml:"table"`
-	Timeout   string                `toml:"timeout"`
-	Retries   int                   `toml:"retries"`
+	Agents       []string            `toml:"agents"`
+	Version      int                 `toml:"version"`
+	Community    string              `toml:"community,omitempty"`
+	SecName      string              `toml:"sec_name,omitempty"`
+	SecLevel     string              `toml:"sec_level,omitempty"`
+	AuthProtocol string              `toml:"auth_protocol,omitempty"`
+	AuthPassword string              `toml:"auth_password,omitempty"`
+	PrivProtocol string              `toml:"priv_protocol,omitempty"`
+	PrivPassword string              `toml:"priv_password,omitempty"`
+	ContextName  string              `toml:"context_name,omitempty"`
+	Timeout      string              `toml:"timeout"`
+	Retries      int                 `toml:"retries"`
+	Fields       []CategrafSNMPField `toml:"field,omitempty"`
+	Tables       []CategrafSNMPTable `toml:"table,omitempty"`
 }
 
 type CategrafSNMPField struct {
-	Name string `toml:"name"`
-	OID  string `toml:"oid"`
+	Name       string `toml:"name"`
+	OID        string `toml:"oid"`
+	IsTag      bool   `toml:"is_tag,omitempty"`
+	Conversion string `toml:"conversion,omitempty"` // hwaddr, ipaddr
 }
 
+// CategrafSNMPTable 不设置 oid，列全部显式列出，采集端不需要安装 MIB 文件
 type CategrafSNMPTable struct {
-	Name   string                `toml:"name"`
-	OID    string                `toml:"oid"`
-	Fields []CategrafSNMPField   `toml:"field"`
+	Name        string              `toml:"name"`
+	OID         string              `toml:"oid,omitempty"`
+	InheritTags []string            `toml:"inherit_tags,omitempty"`
+	IndexAsTag  bool                `toml:"index_as_tag,omitempty"`
+	Fields      []CategrafSNMPField `toml:"field"`
 }
 
 func (s *ConfigService) GenerateConfig(req ConfigGenerationRequest) (*models.Config, error) {
-	var configContent string
-	var err error
-
-	// 根据配置类型生成不同格式的配置
-	switch req.ConfigType {
-	case "snmp_exporter":
-		configContent, err = s.generateSNMPExporterConfig(req)
-	case "categraf":
-		configContent, err = s.generateCategrafConfig(req)
-	default:
-		return nil, fmt.Errorf("unsupported config type: %s", req.ConfigType)
-	}
-
+	configContent, err := s.renderConfig(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate config: %v", err)
+		return nil, err
 	}
 
 	// 创建配置记录
@@ -191,37 +282,94 @@ func (s *ConfigService) GenerateConfig(req ConfigGenerationRequest) (*models.Con
 		UpdatedAt:   time.Now(),
 	}
 
-	// 保存到数据库
-	if err := s.db.Create(config).Error; err != nil {
+	// 保存到数据库并记录第一个版本
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(config).Error; err != nil {
+			return err
+		}
+		version := &models.ConfigVersion{
+			ConfigID:  config.ID,
+			Content:   configContent,
+			Changes:   fmt.Sprintf("generated %s config from %d OIDs", req.ConfigType, len(req.SelectedOIDs)),
+			CreatedBy: "generator",
+		}
+		if err := recordConfigVersion(tx, version); err != nil {
+			return err
+		}
+		config.Version = version.Version
+		return nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to save config: %v", err)
 	}
 
 	return config, nil
 }
 
-// 生成 SNMP Exporter 配置
+// renderConfig 根据配置类型生成不同格式的配置内容
+func (s *ConfigService) renderConfig(req ConfigGenerationRequest) (string, error) {
+	var content string
+	var err error
+	switch req.ConfigType {
+	case "snmp_exporter":
+		content, err = s.generateSNMPExporterConfig(req)
+	case "categraf":
+		content, err = s.generateCategrafConfig(req)
+	case "telegraf":
+		content, err = s.generateTelegrafConfig(req)
+	default:
+		return "", fmt.Errorf("unsupported config type: %s", req.ConfigType)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate config: %v", err)
+	}
+	return content, nil
+}
+
+// 生成 SNMP Exporter 配置，options 中可以指定 generator.yml 形式的 lookups 和 overrides
 func (s *ConfigService) generateSNMPExporterConfig(req ConfigGenerationRequest) (string, error) {
-	// 获取 OID 信息
-	oidMetrics, err := s.getOIDMetrics(req.SelectedOIDs)
+	options, err := parseSNMPGeneratorOptions(req.Options)
+	if err != nil {
+		return "", err
+	}
+
+	// 按 MIB 元数据生成指标、索引和 lookup
+	walk, oidMetrics, err := newSNMPModuleBuilder(s.db).build(req.SelectedOIDs, options)
 	if err != nil {
 		return "", fmt.Errorf("failed to get OID metrics: %v", err)
 	}
 
+	version := s.getSNMPVersion(req.DeviceInfo.Version)
+	auth, err := snmpExporterAuth(req.DeviceInfo, version)
+	if err != nil {
+		return "", err
+	}
+
 	// 构建 SNMP Exporter 配置
-	config := SNMPExporterConfig{
-		Modules: map[string]SNMPModule{
-			req.ConfigName: {
-				Walk:    req.SelectedOIDs,
-				Metrics: oidMetrics,
-				Auth: SNMPAuth{
-					Community: req.DeviceInfo.Community,
-				},
-				Version: s.getSNMPVersion(req.DeviceInfo.Version),
-				Timeout: "5s",
-				Retries: 3,
-			},
-		},
+	module := SNMPModule{
+		Walk:    walk,
+		Metrics: oidMetrics,
+		Timeout: "5s",
+		Retries: 3,
+	}
+	config := SNMPExporterConfig{}
+	if options.Format == "legacy" {
+		module.Auth = &auth
+		module.Version = version
+	} else {
+		auth.Version = version
+		// 模板批量生成时各设备的配置名称相同，默认认证名称带上设备地址，
+		// 避免团体名或 v3 用户不同的设备合并到同一文件时互相覆盖
+		authName := options.AuthName
+		if authName == "" {
+			authName = req.ConfigName
+			if req.DeviceInfo.IP != "" {
+				authName += sanitizeLabelName("_" + req.DeviceInfo.IP)
+			}
+		}
+		config.Auths = map[string]SNMPAuth{authName: auth}
 	}
+	config.Modules = map[string]SNMPModule{req.ConfigName: module}
 
 	// 转换为 YAML
 	yamlData, err := yaml.Marshal(config)
@@ -232,94 +380,102 @@ func (s *ConfigService) generateSNMPExporterConfig(req ConfigGenerationRequest)
 	return string(yamlData), nil
 }
 
-// 生成 Categraf 配置
+// 生成 Categraf 配置，表格列按所在的 MIB 表生成 [[instances.table]]
 func (s *ConfigService) generateCategrafConfig(req ConfigGenerationRequest) (string, error) {
-	// 构建 Categraf 配置
-	var fields []CategrafSNMPField
-	for _, oid := range req.SelectedOIDs {
-		// 获取 OID 名称
-		name, err := s.getOIDName(oid)
-		if err != nil {
-			name = strings.ReplaceAll(oid, ".", "_")
-		}
-		
-		fields = append(fields, CategrafSNMPField{
-			Name: name,
-			OID:  oid,
-		})
-	}
-
-	// 创建 Categraf SNMP 配置结构（用于参考）
-	_ = CategrafSNMPConfig{
-		Instances: []CategrafSNMPInstance{
-			{
-				Agents:    []string{req.DeviceInfo.IP},
-				Version:   s.getSNMPVersion(req.DeviceInfo.Version),
-				Community: req.DeviceInfo.Community,
-				Fields:    fields,
-				Timeout:   "5s",
-				Retries:   3,
-			},
-		},
+	transport, err := normalizeSNMPTransport(req.DeviceInfo.Transport)
+	if err != nil {
+		return "", err
 	}
 
-	// 转换为 TOML 格式（简化实现）
-	var buf bytes.Buffer
-	buf.WriteString("[[instances]]\n")
-	buf.WriteString(fmt.Sprintf("agents = [\"%s\"]\n", req.DeviceInfo.IP))
-	buf.WriteString(fmt.Sprintf("version = %d\n", s.getSNMPVersion(req.DeviceInfo.Version)))
-	buf.WriteString(fmt.Sprintf("community = \"%s\"\n", req.DeviceInfo.Community))
-	buf.WriteString("timeout = \"5s\"\n")
-	buf.WriteString("retries = 3\n\n")
+	version := s.getSNMPVersion(req.DeviceInfo.Version)
+	auth, err := snmpExporterAuth(req.DeviceInfo, version)
+	if err != nil {
+		return "", err
+	}
 
-	for _, field := range fields {
-		buf.WriteString("[[instances.field]]\n")
-		buf.WriteString(fmt.Sprintf("name = \"%s\"\n", field.Name))
-		buf.WriteString(fmt.Sprintf("oid = \"%s\"\n", field.OID))
-		buf.WriteString("\n")
+	fields, tables, err := s.snmpInputFields(req.SelectedOIDs)
+	if err != nil {
+		return "", err
 	}
 
-	return buf.String(), nil
+	instance := CategrafSNMPInstance{
+		Agents:       []string{snmpAgentAddress(transport, req.DeviceInfo.IP, req.DeviceInfo.Port)},
+		Version:      version,
+		Community:    auth.Community,
+		SecName:      auth.Username,
+		SecLevel:     auth.SecurityLevel,
+		AuthProtocol: auth.AuthProtocol,
+		AuthPassword: auth.Password,
+		PrivProtocol: auth.PrivProtocol,
+		PrivPassword: auth.PrivPassword,
+		ContextName:  auth.ContextName,
+		Timeout:      "5s",
+		Retries:      3,
+		Fields:       fields,
+		Tables:       tables,
+	}
+
+	data, err := toml.Marshal(CategrafSNMPConfig{Instances: []CategrafSNMPInstance{instance}})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal TOML: %v", err)
+	}
+	return string(data), nil
 }
 
-// 获取 OID 指标信息
-func (s *ConfigService) getOIDMetrics(oids []string) ([]SNMPMetric, error) {
-	var metrics []SNMPMetric
+// 生成 Telegraf inputs.snmp 配置，field 和 table 与 Categraf 使用相同的生成规则
+func (s *ConfigService) generateTelegrafConfig(req ConfigGenerationRequest) (string, error) {
+	transport, err := normalizeSNMPTransport(req.DeviceInfo.Transport)
+	if err != nil {
+		return "", err
+	}
 
-	for _, oid := range oids {
-		var oidModel models.OID
-		if err := s.db.Where("oid = ?", oid).First(&oidModel).Error; err != nil {
-			// 如果数据库中没有找到，使用默认值
-			metrics = append(metrics, SNMPMetric{
-				Name: strings.ReplaceAll(oid, ".", "_"),
-				OID:  oid,
-				Type: "gauge",
-				Help: fmt.Sprintf("SNMP metric for OID %s", oid),
-			})
-			continue
-		}
+	version := s.getSNMPVersion(req.DeviceInfo.Version)
+	auth, err := snmpExporterAuth(req.DeviceInfo, version)
+	if err != nil {
+		return "", err
+	}
 
-		// 根据 SNMP 类型确定 Prometheus 类型
-		promType := s.getPrometheusType(oidModel.Type)
-		
-		metrics = append(metrics, SNMPMetric{
-			Name: oidModel.Name,
-			OID:  oid,
-			Type: promType,
-			Help: oidModel.Description,
-		})
+	fields, tables, err := s.snmpInputFields(req.SelectedOIDs)
+	if err != nil {
+		return "", err
 	}
 
-	return metrics, nil
+	input := TelegrafSNMPInput{
+		Agents:       []string{snmpAgentAddress(transport, req.DeviceInfo.IP, req.DeviceInfo.Port)},
+		AgentHostTag: "source",
+		Version:      version,
+		Community:    auth.Community,
+		SecName:      auth.Username,
+		SecLevel:     auth.SecurityLevel,
+		AuthProtocol: auth.AuthProtocol,
+		AuthPassword: auth.Password,
+		PrivProtocol: auth.PrivProtocol,
+		PrivPassword: auth.PrivPassword,
+		ContextName:  auth.ContextName,
+		Timeout:      "5s",
+		Retries:      3,
+		Fields:       fields,
+		Tables:       tables,
+	}
+
+	var config TelegrafConfig
+	config.Inputs.SNMP = []TelegrafSNMPInput{input}
+	data, err := toml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal TOML: %v", err)
+	}
+	return string(data), nil
 }
 
-// 获取 OID 名称
-func (s *ConfigService) getOIDName(oid string) (string, error) {
-	var oidModel models.OID
-	if err := s.db.Where("oid = ?", oid).First(&oidModel).Error; err != nil {
-		return "", err
+// snmpInputFields 按 MIB 元数据生成 Categraf/Telegraf 的 field 和 table
+func (s *ConfigService) snmpInputFields(oids []string) ([]CategrafSNMPField, []CategrafSNMPTable, error) {
+	builder := newSNMPModuleBuilder(s.db)
+	// 表格按索引输出 index 标签，不需要 snmp_exporter 的 lookup
+	_, metrics, err := builder.build(oids, &snmpGeneratorOptions{Lookups: []SNMPGeneratorLookup{}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get OID metrics: %v", err)
 	}
-	return oidModel.Name, nil
+	return builder.inputFields(metrics)
 }
 
 // 转换 SNMP 版本
@@ -336,20 +492,6 @@ func (s *ConfigService) getSNMPVersion(version string) int {
 	}
 }
 
-// 转换为 Prometheus 指标类型
-func (s *ConfigService) getPrometheusType(snmpType string) string {
-	switch strings.ToLower(snmpType) {
-	case "counter", "counter32", "counter64":
-		return "counter"
-	case "gauge", "gauge32", "integer32", "unsigned32":
-		return "gauge"
-	case "timeticks":
-		return "counter"
-	default:
-		return "gauge"
-	}
-}
-
 // 保存配置到文件系统
 func (s *ConfigService) SaveConfigToFile(config *models.Config, targetPath string) error {
 	// 确保目标目录存在
@@ -392,6 +534,8 @@ func (s *ConfigService) MergeConfigToFile(config *models.Config, targetPath stri
 		mergedContent, err = s.mergeSNMPExporterConfig(string(existingContent), config.Content)
 	case "categraf":
 		mergedContent, err = s.mergeCategrafConfig(string(existingContent), config.Content)
+	case "telegraf":
+		mergedContent, err = s.mergeTelegrafConfig(string(existingContent), config.Content)
 	default:
 		return fmt.Errorf("unsupported config type for merging: %s", config.Type)
 	}
@@ -408,7 +552,7 @@ func (s *ConfigService) MergeConfigToFile(config *models.Config, targetPath stri
 	return nil
 }
 
-// 合并 SNMP Exporter 配置
+// 合并 SNMP Exporter 配置，auths 和 modules 分别按名称覆盖；任一方是新格式时，旧格式模块内的认证信息移到 auths 中
 func (s *ConfigService) mergeSNMPExporterConfig(existing, new string) (string, error) {
 	var existingConfig, newConfig SNMPExporterConfig
 
@@ -426,7 +570,21 @@ func (s *ConfigService) mergeSNMPExporterConfig(existing, new string) (string, e
 		return "", fmt.Errorf("failed to parse new config: %v", err)
 	}
 
-	// 合并模块
+	if existingConfig.Modules == nil {
+		existingConfig.Modules = make(map[string]SNMPModule)
+	}
+	if len(existingConfig.Auths) > 0 || len(newConfig.Auths) > 0 {
+		splitSNMPExporterAuths(&existingConfig)
+		splitSNMPExporterAuths(&newConfig)
+	}
+
+	// 合并认证和模块
+	for name, auth := range newConfig.Auths {
+		if existingConfig.Auths == nil {
+			existingConfig.Auths = make(map[string]SNMPAuth)
+		}
+		existingConfig.Auths[name] = auth
+	}
 	for name, module := range newConfig.Modules {
 		existingConfig.Modules[name] = module
 	}
@@ -440,14 +598,37 @@ func (s *ConfigService) mergeSNMPExporterConfig(existing, new string) (string, e
 	return string(mergedData), nil
 }
 
-// 合并 Categraf 配置
-func (s *ConfigService) mergeCategrafConfig(existing, new string) (string, error) {
-	// 简单的文本合并（实际应用中可能需要更复杂的逻辑）
-	if existing == "" {
-		return new, nil
+// splitSNMPExporterAuths 把旧格式模块内的 auth 和 version 移到 auths 中，认证名称与模块名相同
+func splitSNMPExporterAuths(config *SNMPExporterConfig) {
+	for name, module := range config.Modules {
+		if module.Auth == nil && module.Version == 0 {
+			continue
+		}
+		auth := SNMPAuth{}
+		if module.Auth != nil {
+			auth = *module.Auth
+		}
+		auth.Version = module.Version
+		if config.Auths == nil {
+			config.Auths = make(map[string]SNMPAuth)
+		}
+		if _, exists := config.Auths[name]; !exists {
+			config.Auths[name] = auth
+		}
+		module.Auth = nil
+		module.Version = 0
+		config.Modules[name] = module
 	}
-	
-	return existing + "\n\n" + new, nil
+}
+
+// 合并 Categraf 配置，按 agent 地址去重，新配置中的 instance 替换采集相同 agent 的旧 instance
+func (s *ConfigService) mergeCategrafConfig(existing, new string) (string, error) {
+	return mergeSNMPInputs(existing, new, "instances")
+}
+
+// 合并 Telegraf 配置，只替换采集相同 agent 的 [[inputs.snmp]]，其他插件和 agent 配置保持不变
+func (s *ConfigService) mergeTelegrafConfig(existing, new string) (string, error) {
+	return mergeSNMPInputs(existing, new, "inputs", "snmp")
 }
 
 func (s *ConfigService) ValidateConfig(id uint) (map[string]interface{}, error) {
@@ -472,6 +653,10 @@ func (s *ConfigService) ValidateConfig(id uint) (map[string]interface{}, error)
 		errors, warnings = s.validateDeviceConfig(config.Content, errors, warnings)
 	case "template":
 		errors, warnings = s.validateTemplateConfig(config.Content, errors, warnings)
+	case "categraf":
+		errors, warnings = validateSNMPInputConfig(config.Content, []string{"instances"}, errors, warnings)
+	case "telegraf":
+		errors, warnings = validateSNMPInputConfig(config.Content, []string{"inputs", "snmp"}, errors, warnings)
 	default:
 		warnings = append(warnings, fmt.Sprintf("Unknown configuration type: %s", config.Type))
 	}
@@ -522,7 +707,7 @@ func (s *ConfigService) CreateTemplate(template *models.ConfigTemplate) error {
 
 func (s *ConfigService) GetConfigVersions(configID uint) ([]models.ConfigVersion, error) {
 	var versions []models.ConfigVersion
-	if err := s.db.Where("config_id = ?", configID).Order("created_at DESC").Find(&versions).Error; err != nil {
+	if err := s.db.Where("config_id = ?", configID).Order("revision DESC, created_at DESC").Find(&versions).Error; err != nil {
 		return nil, err
 	}
 	return versions, nil
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mib-platform/models"
+)
+
+// ErrConfigUnchanged 回滚的目标版本与当前内容相同
+var ErrConfigUnchanged = errors.New("config content unchanged")
+
+// recordConfigVersion 为配置新增一个版本并同步配置的版本标签：版本号在同一配置内递增，
+// 标签为空时使用 v<版本号>，变更说明为空时按与上一个版本的差异行数生成。
+// 调用方需先锁定配置行，否则并发写入会分到同一个版本号
+func recordConfigVersion(tx *gorm.DB, version *models.ConfigVersion) error {
+	var latest models.ConfigVersion
+	if err := tx.Where("config_id = ?", version.ConfigID).Order("revision DESC, id DESC").Limit(1).Find(&latest).Error; err != nil {
+		return err
+	}
+	// 唯一索引也包含已软删除的版本，版本号要在它们之后继续递增
+	var revision int
+	if err := tx.Unscoped().Model(&models.ConfigVersion{}).Where("config_id = ?", version.ConfigID).
+		Select("COALESCE(MAX(revision), 0)").Scan(&revision).Error; err != nil {
+		return err
+	}
+
+	version.Revision = revision + 1
+	digest := sha256.Sum256([]byte(version.Content))
+	version.Hash = hex.EncodeToString(digest[:])
+	if version.Version == "" {
+		version.Version = fmt.Sprintf("v%d", version.Revision)
+	}
+	if version.CreatedBy == "" {
+		version.CreatedBy = "system"
+	}
+	if version.Changes == "" {
+		if latest.ID == 0 {
+			version.Changes = "initial version"
+		} else {
+			_, added, removed := unifiedDiff("", "", splitLines(latest.Content), splitLines(version.Content), 0)
+			version.Changes = fmt.Sprintf("%d lines added, %d lines removed", added, removed)
+		}
+	}
+
+	if err := tx.Create(version).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Config{}).Where("id = ?", version.ConfigID).UpdateColumn("version", version.Version).Error
+}
+
+// GetConfigVersion 获取指定版本，revision 为 0 时返回最新版本
+func (s *ConfigService) GetConfigVersion(configID uint, revision int) (*models.ConfigVersion, error) {
+	var version models.ConfigVersion
+	query := s.db.Where("config_id = ?", configID)
+	if revision > 0 {
+		query = query.Where("revision = ?", revision)
+	}
+	if err := query.Order("revision DESC, id DESC").First(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// DiffConfigVersions 比较两个版本，to 为 0 时取最新版本，from 为 0 时取 to 的上一个版本；
+// 第一个版本没有上一个版本，与空配置比较
+func (s *ConfigService) DiffConfigVersions(configID uint, from, to int) (*models.ConfigVersionDiff, error) {
+	target, err := s.GetConfigVersion(configID, to)
+	if err != nil {
+		return nil, err
+	}
+	if from == 0 {
+		from = target.Revision - 1
+	}
+	base := &models.ConfigVersion{}
+	if from > 0 {
+		if base, err = s.GetConfigVersion(configID, from); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &models.ConfigVersionDiff{ConfigID: configID, FromRevision: base.Revision, ToRevision: target.Revision}
+	result.Diff, result.Added, result.Removed = unifiedDiff(
+		fmt.Sprintf("version %d", base.Revision), fmt.Sprintf("version %d", target.Revision),
+		splitLines(base.Content), splitLines(target.Content), configDiffContext)
+	return result, nil
+}
+
+// RollbackConfig 把配置内容恢复为指定版本，并记录为一个新版本
+func (s *ConfigService) RollbackConfig(configID uint, revision int, req *models.ConfigRollbackRequest) (*models.ConfigVersion, error) {
+	var version *models.ConfigVersion
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var config models.Config
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&config, configID).Error; err != nil {
+			return err
+		}
+		var target models.ConfigVersion
+		if err := tx.Where("config_id = ? AND revision = ?", configID, revision).First(&target).Error; err != nil {
+			return err
+		}
+		if target.Content == config.Content {
+			return fmt.Errorf("%w: config already matches version %d", ErrConfigUnchanged, revision)
+		}
+
+		if err := tx.Model(&config).Update("content", target.Content).Error; err != nil {
+			return err
+		}
+		version = &models.ConfigVersion{
+			ConfigID:     configID,
+			Content:      target.Content,
+			Changes:      req.Changes,
+			RestoredFrom: target.Revision,
+			CreatedBy:    req.Author,
+		}
+		if version.Changes == "" {
+			version.Changes = fmt.Sprintf("rollback to version %d", target.Revision)
+		}
+		return recordConfigVersion(tx, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+// deviceCollector 按固定周期对所有配置了 SNMP 凭据的设备执行采集
+type deviceCollector struct {
+	name     string
+	db       *gorm.DB
+	logger   utils.Logger
+	interval time.Duration
+	collect  func(deviceID uint) error
+	targets  func() ([]uint, error) // 需要采集的设备，默认为配置了 SNMP 凭据的设备
+
+	mu      sync.Mutex
+	running bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// newDeviceCollector interval 单位为秒，0 表示不定时采集
+func newDeviceCollector(name string, db *gorm.DB, logger utils.Logger, interval int, collect func(deviceID uint) error) *deviceCollector {
+	return &deviceCollector{
+		name:     name,
+		db:       db,
+		logger:   logger,
+		interval: time.Duration(interval) * time.Second,
+		collect:  collect,
+	}
+}
+
+func (c *deviceCollector) Start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.running || c.interval <= 0 {
+		return
+	}
+	c.running = true
+	c.stop = make(chan struct{})
+
+	c.wg.Add(1)
+	go c.run()
+	c.logger.Info(c.name+" collector started", "interval", c.interval.String())
+}
+
+func (c *deviceCollector) Stop() {
+	c.mu.Lock()
+	if !c.running {
+		c.mu.Unlock()
+		return
+	}
+	c.running = false
+	close(c.stop)
+	c.mu.Unlock()
+
+	c.wg.Wait()
+	c.logger.Info(c.name + " collector stopped")
+}
+
+func (c *deviceCollector) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.collectAll()
+		}
+	}
+}
+
+// collectAll 依次采集所有配置了凭据的设备
+func (c *deviceCollector) collectAll() {
+	deviceIDs, err := c.deviceIDs()
+	if err != nil {
+		c.logger.Error("Failed to load devices for "+c.name+" collection", "error", err)
+		return
+	}
+
+	for _, id := range deviceIDs {
+		select {
+		case <-c.stop:
+			return
+		default:
+		}
+		if err := c.collect(id); err != nil {
+			c.logger.Warn(c.name+" collection failed", "device_id", id, "error", err)
+		}
+	}
+}
+
+func (c *deviceCollector) deviceIDs() ([]uint, error) {
+	if c.targets != nil {
+		return c.targets()
+	}
+	var deviceIDs []uint
+	err := c.db.Model(&models.SNMPCredential{}).Distinct("device_id").Pluck("device_id", &deviceIDs).Error
+	return deviceIDs, err
+}
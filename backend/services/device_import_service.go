@@ -0,0 +1,672 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+// redactedSecret 导出时替换敏感字段的占位符；导入时遇到该值视为保留原值
+const redactedSecret = utils.SecretMask
+
+// deviceRecordColumns 导入导出的列顺序
+var deviceRecordColumns = []string{
+	"name", "hostname", "ip_address", "port", "transport", "type", "vendor", "model", "os_family",
+	"location", "contact", "description", "tags", "poll_interval", "template", "credential_profile",
+	"snmp_version", "community", "username", "auth_proto", "auth_key", "priv_proto", "priv_key",
+}
+
+// deviceColumnAliases 常见表格列名到标准列名的映射
+var deviceColumnAliases = map[string]string{
+	"ip":             "ip_address",
+	"ip_addr":        "ip_address",
+	"address":        "ip_address",
+	"management_ip":  "ip_address",
+	"mgmt_ip":        "ip_address",
+	"device_name":    "name",
+	"sysname":        "hostname",
+	"os":             "os_family",
+	"template_name":  "template",
+	"profile":        "credential_profile",
+	"credential":     "credential_profile",
+	"version":        "snmp_version",
+	"snmp_community": "community",
+	"auth_protocol":  "auth_proto",
+	"auth_password":  "auth_key",
+	"priv_protocol":  "priv_proto",
+	"priv_password":  "priv_key",
+}
+
+func deviceRecordField(record *models.DeviceRecord, column string) *string {
+	switch column {
+	case "name":
+		return &record.Name
+	case "hostname":
+		return &record.Hostname
+	case "ip_address":
+		return &record.IPAddress
+	case "port":
+		return &record.Port
+	case "transport":
+		return &record.Transport
+	case "type":
+		return &record.Type
+	case "vendor":
+		return &record.Vendor
+	case "model":
+		return &record.Model
+	case "os_family":
+		return &record.OSFamily
+	case "location":
+		return &record.Location
+	case "contact":
+		return &record.Contact
+	case "description":
+		return &record.Description
+	case "tags":
+		return &record.Tags
+	case "poll_interval":
+		return &record.PollInterval
+	case "template":
+		return &record.Template
+	case "credential_profile":
+		return &record.CredentialProfile
+	case "snmp_version":
+		return &record.SNMPVersion
+	case "community":
+		return &record.Community
+	case "username":
+		return &record.Username
+	case "auth_proto":
+		return &record.AuthProto
+	case "auth_key":
+		return &record.AuthKey
+	case "priv_proto":
+		return &record.PrivProto
+	case "priv_key":
+		return &record.PrivKey
+	}
+	return nil
+}
+
+// deviceImportPlan 单行校验后的写入计划
+type deviceImportPlan struct {
+	row        models.DeviceImportRow
+	device     models.Device
+	existing   *models.Device
+	credential *models.SNMPCredential
+}
+
+// ImportDevices 解析 CSV/JSON/YAML 设备清单，按 IP 新增或更新设备；dry_run 时只返回预览
+func (s *DeviceService) ImportDevices(data []byte, opts *models.DeviceImportOptions) (*models.DeviceImportResult, error) {
+	rows, err := parseDeviceRows(data, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.DeviceImportResult{
+		DryRun: opts.DryRun,
+		Total:  len(rows),
+		Rows:   make([]models.DeviceImportRow, 0, len(rows)),
+	}
+
+	records := make([]models.DeviceRecord, len(rows))
+	unknown := map[string]bool{}
+	for i, row := range rows {
+		for key, value := range row {
+			column := normalizeDeviceColumn(key, opts.Mapping)
+			field := deviceRecordField(&records[i], column)
+			if field == nil {
+				unknown[key] = true
+				continue
+			}
+			*field = strings.TrimSpace(value)
+		}
+	}
+	for column := range unknown {
+		result.UnknownColumns = append(result.UnknownColumns, column)
+	}
+	sort.Strings(result.UnknownColumns)
+
+	plans, err := s.planDeviceImport(records, opts.UpdateExisting)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, plan := range plans {
+		switch plan.row.Action {
+		case "error":
+			result.Failed++
+		case "skip":
+			result.Skipped++
+		case "create":
+			result.Created++
+		case "update":
+			result.Updated++
+		}
+	}
+
+	if !opts.DryRun {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			for i := range plans {
+				if err := applyDeviceImportPlan(tx, &plans[i]); err != nil {
+					return fmt.Errorf("row %d (%s): %v", plans[i].row.Row, plans[i].row.IPAddress, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to import devices: %v", err)
+		}
+
+		var deviceIDs []uint
+		for _, plan := range plans {
+			if plan.row.DeviceID != nil && (plan.row.Action == "create" || plan.row.Action == "update") {
+				deviceIDs = append(deviceIDs, *plan.row.DeviceID)
+			}
+		}
+		s.syncGroups(deviceIDs...)
+	}
+
+	for _, plan := range plans {
+		result.Rows = append(result.Rows, plan.row)
+	}
+	return result, nil
+}
+
+// planDeviceImport 校验每一行并确定动作，同一批次内重复的 IP 视为错误
+func (s *DeviceService) planDeviceImport(records []models.DeviceRecord, updateExisting bool) ([]deviceImportPlan, error) {
+	var templates []models.DeviceTemplate
+	if err := s.db.Select("id", "name").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load templates: %v", err)
+	}
+	templateIDs := make(map[string]uint, len(templates))
+	for _, template := range templates {
+		templateIDs[template.Name] = template.ID
+		templateIDs[strconv.FormatUint(uint64(template.ID), 10)] = template.ID
+	}
+
+	var profiles []models.SNMPCredentialProfile
+	if err := s.db.Find(&profiles).Error; err != nil {
+		return nil, fmt.Errorf("failed to load credential profiles: %v", err)
+	}
+	profilesByName := make(map[string]models.SNMPCredentialProfile, len(profiles))
+	for _, profile := range profiles {
+		profilesByName[profile.Name] = profile
+	}
+
+	var ips []string
+	for _, record := range records {
+		if ip := net.ParseIP(record.IPAddress); ip != nil {
+			ips = append(ips, ip.String())
+		}
+	}
+	existingByIP := map[string]*models.Device{}
+	if len(ips) > 0 {
+		var devices []models.Device
+		if err := s.db.Preload("Credentials").Where("ip_address IN ?", ips).Find(&devices).Error; err != nil {
+			return nil, fmt.Errorf("failed to load existing devices: %v", err)
+		}
+		for i := range devices {
+			existingByIP[devices[i].IPAddress] = &devices[i]
+		}
+	}
+
+	seen := map[string]int{}
+	plans := make([]deviceImportPlan, 0, len(records))
+	for i, record := range records {
+		plan := deviceImportPlan{row: models.DeviceImportRow{Row: i + 1, Name: record.Name, IPAddress: record.IPAddress}}
+		var errs []string
+		addError := func(format string, args ...interface{}) {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+
+		device := &plan.device
+		device.Name = record.Name
+		device.Hostname = record.Hostname
+		device.Type = record.Type
+		device.Vendor = record.Vendor
+		device.Model = record.Model
+		device.OSFamily = record.OSFamily
+		device.Location = record.Location
+		device.Contact = record.Contact
+		device.Description = record.Description
+		if record.Tags != "" {
+			device.Tags = normalizeInterfaceTags(strings.FieldsFunc(record.Tags, func(r rune) bool { return r == ',' || r == ';' }))
+		}
+
+		ip := net.ParseIP(record.IPAddress)
+		switch {
+		case record.IPAddress == "":
+			addError("ip_address is required")
+		case ip == nil:
+			addError("invalid ip_address %q", record.IPAddress)
+		default:
+			device.IPAddress = ip.String()
+			plan.row.IPAddress = device.IPAddress
+			if first, ok := seen[device.IPAddress]; ok {
+				addError("duplicate ip_address, first seen in row %d", first)
+			} else {
+				seen[device.IPAddress] = plan.row.Row
+			}
+		}
+
+		if record.Port != "" {
+			port, err := strconv.Atoi(record.Port)
+			if err != nil || port < 1 || port > 65535 {
+				addError("invalid port %q", record.Port)
+			}
+			device.Port = port
+		}
+		if record.Transport != "" {
+			transport, err := normalizeSNMPTransport(record.Transport)
+			if err != nil {
+				addError("%v", err)
+			}
+			device.Transport = transport
+		}
+		if record.PollInterval != "" {
+			interval, err := strconv.Atoi(record.PollInterval)
+			if err != nil || interval < 0 {
+				addError("invalid poll_interval %q", record.PollInterval)
+			}
+			device.PollInterval = interval
+		}
+		if record.Template != "" {
+			id, ok := templateIDs[record.Template]
+			if !ok {
+				addError("template %q not found", record.Template)
+			}
+			device.TemplateID = &id
+		}
+
+		credential, err := importCredential(record, profilesByName)
+		if err != nil {
+			addError("%v", err)
+		}
+		plan.credential = credential
+
+		plan.existing = existingByIP[device.IPAddress]
+		switch {
+		case len(errs) > 0:
+			plan.row.Action = "error"
+			plan.row.Errors = errs
+		case plan.existing != nil && !updateExisting:
+			plan.row.Action = "skip"
+			plan.row.DeviceID = &plan.existing.ID
+		case plan.existing != nil:
+			plan.row.Action = "update"
+			plan.row.DeviceID = &plan.existing.ID
+			// 设备没有凭据时会新建凭据，不能保存导出时掩码的密钥
+			if len(plan.existing.Credentials) == 0 && hasRedactedSecret(credential) {
+				plan.row.Action = "error"
+				plan.row.Errors = []string{"redacted secrets cannot be used for devices without credentials"}
+			}
+		default:
+			plan.row.Action = "create"
+			if device.Name == "" {
+				device.Name = device.Hostname
+			}
+			if device.Name == "" {
+				device.Name = device.IPAddress
+			}
+			if hasRedactedSecret(credential) {
+				plan.row.Action = "error"
+				plan.row.Errors = []string{"redacted secrets cannot be used for new devices"}
+			}
+		}
+		if plan.row.Name == "" {
+			plan.row.Name = device.Name
+		}
+
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// hasRedactedSecret 凭据中是否有脱敏导出留下的掩码密钥
+func hasRedactedSecret(credential *models.SNMPCredential) bool {
+	return credential != nil && (credential.Community == redactedSecret || credential.AuthKey == redactedSecret || credential.PrivKey == redactedSecret)
+}
+
+// importCredential 合并凭据模板和行内凭据字段，行内非空字段优先
+func importCredential(record models.DeviceRecord, profiles map[string]models.SNMPCredentialProfile) (*models.SNMPCredential, error) {
+	credential := &models.SNMPCredential{}
+	hasCredential := false
+
+	if record.CredentialProfile != "" {
+		profile, ok := profiles[record.CredentialProfile]
+		if !ok {
+			return nil, fmt.Errorf("credential profile %q not found", record.CredentialProfile)
+		}
+		credential.Version = profile.Version
+		credential.Community = profile.Community
+		credential.Username = profile.Username
+		credential.AuthProto = profile.AuthProto
+		credential.AuthKey = profile.AuthKey
+		credential.PrivProto = profile.PrivProto
+		credential.PrivKey = profile.PrivKey
+		hasCredential = true
+	}
+
+	inline := []struct {
+		value  string
+		target *string
+	}{
+		{record.SNMPVersion, &credential.Version},
+		{record.Community, &credential.Community},
+		{record.Username, &credential.Username},
+		{record.AuthProto, &credential.AuthProto},
+		{record.AuthKey, &credential.AuthKey},
+		{record.PrivProto, &credential.PrivProto},
+		{record.PrivKey, &credential.PrivKey},
+	}
+	for _, field := range inline {
+		if field.value != "" {
+			*field.target = field.value
+			hasCredential = true
+		}
+	}
+	if !hasCredential {
+		return nil, nil
+	}
+
+	credential.Version = strings.ToLower(credential.Version)
+	if credential.Version == "" {
+		if credential.Username != "" {
+			credential.Version = "v3"
+		} else {
+			credential.Version = "v2c"
+		}
+	}
+	switch credential.Version {
+	case "1", "v1":
+		credential.Version = "v1"
+	case "2", "2c", "v2", "v2c":
+		credential.Version = "v2c"
+	case "3", "v3":
+		credential.Version = "v3"
+	default:
+		return nil, fmt.Errorf("invalid snmp_version %q", credential.Version)
+	}
+
+	if credential.Version == "v3" {
+		if credential.Username == "" {
+			return nil, fmt.Errorf("username is required for SNMPv3")
+		}
+		if credential.PrivKey != "" && credential.AuthKey == "" {
+			return nil, fmt.Errorf("auth_key is required when priv_key is set")
+		}
+		if _, err := snmpAuthProtocol(credential.AuthProto); err != nil {
+			return nil, err
+		}
+		if _, err := snmpPrivProtocol(credential.PrivProto); err != nil {
+			return nil, err
+		}
+	} else if credential.Community == "" {
+		return nil, fmt.Errorf("community is required for SNMP %s", credential.Version)
+	}
+
+	return credential, nil
+}
+
+func applyDeviceImportPlan(tx *gorm.DB, plan *deviceImportPlan) error {
+	switch plan.row.Action {
+	case "create":
+		if plan.credential != nil {
+			plan.device.Credentials = []models.SNMPCredential{*plan.credential}
+		}
+		if err := tx.Create(&plan.device).Error; err != nil {
+			return err
+		}
+		plan.row.DeviceID = &plan.device.ID
+	case "update":
+		existing := plan.existing
+		rebound := templateChanged(existing.TemplateID, plan.device.TemplateID)
+		// 只更新文件中给出的字段，空值保持原值
+		if err := tx.Model(existing).Updates(&plan.device).Error; err != nil {
+			return err
+		}
+		if rebound {
+			if err := tx.Model(existing).UpdateColumn("template_version", 0).Error; err != nil {
+				return err
+			}
+		}
+		if plan.credential == nil {
+			return nil
+		}
+		if len(existing.Credentials) == 0 {
+			plan.credential.DeviceID = existing.ID
+			return tx.Create(plan.credential).Error
+		}
+		// 用结构体而不是 map 更新，密钥字段才会经过 secret 序列化器加密
+		columns := []string{"version"}
+		secrets := map[string]string{
+			"community":  plan.credential.Community,
+			"username":   plan.credential.Username,
+			"auth_proto": plan.credential.AuthProto,
+			"auth_key":   plan.credential.AuthKey,
+			"priv_proto": plan.credential.PrivProto,
+			"priv_key":   plan.credential.PrivKey,
+		}
+		for column, value := range secrets {
+			if value != "" && value != redactedSecret {
+				columns = append(columns, column)
+			}
+		}
+		return tx.Model(&existing.Credentials[0]).Select(columns).Updates(plan.credential).Error
+	}
+	return nil
+}
+
+// ExportDevices 按导入格式导出设备清单，redact 为 true 时隐藏团体名和密钥
+func (s *DeviceService) ExportDevices(format string, redact bool) ([]byte, error) {
+	var devices []models.Device
+	if err := s.db.Preload("Template").Preload("Credentials").Order("id").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	records := make([]models.DeviceRecord, 0, len(devices))
+	for _, device := range devices {
+		record := models.DeviceRecord{
+			Name:        device.Name,
+			Hostname:    device.Hostname,
+			IPAddress:   device.IPAddress,
+			Transport:   device.Transport,
+			Type:        device.Type,
+			Vendor:      device.Vendor,
+			Model:       device.Model,
+			OSFamily:    device.OSFamily,
+			Location:    device.Location,
+			Contact:     device.Contact,
+			Description: device.Description,
+			Tags:        strings.Join(device.Tags, ","),
+		}
+		if device.Port != 0 {
+			record.Port = strconv.Itoa(device.Port)
+		}
+		if device.PollInterval != 0 {
+			record.PollInterval = strconv.Itoa(device.PollInterval)
+		}
+		if device.Template != nil {
+			record.Template = device.Template.Name
+		}
+		if len(device.Credentials) > 0 {
+			cred := device.Credentials[0]
+			record.SNMPVersion = cred.Version
+			record.Community = cred.Community
+			record.Username = cred.Username
+			record.AuthProto = cred.AuthProto
+			record.AuthKey = cred.AuthKey
+			record.PrivProto = cred.PrivProto
+			record.PrivKey = cred.PrivKey
+			if redact {
+				for _, secret := range []*string{&record.Community, &record.AuthKey, &record.PrivKey} {
+					if *secret != "" {
+						*secret = redactedSecret
+					}
+				}
+			}
+		}
+		records = append(records, record)
+	}
+
+	switch strings.ToLower(format) {
+	case "", "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.Write(deviceRecordColumns); err != nil {
+			return nil, err
+		}
+		for i := range records {
+			row := make([]string, len(deviceRecordColumns))
+			for j, column := range deviceRecordColumns {
+				row[j] = *deviceRecordField(&records[i], column)
+			}
+			if err := w.Write(row); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		return buf.Bytes(), w.Error()
+	case "json":
+		return json.MarshalIndent(records, "", "  ")
+	case "yaml", "yml":
+		return yaml.Marshal(records)
+	}
+	return nil, fmt.Errorf("unsupported export format: %s", format)
+}
+
+// parseDeviceRows 将导入内容解析为列名到值的行
+func parseDeviceRows(data []byte, format string) ([]map[string]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // Excel 导出的 UTF-8 BOM
+
+	switch strings.ToLower(format) {
+	case "csv":
+		r := csv.NewReader(bytes.NewReader(data))
+		r.FieldsPerRecord = -1
+		r.TrimLeadingSpace = true
+		lines, err := r.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %v", err)
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("CSV has no header row")
+		}
+		header := lines[0]
+		rows := make([]map[string]string, 0, len(lines)-1)
+		for _, line := range lines[1:] {
+			row := make(map[string]string, len(header))
+			for i, column := range header {
+				if i < len(line) {
+					row[column] = line[i]
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	case "json", "yaml", "yml":
+		var raw interface{}
+		var err error
+		if format == "json" {
+			err = json.Unmarshal(data, &raw)
+		} else {
+			err = yaml.Unmarshal(data, &raw)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", format, err)
+		}
+		// 支持顶层数组或 {"devices": [...]}
+		if obj, ok := raw.(map[string]interface{}); ok {
+			raw = obj["devices"]
+		}
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s must be a list of devices", format)
+		}
+		rows := make([]map[string]string, 0, len(items))
+		for i, item := range items {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("device %d is not an object", i+1)
+			}
+			row := make(map[string]string, len(obj))
+			for key, value := range obj {
+				switch v := value.(type) {
+				case nil:
+				case []interface{}:
+					// 列表（如 tags）按逗号连接
+					parts := make([]string, len(v))
+					for j, part := range v {
+						parts[j] = fmt.Sprint(part)
+					}
+					row[key] = strings.Join(parts, ",")
+				default:
+					row[key] = fmt.Sprint(v)
+				}
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+	return nil, fmt.Errorf("unsupported import format: %s", format)
+}
+
+// normalizeDeviceColumn 先应用用户映射，再统一大小写、分隔符并解析别名
+func normalizeDeviceColumn(column string, mapping map[string]string) string {
+	if mapped, ok := mapping[column]; ok {
+		column = mapped
+	}
+	column = strings.ToLower(strings.TrimSpace(column))
+	column = strings.NewReplacer(" ", "_", "-", "_").Replace(column)
+	if alias, ok := deviceColumnAliases[column]; ok {
+		return alias
+	}
+	return column
+}
+
+func (s *DeviceService) GetCredentialProfiles() ([]models.SNMPCredentialProfile, error) {
+	var profiles []models.SNMPCredentialProfile
+	if err := s.db.Order("name").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (s *DeviceService) CreateCredentialProfile(profile *models.SNMPCredentialProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	credential, err := importCredential(models.DeviceRecord{
+		SNMPVersion: profile.Version,
+		Community:   profile.Community,
+		Username:    profile.Username,
+		AuthProto:   profile.AuthProto,
+		AuthKey:     profile.AuthKey,
+		PrivProto:   profile.PrivProto,
+		PrivKey:     profile.PrivKey,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if credential == nil {
+		return fmt.Errorf("profile has no credential fields")
+	}
+	profile.Version = credential.Version
+	return s.db.Create(profile).Error
+}
+
+func (s *DeviceService) DeleteCredentialProfile(id uint) error {
+	return s.db.Unscoped().Delete(&models.SNMPCredentialProfile{}, id).Error
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mib-platform/models"
+)
+
+// 设备分组选择器，例如：
+//
+//	{"vendor": ["cisco", "huawei"], "cidr": ["10.0.0.0/8", "!10.9.0.0/16"], "location": "!~^lab", "tags": ["core", "!decommissioned"]}
+//
+// 各字段之间为“与”；字段值为字符串或字符串列表，"~" 开头为正则（整串匹配），"!" 开头为取反，均不区分大小写。
+// 普通字段：满足任一肯定条件且不满足任何取反条件；tags：每个肯定条件都要有标签匹配，取反条件不能有标签匹配；
+// cidr：IP 在任一肯定网段内且不在任何取反网段内。
+var deviceSelectorFields = map[string]func(*models.Device) string{
+	"name":        func(d *models.Device) string { return d.Name },
+	"hostname":    func(d *models.Device) string { return d.Hostname },
+	"ip_address":  func(d *models.Device) string { return d.IPAddress },
+	"type":        func(d *models.Device) string { return d.Type },
+	"vendor":      func(d *models.Device) string { return d.Vendor },
+	"model":       func(d *models.Device) string { return d.Model },
+	"os_family":   func(d *models.Device) string { return d.OSFamily },
+	"location":    func(d *models.Device) string { return d.Location },
+	"contact":     func(d *models.Device) string { return d.Contact },
+	"description": func(d *models.Device) string { return d.Description },
+	"status":      func(d *models.Device) string { return d.Status },
+	"transport":   func(d *models.Device) string { return d.Transport },
+	"template_id": func(d *models.Device) string {
+		if d.TemplateID == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*d.TemplateID), 10)
+	},
+}
+
+// 兼容 Prometheus 风格的字段名
+var deviceSelectorAliases = map[string]string{
+	"ip":          "ip_address",
+	"instance":    "ip_address",
+	"job":         "type",
+	"device_type": "type",
+	"tag":         "tags",
+	"subnet":      "cidr",
+}
+
+type deviceSelector struct {
+	conditions []selectorCondition
+}
+
+type selectorCondition struct {
+	field   string
+	include []func(string) bool
+	exclude []func(string) bool
+}
+
+// parseDeviceSelector 解析分组中保存的选择器，空选择器返回 nil，表示静态分组
+func parseDeviceSelector(raw models.JSON) (*deviceSelector, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var selector map[string]interface{}
+	if err := json.Unmarshal(raw, &selector); err != nil {
+		return nil, fmt.Errorf("invalid selector: %v", err)
+	}
+	return compileDeviceSelector(selector)
+}
+
+func compileDeviceSelector(selector map[string]interface{}) (*deviceSelector, error) {
+	if len(selector) == 0 {
+		return nil, nil
+	}
+
+	fields := make([]string, 0, len(selector))
+	for field := range selector {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	compiled := &deviceSelector{}
+	for _, key := range fields {
+		field := strings.ToLower(strings.TrimSpace(key))
+		if alias, ok := deviceSelectorAliases[field]; ok {
+			field = alias
+		}
+		if _, ok := deviceSelectorFields[field]; !ok && field != "tags" && field != "cidr" {
+			return nil, fmt.Errorf("unknown selector field %q", key)
+		}
+
+		values, err := selectorValues(selector[key])
+		if err != nil {
+			return nil, fmt.Errorf("selector field %q: %v", key, err)
+		}
+		condition := selectorCondition{field: field}
+		for _, value := range values {
+			negate := strings.HasPrefix(value, "!")
+			value = strings.TrimPrefix(value, "!")
+
+			var match func(string) bool
+			if field == "cidr" {
+				match, err = cidrMatcher(value)
+			} else {
+				match, err = valueMatcher(value)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("selector field %q: %v", key, err)
+			}
+			if negate {
+				condition.exclude = append(condition.exclude, match)
+			} else {
+				condition.include = append(condition.include, match)
+			}
+		}
+		compiled.conditions = append(compiled.conditions, condition)
+	}
+	return compiled, nil
+}
+
+func selectorValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case float64, bool:
+		return []string{fmt.Sprint(v)}, nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, fmt.Errorf("empty value list")
+		}
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case string, float64, bool:
+				values = append(values, fmt.Sprint(item))
+			default:
+				return nil, fmt.Errorf("values must be strings")
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("value must be a string or a list of strings")
+}
+
+func valueMatcher(value string) (func(string) bool, error) {
+	if strings.HasPrefix(value, "~") {
+		re, err := regexp.Compile("(?i)^(?:" + strings.TrimPrefix(value, "~") + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", value, err)
+		}
+		return re.MatchString, nil
+	}
+	return func(s string) bool { return strings.EqualFold(s, value) }, nil
+}
+
+func cidrMatcher(value string) (func(string) bool, error) {
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		return func(s string) bool { return ip.Equal(net.ParseIP(s)) }, nil
+	}
+	return func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && network.Contains(ip)
+	}, nil
+}
+
+// Match 判断设备是否满足选择器的全部条件
+func (s *deviceSelector) Match(device *models.Device) bool {
+	for _, condition := range s.conditions {
+		if !condition.match(device) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *selectorCondition) match(device *models.Device) bool {
+	if c.field == "tags" {
+		for _, include := range c.include {
+			if !anyString(device.Tags, include) {
+				return false
+			}
+		}
+		for _, exclude := range c.exclude {
+			if anyString(device.Tags, exclude) {
+				return false
+			}
+		}
+		return true
+	}
+
+	value := device.IPAddress
+	if get, ok := deviceSelectorFields[c.field]; ok {
+		value = get(device)
+	}
+	if len(c.include) > 0 && !anyMatch(c.include, value) {
+		return false
+	}
+	return !anyMatch(c.exclude, value)
+}
+
+func anyMatch(matchers []func(string) bool, value string) bool {
+	for _, match := range matchers {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyString(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncDeviceGroup 按选择器重新计算分组成员；只增删 selector 来源的成员，手动添加的成员保持不变
+func syncDeviceGroup(db *gorm.DB, group *models.DeviceGroup) (*models.DeviceGroupSyncResult, error) {
+	result := &models.DeviceGroupSyncResult{GroupID: group.ID}
+	selector, err := parseDeviceSelector(group.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := map[string]bool{}
+	if selector != nil {
+		var devices []models.Device
+		if err := db.Find(&devices).Error; err != nil {
+			return nil, err
+		}
+		for i := range devices {
+			if selector.Match(&devices[i]) {
+				matched[strconv.FormatUint(uint64(devices[i].ID), 10)] = true
+			}
+		}
+	}
+	result.Matched = len(matched)
+
+	var members []models.DeviceGroupDevice
+	if err := db.Where("device_group_id = ?", group.ID).Find(&members).Error; err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, member := range members {
+		if matched[member.DeviceID] {
+			delete(matched, member.DeviceID)
+		} else if member.Source == "selector" {
+			removed = append(removed, member.DeviceID)
+		}
+	}
+	added := make([]models.DeviceGroupDevice, 0, len(matched))
+	for deviceID := range matched {
+		added = append(added, models.DeviceGroupDevice{DeviceGroupID: group.ID, DeviceID: deviceID, Source: "selector"})
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if len(removed) > 0 {
+			err := tx.Where("device_group_id = ? AND source = ? AND device_id IN ?", group.ID, "selector", removed).
+				Delete(&models.DeviceGroupDevice{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(added) > 0 {
+			return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&added).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update group members: %v", err)
+	}
+
+	result.Added = len(added)
+	result.Removed = len(removed)
+	return result, nil
+}
+
+// syncDeviceMemberships 设备新增、修改或删除后，重新判断其在所有选择器分组中的成员关系
+func syncDeviceMemberships(db *gorm.DB, deviceIDs ...uint) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+
+	var groups []models.DeviceGroup
+	if err := db.Where("selector IS NOT NULL").Find(&groups).Error; err != nil {
+		return err
+	}
+	var devices []models.Device
+	if err := db.Where("id IN ?", deviceIDs).Find(&devices).Error; err != nil {
+		return err
+	}
+	present := make(map[uint]*models.Device, len(devices))
+	for i := range devices {
+		present[devices[i].ID] = &devices[i]
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, group := range groups {
+			selector, err := parseDeviceSelector(group.Selector)
+			if err != nil {
+				log.Printf("skipping device group %s with invalid selector: %v", group.ID, err)
+				continue
+			}
+			for _, id := range deviceIDs {
+				deviceID := strconv.FormatUint(uint64(id), 10)
+				if device, ok := present[id]; ok && selector != nil && selector.Match(device) {
+					member := &models.DeviceGroupDevice{DeviceGroupID: group.ID, DeviceID: deviceID, Source: "selector"}
+					if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				err := tx.Where("device_group_id = ? AND device_id = ? AND source = ?", group.ID, deviceID, "selector").
+					Delete(&models.DeviceGroupDevice{}).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// previewDeviceSelector 返回选择器当前能匹配到的设备
+func previewDeviceSelector(db *gorm.DB, selector map[string]interface{}) (*models.DeviceGroupPreview, error) {
+	compiled, err := compileDeviceSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	if compiled == nil {
+		return nil, fmt.Errorf("selector is empty")
+	}
+
+	var devices []models.Device
+	if err := db.Order("id").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	preview := &models.DeviceGroupPreview{Devices: []models.Device{}}
+	for i := range devices {
+		if compiled.Match(&devices[i]) {
+			preview.Devices = append(preview.Devices, devices[i])
+		}
+	}
+	preview.Total = len(preview.Devices)
+	return preview, nil
+}
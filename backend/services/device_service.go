@@ -3,6 +3,7 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -47,6 +48,7 @@ func (s *DeviceService) GetDevices(page, limit int, search, status string) ([]mo
 		return nil, 0, err
 	}
 
+	s.attachMaintenance(devices)
 	return devices, total, nil
 }
 
@@ -55,11 +57,44 @@ func (s *DeviceService) GetDevice(id uint) (*models.Device, error) {
 	if err := s.db.Preload("Template").Preload("Credentials").First(&device, id).Error; err != nil {
 		return nil, err
 	}
-	return &device, nil
+	devices := []models.Device{device}
+	s.attachMaintenance(devices)
+	return &devices[0], nil
+}
+
+// attachMaintenance 填充设备生效中和即将开始的维护窗口，失败只记录日志
+func (s *DeviceService) attachMaintenance(devices []models.Device) {
+	ids := make([]uint, len(devices))
+	for i := range devices {
+		ids[i] = devices[i].ID
+	}
+	windows, err := deviceMaintenance(s.db, ids)
+	if err != nil {
+		log.Printf("failed to load maintenance windows for devices: %v", err)
+		return
+	}
+	for i := range devices {
+		devices[i].Maintenance = windows[devices[i].ID]
+	}
 }
 
 func (s *DeviceService) CreateDevice(device *models.Device) error {
-	return s.db.Create(device).Error
+	if err := s.db.Create(device).Error; err != nil {
+		return err
+	}
+
+	// 有凭据且厂商、型号或模板未填写时，按指纹自动识别；识别失败不影响创建
+	if len(device.Credentials) > 0 && (device.Vendor == "" || device.Model == "" || device.TemplateID == nil) {
+		classified, _, err := NewFingerprintService(s.db, s.redis).ClassifyDevice(device.ID, false)
+		if err != nil {
+			log.Printf("failed to classify device %s: %v", device.Name, err)
+		} else {
+			*device = *classified
+		}
+	}
+
+	s.syncGroups(device.ID)
+	return nil
 }
 
 func (s *DeviceService) UpdateDevice(id uint, updates *models.Device) (*models.Device, error) {
@@ -68,15 +103,41 @@ func (s *DeviceService) UpdateDevice(id uint, updates *models.Device) (*models.D
 		return nil, err
 	}
 
+	rebound := templateChanged(device.TemplateID, updates.TemplateID)
 	if err := s.db.Model(&device).Updates(updates).Error; err != nil {
 		return nil, err
 	}
+	if rebound {
+		if err := s.db.Model(&device).UpdateColumn("template_version", 0).Error; err != nil {
+			return nil, err
+		}
+	}
 
+	s.syncGroups(device.ID)
 	return &device, nil
 }
 
 func (s *DeviceService) DeleteDevice(id uint) error {
-	return s.db.Delete(&models.Device{}, id).Error
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Device{}, id).Error; err != nil {
+			return err
+		}
+		// 最新采集值不再有意义，轮询历史保留
+		return tx.Where("device_id = ?", id).Delete(&models.PollValue{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	s.syncGroups(id)
+	return nil
+}
+
+// syncGroups 重新计算设备所属的选择器分组，失败只记录日志
+func (s *DeviceService) syncGroups(deviceIDs ...uint) {
+	if err := syncDeviceMemberships(s.db, deviceIDs...); err != nil {
+		log.Printf("failed to sync device group membership for devices %v: %v", deviceIDs, err)
+	}
 }
 
 func (s *DeviceService) TestDevice(id uint) (map[string]interface{}, error) {
@@ -97,20 +158,7 @@ func (s *DeviceService) TestDevice(id uint) (map[string]interface{}, error) {
 	cred := device.Credentials[0]
 
 	// Create SNMP test request
-	snmpReq := &models.SNMPRequest{
-		Target:    device.IPAddress,
-		Port:      device.Port,
-		Version:   cred.Version,
-		Community: cred.Community,
-		Username:  cred.Username,
-		AuthProto: cred.AuthProto,
-		AuthKey:   cred.AuthKey,
-		PrivProto: cred.PrivProto,
-		PrivKey:   cred.PrivKey,
-		OID:       "1.3.6.1.2.1.1.3.0", // sysUpTime
-		Timeout:   5,
-		Retries:   3,
-	}
+	snmpReq := newDeviceSNMPRequest(device, cred, "1.3.6.1.2.1.1.3.0") // sysUpTime
 
 	// Create SNMP service for testing
 	snmpService := NewSNMPService(s.db, s.redis)
@@ -123,16 +171,9 @@ func (s *DeviceService) TestDevice(id uint) (map[string]interface{}, error) {
 	}
 
 	// Update device status based on test result
-	status := "offline"
-	if result["success"].(bool) {
-		status = "online"
-		now := time.Now()
-		s.db.Model(device).Updates(map[string]interface{}{
-			"status":    status,
-			"last_seen": &now,
-		})
-	} else {
-		s.db.Model(device).Update("status", status)
+	status, reason := deviceTestStatus(result)
+	if _, err := recordDeviceStatus(s.db, device.ID, status, reason, "manual"); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -153,8 +194,24 @@ func (s *DeviceService) GetDeviceTemplates(deviceType string) ([]models.DeviceTe
 	return templates, nil
 }
 
+// CreateDeviceTemplate 创建模板并保存第一个版本
 func (s *DeviceService) CreateDeviceTemplate(template *models.DeviceTemplate) error {
-	return s.db.Create(template).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		tree, err := loadTemplateTree(tx)
+		if err != nil {
+			return err
+		}
+		if err := tree.validateParent(0, template.ParentID); err != nil {
+			return err
+		}
+
+		template.Version = 1
+		if err := tx.Create(template).Error; err != nil {
+			return err
+		}
+		tree[template.ID] = template
+		return recordTemplateVersion(tx, tree, template.ID, "created")
+	})
 }
 
 // GetDeviceGroupDevices 获取设备分组中的设备
@@ -178,6 +235,12 @@ func (s *DeviceService) BatchCreateDeviceGroups(groups []models.CreateDeviceGrou
 	}
 
 	for _, groupReq := range groups {
+		if _, err := compileDeviceSelector(groupReq.Selector); err != nil {
+			response.FailureCount++
+			response.Errors = append(response.Errors, fmt.Sprintf("创建设备分组失败 %s: 选择器无效: %v", groupReq.Name, err))
+			continue
+		}
+
 		// 转换标签和选择器为JSON
 		tagsJson, _ := json.Marshal(groupReq.Tags)
 		selectorJson, _ := json.Marshal(groupReq.Selector)
@@ -196,6 +259,9 @@ func (s *DeviceService) BatchCreateDeviceGroups(groups []models.CreateDeviceGrou
 			response.FailureCount++
 			response.Errors = append(response.Errors, fmt.Sprintf("创建设备分组失败 %s: %v", groupReq.Name, err))
 		} else {
+			if _, err := syncDeviceGroup(s.db, &group); err != nil {
+				response.Errors = append(response.Errors, fmt.Sprintf("计算分组成员失败 %s: %v", groupReq.Name, err))
+			}
 			response.SuccessCount++
 			response.CreatedGroups = append(response.CreatedGroups, group)
 		}
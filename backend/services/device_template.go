@@ -0,0 +1,604 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mib-platform/models"
+)
+
+var ErrInvalidTemplate = errors.New("invalid device template")
+
+// maxTemplateDepth 继承链的最大层数
+const maxTemplateDepth = 10
+
+// templateTree 按 ID 索引的设备模板，用于计算继承后的生效内容
+type templateTree map[uint]*models.DeviceTemplate
+
+func loadTemplateTree(db *gorm.DB) (templateTree, error) {
+	var templates []models.DeviceTemplate
+	if err := db.Preload("MIBs").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load device templates: %v", err)
+	}
+	tree := make(templateTree, len(templates))
+	for i := range templates {
+		tree[templates[i].ID] = &templates[i]
+	}
+	return tree, nil
+}
+
+// resolveDeviceTemplate 只加载 id 的继承链并返回生效模板，供轮询等高频路径使用；结果不包含 MIB
+func resolveDeviceTemplate(db *gorm.DB, id uint) (*models.DeviceTemplate, error) {
+	tree := templateTree{}
+	for next := &id; next != nil && len(tree) <= maxTemplateDepth; {
+		if _, ok := tree[*next]; ok {
+			break
+		}
+		var tmpl models.DeviceTemplate
+		if err := db.First(&tmpl, *next).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) && len(tree) > 0 {
+				break
+			}
+			return nil, err
+		}
+		tree[tmpl.ID] = &tmpl
+		next = tmpl.ParentID
+	}
+	return tree.resolve(id)
+}
+
+// chain 返回从根模板到 id 的继承链；父模板已删除时视为根模板
+func (t templateTree) chain(id uint) ([]*models.DeviceTemplate, error) {
+	tmpl, ok := t[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	chain := []*models.DeviceTemplate{tmpl}
+	for tmpl.ParentID != nil {
+		parent, ok := t[*tmpl.ParentID]
+		if !ok {
+			break
+		}
+		if len(chain) >= maxTemplateDepth || parent.ID == id {
+			return nil, fmt.Errorf("%w: template %s has an inheritance cycle or more than %d levels", ErrInvalidTemplate, t[id].Name, maxTemplateDepth)
+		}
+		chain = append(chain, parent)
+		tmpl = parent
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+// resolve 沿继承链合并：每一层先去掉 exclude_oids 覆盖的继承 OID，再追加自身 OID；
+// config 按键覆盖，MIB 取并集。返回的副本中 OIDs、Config、MIBs 均为生效值
+func (t templateTree) resolve(id uint) (*models.DeviceTemplate, error) {
+	chain, err := t.chain(id)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := *chain[len(chain)-1]
+	var oids []string
+	config := map[string]interface{}{}
+	var mibs []models.MIB
+	seenMIBs := map[uint]bool{}
+	for _, tmpl := range chain {
+		oids = appendOIDs(excludeOIDs(oids, tmpl.ExcludeOIDs), tmpl.OIDs)
+		for key, value := range tmpl.Config {
+			config[key] = value
+		}
+		for _, mib := range tmpl.MIBs {
+			if !seenMIBs[mib.ID] {
+				seenMIBs[mib.ID] = true
+				mibs = append(mibs, mib)
+			}
+		}
+	}
+
+	resolved.OIDs = oids
+	resolved.Config = config
+	resolved.MIBs = mibs
+	return &resolved, nil
+}
+
+// descendants 返回继承 id 的全部子孙模板，按层级从近到远
+func (t templateTree) descendants(id uint) []uint {
+	children := map[uint][]uint{}
+	for _, tmpl := range t {
+		if tmpl.ParentID != nil {
+			children[*tmpl.ParentID] = append(children[*tmpl.ParentID], tmpl.ID)
+		}
+	}
+	for parent := range children {
+		sort.Slice(children[parent], func(i, j int) bool { return children[parent][i] < children[parent][j] })
+	}
+
+	var result []uint
+	seen := map[uint]bool{id: true}
+	queue := children[id]
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		result = append(result, next)
+		queue = append(queue, children[next]...)
+	}
+	return result
+}
+
+// validateParent 父模板必须存在，且不能是自身或自身的子孙
+func (t templateTree) validateParent(id uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+	if _, ok := t[*parentID]; !ok {
+		return fmt.Errorf("%w: parent template %d not found", ErrInvalidTemplate, *parentID)
+	}
+	if *parentID == id {
+		return fmt.Errorf("%w: template cannot inherit from itself", ErrInvalidTemplate)
+	}
+	for _, descendant := range t.descendants(id) {
+		if descendant == *parentID {
+			return fmt.Errorf("%w: parent template %d inherits from this template", ErrInvalidTemplate, *parentID)
+		}
+	}
+	chain, err := t.chain(*parentID)
+	if err != nil {
+		return err
+	}
+	if len(chain) >= maxTemplateDepth {
+		return fmt.Errorf("%w: inheritance is limited to %d levels", ErrInvalidTemplate, maxTemplateDepth)
+	}
+	return nil
+}
+
+// excludeOIDs 去掉与 exclude 相同或位于其子树下的 OID
+func excludeOIDs(oids, exclude []string) []string {
+	if len(exclude) == 0 {
+		return oids
+	}
+	kept := make([]string, 0, len(oids))
+	for _, oid := range oids {
+		excluded := false
+		for _, prefix := range exclude {
+			prefix = normalizeOID(prefix)
+			if prefix != "" && (oid == prefix || strings.HasPrefix(oid, prefix+".")) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, oid)
+		}
+	}
+	return kept
+}
+
+func appendOIDs(oids, extra []string) []string {
+	for _, oid := range extra {
+		oid = normalizeOID(oid)
+		if oid != "" && !containsString(oids, oid) {
+			oids = append(oids, oid)
+		}
+	}
+	return oids
+}
+
+// templateChanged 设备换绑了模板，原采集配置对应的模板版本不再有效
+func templateChanged(current, next *uint) bool {
+	return next != nil && (current == nil || *current != *next)
+}
+
+// describeTemplateChange 概括两个生效模板之间的差异
+func describeTemplateChange(before, after *models.DeviceTemplate) string {
+	var parts []string
+	var added, removed []string
+	for _, oid := range after.OIDs {
+		if !containsString(before.OIDs, oid) {
+			added = append(added, oid)
+		}
+	}
+	for _, oid := range before.OIDs {
+		if !containsString(after.OIDs, oid) {
+			removed = append(removed, oid)
+		}
+	}
+	if len(added) > 0 {
+		parts = append(parts, "added OIDs: "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		parts = append(parts, "removed OIDs: "+strings.Join(removed, ", "))
+	}
+	if !reflect.DeepEqual(before.Config, after.Config) {
+		parts = append(parts, "config changed")
+	}
+	if !reflect.DeepEqual(before.ParentID, after.ParentID) {
+		parts = append(parts, "parent changed")
+	}
+	if len(parts) == 0 {
+		return "no effective change"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// recordTemplateVersion 保存模板当前版本的快照
+func recordTemplateVersion(tx *gorm.DB, tree templateTree, id uint, changes string) error {
+	resolved, err := tree.resolve(id)
+	if err != nil {
+		return err
+	}
+	tmpl := tree[id]
+	return tx.Create(&models.DeviceTemplateVersion{
+		TemplateID:    tmpl.ID,
+		Version:       tmpl.Version,
+		Name:          tmpl.Name,
+		ParentID:      tmpl.ParentID,
+		OIDs:          tmpl.OIDs,
+		ExcludeOIDs:   tmpl.ExcludeOIDs,
+		EffectiveOIDs: resolved.OIDs,
+		Config:        resolved.Config,
+		Changes:       changes,
+	}).Error
+}
+
+// GetDeviceTemplate 获取模板及合并继承链后的生效内容
+func (s *DeviceService) GetDeviceTemplate(id uint) (*models.EffectiveDeviceTemplate, error) {
+	tree, err := loadTemplateTree(s.db)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := tree.chain(id)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := tree.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := &models.EffectiveDeviceTemplate{
+		Template:      *tree[id],
+		EffectiveOIDs: resolved.OIDs,
+		Config:        resolved.Config,
+		MIBs:          resolved.MIBs,
+	}
+	for _, tmpl := range chain {
+		effective.Chain = append(effective.Chain, models.DeviceTemplateRef{ID: tmpl.ID, Name: tmpl.Name, Version: tmpl.Version})
+	}
+	return effective, nil
+}
+
+// UpdateDeviceTemplate 修改模板并生成新版本；生效 OID 或配置因此改变的子孙模板同时生成新版本
+func (s *DeviceService) UpdateDeviceTemplate(id uint, updates *models.UpdateDeviceTemplateRequest) (*models.DeviceTemplate, error) {
+	var template models.DeviceTemplate
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tree, err := loadTemplateTree(tx)
+		if err != nil {
+			return err
+		}
+		existing, ok := tree[id]
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+		parentID := existing.ParentID
+		if updates.ParentID != nil {
+			parentID = updates.ParentID
+			if *parentID == 0 {
+				parentID = nil
+			}
+		}
+		if err := tree.validateParent(id, parentID); err != nil {
+			return err
+		}
+
+		affected := append([]uint{id}, tree.descendants(id)...)
+		before := make(map[uint]*models.DeviceTemplate, len(affected))
+		for _, templateID := range affected {
+			if before[templateID], err = tree.resolve(templateID); err != nil {
+				return err
+			}
+		}
+
+		if updates.Name != nil {
+			if *updates.Name == "" {
+				return fmt.Errorf("%w: name cannot be empty", ErrInvalidTemplate)
+			}
+			existing.Name = *updates.Name
+		}
+		if updates.Type != nil {
+			if *updates.Type == "" {
+				return fmt.Errorf("%w: type cannot be empty", ErrInvalidTemplate)
+			}
+			existing.Type = *updates.Type
+		}
+		if updates.Vendor != nil {
+			existing.Vendor = *updates.Vendor
+		}
+		if updates.Description != nil {
+			existing.Description = *updates.Description
+		}
+		existing.ParentID = parentID
+		if updates.OIDs != nil {
+			existing.OIDs = *updates.OIDs
+		}
+		if updates.ExcludeOIDs != nil {
+			existing.ExcludeOIDs = *updates.ExcludeOIDs
+		}
+		if updates.Config != nil {
+			existing.Config = updates.Config
+		}
+		existing.Version++
+		if err := tx.Omit("MIBs").Save(existing).Error; err != nil {
+			return err
+		}
+		if updates.MIBs != nil {
+			if err := tx.Model(existing).Association("MIBs").Replace(updates.MIBs); err != nil {
+				return err
+			}
+			existing.MIBs = updates.MIBs
+		}
+
+		for _, templateID := range affected {
+			after, err := tree.resolve(templateID)
+			if err != nil {
+				return err
+			}
+			changes := describeTemplateChange(before[templateID], after)
+			if templateID != id {
+				if reflect.DeepEqual(before[templateID].OIDs, after.OIDs) && reflect.DeepEqual(before[templateID].Config, after.Config) {
+					continue
+				}
+				descendant := tree[templateID]
+				descendant.Version++
+				if err := tx.Model(descendant).UpdateColumn("version", descendant.Version).Error; err != nil {
+					return err
+				}
+				changes = fmt.Sprintf("inherited from %s v%d; %s", existing.Name, existing.Version, changes)
+			}
+			if err := recordTemplateVersion(tx, tree, templateID, changes); err != nil {
+				return err
+			}
+		}
+
+		template = *existing
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetDeviceTemplateVersions 获取模板的版本历史，最新的在前
+func (s *DeviceService) GetDeviceTemplateVersions(id uint) ([]models.DeviceTemplateVersion, error) {
+	var versions []models.DeviceTemplateVersion
+	if err := s.db.Where("template_id = ?", id).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetDeviceTemplateUsage 按版本列出绑定该模板的设备
+func (s *DeviceService) GetDeviceTemplateUsage(id uint) (*models.DeviceTemplateUsage, error) {
+	var tmpl models.DeviceTemplate
+	if err := s.db.First(&tmpl, id).Error; err != nil {
+		return nil, err
+	}
+	var devices []models.Device
+	if err := s.db.Where("template_id = ?", id).Order("id").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	var children []models.DeviceTemplate
+	if err := s.db.Where("parent_id = ?", id).Order("name").Find(&children).Error; err != nil {
+		return nil, err
+	}
+
+	usage := &models.DeviceTemplateUsage{
+		TemplateID:     tmpl.ID,
+		Name:           tmpl.Name,
+		CurrentVersion: tmpl.Version,
+		Total:          len(devices),
+		Versions:       []models.DeviceTemplateVersionUsage{},
+		Children:       []models.DeviceTemplateRef{},
+	}
+	byVersion := map[int]int{}
+	for _, device := range devices {
+		if device.TemplateVersion != tmpl.Version {
+			usage.Outdated++
+		}
+		index, ok := byVersion[device.TemplateVersion]
+		if !ok {
+			index = len(usage.Versions)
+			byVersion[device.TemplateVersion] = index
+			usage.Versions = append(usage.Versions, models.DeviceTemplateVersionUsage{
+				Version: device.TemplateVersion,
+				Current: device.TemplateVersion == tmpl.Version,
+			})
+		}
+		usage.Versions[index].Devices = append(usage.Versions[index].Devices, models.DeviceTemplateUser{
+			ID:        device.ID,
+			Name:      device.Name,
+			IPAddress: device.IPAddress,
+		})
+	}
+	sort.Slice(usage.Versions, func(i, j int) bool { return usage.Versions[i].Version > usage.Versions[j].Version })
+
+	for _, child := range children {
+		usage.Children = append(usage.Children, models.DeviceTemplateRef{ID: child.ID, Name: child.Name, Version: child.Version})
+	}
+	return usage, nil
+}
+
+// ApplyDeviceTemplate 为绑定该模板及其子孙模板的全部设备重新生成采集配置
+func (s *DeviceService) ApplyDeviceTemplate(id uint, req *models.ApplyDeviceTemplateRequest) (*models.DeviceTemplateApplyResult, error) {
+	configTypes := req.ConfigTypes
+	if len(configTypes) == 0 {
+		configTypes = []string{"snmp_exporter"}
+	}
+	for _, configType := range configTypes {
+		if configType != "snmp_exporter" && configType != "categraf" && configType != "telegraf" {
+			return nil, fmt.Errorf("%w: unsupported config type %s", ErrInvalidTemplate, configType)
+		}
+	}
+
+	tree, err := loadTemplateTree(s.db)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := tree[id]; !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	templateIDs := append([]uint{id}, tree.descendants(id)...)
+	resolved := make(map[uint]*models.DeviceTemplate, len(templateIDs))
+	for _, templateID := range templateIDs {
+		if resolved[templateID], err = tree.resolve(templateID); err != nil {
+			return nil, err
+		}
+	}
+
+	var devices []models.Device
+	if err := s.db.Preload("Credentials").Where("template_id IN ?", templateIDs).Order("id").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	result := &models.DeviceTemplateApplyResult{
+		TemplateID: id,
+		Templates:  len(templateIDs),
+		Devices:    len(devices),
+	}
+	configService := NewConfigService(s.db, s.redis)
+	for i := range devices {
+		device := &devices[i]
+		changed, err := s.applyTemplateToDevice(configService, device, resolved[*device.TemplateID], configTypes)
+		switch {
+		case err != nil:
+			result.Failed++
+			result.Errors = append(result.Errors, fmt.Sprintf("device %s: %v", device.Name, err))
+		case changed:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+	return result, nil
+}
+
+// ExportDeviceTemplateZabbix 把模板合并继承链后的 OID 导出为 Zabbix 模板；未指定时模板名取设备模板名，
+// 告警规则模板取配置中的 alert_rule_templates，采集间隔取 poll_interval
+func (s *DeviceService) ExportDeviceTemplateZabbix(id uint, req *ZabbixExportRequest) (*ZabbixExportResult, error) {
+	tmpl, err := resolveDeviceTemplate(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tmpl.OIDs) == 0 {
+		return nil, fmt.Errorf("%w: template %s has no OIDs", ErrInvalidTemplate, tmpl.Name)
+	}
+
+	export := *req
+	export.SelectedOIDs = tmpl.OIDs
+	if export.TemplateName == "" {
+		export.TemplateName = tmpl.Name
+	}
+	if len(export.AlertTemplateIDs) == 0 {
+		export.AlertTemplateIDs = zabbixAlertTemplateIDs(tmpl.Config)
+	}
+	if export.Interval == "" {
+		if seconds := toPollSeconds(tmpl.Config["poll_interval"]); seconds > 0 {
+			export.Interval = fmt.Sprintf("%ds", seconds)
+		}
+	}
+	return NewConfigService(s.db, s.redis).ExportZabbixTemplate(export)
+}
+
+// applyTemplateToDevice 按生效模板重新生成设备的采集配置，内容变化时保存为配置的新版本，并记录设备使用的模板版本
+func (s *DeviceService) applyTemplateToDevice(configService *ConfigService, device *models.Device, tmpl *models.DeviceTemplate, configTypes []string) (bool, error) {
+	if len(tmpl.OIDs) == 0 {
+		return false, fmt.Errorf("template %s has no OIDs", tmpl.Name)
+	}
+
+	req := ConfigGenerationRequest{
+		ConfigName: tmpl.Name,
+		DeviceInfo: DeviceInfo{
+			IP:        device.IPAddress,
+			Name:      device.Name,
+			Port:      device.Port,
+			Transport: device.Transport,
+		},
+		SelectedOIDs: tmpl.OIDs,
+	}
+	if len(device.Credentials) > 0 {
+		cred := device.Credentials[0]
+		req.DeviceInfo.Community = cred.Community
+		req.DeviceInfo.Version = cred.Version
+		req.DeviceInfo.Username = cred.Username
+		req.DeviceInfo.AuthProto = cred.AuthProto
+		req.DeviceInfo.AuthKey = cred.AuthKey
+		req.DeviceInfo.PrivProto = cred.PrivProto
+		req.DeviceInfo.PrivKey = cred.PrivKey
+	}
+	version := fmt.Sprintf("%s v%d", tmpl.Name, tmpl.Version)
+
+	changed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, configType := range configTypes {
+			req.ConfigType = configType
+			content, err := configService.renderConfig(req)
+			if err != nil {
+				return err
+			}
+
+			var config models.Config
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("device_id = ? AND type = ?", device.ID, configType).First(&config).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				config = models.Config{
+					Name:     fmt.Sprintf("%s-%s", device.Name, configType),
+					Type:     configType,
+					Content:  content,
+					DeviceID: &device.ID,
+					Status:   "generated",
+					Version:  version,
+				}
+				if err := tx.Create(&config).Error; err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			case config.Content == content:
+				continue
+			default:
+				if err := tx.Model(&config).Updates(map[string]interface{}{"content": content, "version": version}).Error; err != nil {
+					return err
+				}
+			}
+
+			err = recordConfigVersion(tx, &models.ConfigVersion{
+				ConfigID:  config.ID,
+				Version:   version,
+				Content:   content,
+				Changes:   fmt.Sprintf("generated from device template %s v%d", tmpl.Name, tmpl.Version),
+				CreatedBy: "template",
+			})
+			if err != nil {
+				return err
+			}
+			changed = true
+		}
+		return tx.Model(device).UpdateColumn("template_version", tmpl.Version).Error
+	})
+	return changed, err
+}
@@ -0,0 +1,572 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+)
+
+const enterprisesOIDPrefix = "1.3.6.1.4.1."
+
+type FingerprintService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	snmpService *SNMPService
+}
+
+func NewFingerprintService(db *gorm.DB, redis *redis.Client) *FingerprintService {
+	return &FingerprintService{
+		db:          db,
+		redis:       redis,
+		snmpService: NewSNMPService(db, redis),
+	}
+}
+
+func (s *FingerprintService) GetFingerprints(page, limit int, vendor string) ([]models.DeviceFingerprint, int64, error) {
+	var fingerprints []models.DeviceFingerprint
+	var total int64
+
+	query := s.db.Model(&models.DeviceFingerprint{})
+	if vendor != "" {
+		query = query.Where("vendor = ?", vendor)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Template").Order("priority DESC, name").Offset(offset).Limit(limit).Find(&fingerprints).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return fingerprints, total, nil
+}
+
+func (s *FingerprintService) GetFingerprint(id uint) (*models.DeviceFingerprint, error) {
+	var fingerprint models.DeviceFingerprint
+	if err := s.db.Preload("Template").First(&fingerprint, id).Error; err != nil {
+		return nil, err
+	}
+	return &fingerprint, nil
+}
+
+func (s *FingerprintService) CreateFingerprint(fingerprint *models.DeviceFingerprint) error {
+	if err := validateFingerprint(fingerprint); err != nil {
+		return err
+	}
+	fingerprint.Builtin = false
+	return s.db.Create(fingerprint).Error
+}
+
+// UpdateFingerprint 整体替换规则内容，内置标记保持不变
+func (s *FingerprintService) UpdateFingerprint(id uint, updates *models.DeviceFingerprint) (*models.DeviceFingerprint, error) {
+	fingerprint, err := s.GetFingerprint(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint.Name = updates.Name
+	fingerprint.SysObjectIDPrefix = updates.SysObjectIDPrefix
+	fingerprint.SysDescrPattern = updates.SysDescrPattern
+	fingerprint.Vendor = updates.Vendor
+	fingerprint.Model = updates.Model
+	fingerprint.OSFamily = updates.OSFamily
+	fingerprint.DeviceType = updates.DeviceType
+	fingerprint.TemplateID = updates.TemplateID
+	fingerprint.Template = nil
+	fingerprint.Priority = updates.Priority
+	fingerprint.Disabled = updates.Disabled
+	fingerprint.Description = updates.Description
+
+	if err := validateFingerprint(fingerprint); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(fingerprint).Error; err != nil {
+		return nil, err
+	}
+	return fingerprint, nil
+}
+
+// DeleteFingerprint 物理删除规则，以便同名规则可以重新创建或导入
+func (s *FingerprintService) DeleteFingerprint(id uint) error {
+	return s.db.Unscoped().Delete(&models.DeviceFingerprint{}, id).Error
+}
+
+// ImportFingerprints 按名称新增或更新规则，单条错误不影响其余规则
+func (s *FingerprintService) ImportFingerprints(req *models.ImportFingerprintsRequest) (*models.ImportFingerprintsResult, error) {
+	result := &models.ImportFingerprintsResult{Errors: []string{}}
+
+	templates, err := s.templateIDsByName()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if req.Replace {
+			res := tx.Unscoped().Where("builtin = ?", false).Delete(&models.DeviceFingerprint{})
+			if res.Error != nil {
+				return res.Error
+			}
+			result.Deleted = res.RowsAffected
+		}
+
+		for i, entry := range req.Fingerprints {
+			fingerprint := models.DeviceFingerprint{
+				Name:              entry.Name,
+				SysObjectIDPrefix: entry.SysObjectIDPrefix,
+				SysDescrPattern:   entry.SysDescrPattern,
+				Vendor:            entry.Vendor,
+				Model:             entry.Model,
+				OSFamily:          entry.OSFamily,
+				DeviceType:        entry.DeviceType,
+				Priority:          entry.Priority,
+				Disabled:          entry.Disabled,
+				Description:       entry.Description,
+			}
+			if entry.Template != "" {
+				id, ok := templates[entry.Template]
+				if !ok {
+					result.Errors = append(result.Errors, fmt.Sprintf("fingerprint %d (%s): template %q not found", i, entry.Name, entry.Template))
+					continue
+				}
+				fingerprint.TemplateID = &id
+			}
+			if err := validateFingerprint(&fingerprint); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("fingerprint %d (%s): %v", i, entry.Name, err))
+				continue
+			}
+
+			var existing models.DeviceFingerprint
+			err := tx.Where("name = ?", fingerprint.Name).First(&existing).Error
+			switch {
+			case err == nil:
+				fingerprint.ID = existing.ID
+				fingerprint.Builtin = existing.Builtin
+				fingerprint.CreatedAt = existing.CreatedAt
+				if err := tx.Save(&fingerprint).Error; err != nil {
+					return err
+				}
+				result.Updated++
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if err := tx.Create(&fingerprint).Error; err != nil {
+					return err
+				}
+				result.Created++
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to import fingerprints: %v", err)
+	}
+
+	return result, nil
+}
+
+// ExportFingerprints 导出全部规则，模板以名称表示
+func (s *FingerprintService) ExportFingerprints() ([]models.FingerprintEntry, error) {
+	var fingerprints []models.DeviceFingerprint
+	if err := s.db.Preload("Template").Order("priority DESC, name").Find(&fingerprints).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.FingerprintEntry, 0, len(fingerprints))
+	for _, fingerprint := range fingerprints {
+		entry := models.FingerprintEntry{
+			Name:              fingerprint.Name,
+			SysObjectIDPrefix: fingerprint.SysObjectIDPrefix,
+			SysDescrPattern:   fingerprint.SysDescrPattern,
+			Vendor:            fingerprint.Vendor,
+			Model:             fingerprint.Model,
+			OSFamily:          fingerprint.OSFamily,
+			DeviceType:        fingerprint.DeviceType,
+			Priority:          fingerprint.Priority,
+			Disabled:          fingerprint.Disabled,
+			Description:       fingerprint.Description,
+		}
+		if fingerprint.Template != nil {
+			entry.Template = fingerprint.Template.Name
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// LoadBuiltinFingerprints 写入内置规则，已存在的同名规则保持不变
+func (s *FingerprintService) LoadBuiltinFingerprints() (*models.ImportFingerprintsResult, error) {
+	result := &models.ImportFingerprintsResult{Errors: []string{}}
+	for _, fingerprint := range builtinFingerprints() {
+		var count int64
+		if err := s.db.Model(&models.DeviceFingerprint{}).Where("name = ?", fingerprint.Name).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to load builtin fingerprints: %v", err)
+		}
+		if count > 0 {
+			continue
+		}
+		if err := s.db.Create(&fingerprint).Error; err != nil {
+			return nil, fmt.Errorf("failed to load builtin fingerprints: %v", err)
+		}
+		result.Created++
+	}
+	return result, nil
+}
+
+// Classify 按注册表识别系统信息，注册表为空时使用内置规则
+func (s *FingerprintService) Classify(sysObjectID, sysDescr string) (*models.FingerprintMatch, error) {
+	classifier, err := s.loadClassifier()
+	if err != nil {
+		return nil, err
+	}
+	return classifier.classify(normalizeOID(sysObjectID), sysDescr), nil
+}
+
+// ClassifyDevice 读取设备 system 组并写入识别结果；force 为 false 时只补齐空字段和未设置的模板
+func (s *FingerprintService) ClassifyDevice(deviceID uint, force bool) (*models.Device, *models.FingerprintMatch, error) {
+	device, err := NewDeviceService(s.db, s.redis).GetDevice(deviceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(device.Credentials) == 0 {
+		return nil, nil, fmt.Errorf("device %s has no SNMP credentials", device.Name)
+	}
+
+	info, err := s.snmpService.querySystemInfo(newDeviceSNMPRequest(device, device.Credentials[0], oidSysObjectID), 2*time.Second, 1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query system information: %v", err)
+	}
+
+	match, err := s.Classify(info.SysObjectID, info.SysDescr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates := map[string]interface{}{}
+	setField := func(column, current, value string) {
+		if value != "" && (force || current == "") {
+			updates[column] = value
+		}
+	}
+	setField("vendor", device.Vendor, match.Vendor)
+	setField("model", device.Model, match.Model)
+	setField("os_family", device.OSFamily, match.OSFamily)
+	setField("type", device.Type, match.DeviceType)
+	setField("hostname", device.Hostname, info.SysName)
+	setField("location", device.Location, info.SysLocation)
+	setField("contact", device.Contact, info.SysContact)
+	setField("description", device.Description, info.SysDescr)
+	if match.TemplateID != nil && (force || device.TemplateID == nil) {
+		updates["template_id"] = *match.TemplateID
+		if templateChanged(device.TemplateID, match.TemplateID) {
+			updates["template_version"] = 0
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.db.Model(&models.Device{ID: device.ID}).Updates(updates).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to update device: %v", err)
+		}
+		deviceService := NewDeviceService(s.db, s.redis)
+		deviceService.syncGroups(deviceID)
+		device, err = deviceService.GetDevice(deviceID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return device, match, nil
+}
+
+func (s *FingerprintService) templateIDsByName() (map[string]uint, error) {
+	var templates []models.DeviceTemplate
+	if err := s.db.Select("id", "name").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, len(templates))
+	for _, template := range templates {
+		ids[template.Name] = template.ID
+	}
+	return ids, nil
+}
+
+// loadClassifier 加载已启用的注册表规则；注册表没有任何规则时使用内置规则，
+// 否则只使用注册表，停用或删除的内置规则不再生效
+func (s *FingerprintService) loadClassifier() (*fingerprintClassifier, error) {
+	var total int64
+	if err := s.db.Model(&models.DeviceFingerprint{}).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count fingerprints: %v", err)
+	}
+	if total == 0 {
+		return builtinClassifier, nil
+	}
+
+	var fingerprints []models.DeviceFingerprint
+	if err := s.db.Where("disabled = ?", false).Find(&fingerprints).Error; err != nil {
+		return nil, fmt.Errorf("failed to load fingerprints: %v", err)
+	}
+	return newFingerprintClassifier(fingerprints, "registry"), nil
+}
+
+func validateFingerprint(fingerprint *models.DeviceFingerprint) error {
+	fingerprint.Name = strings.TrimSpace(fingerprint.Name)
+	fingerprint.SysObjectIDPrefix = normalizeOID(fingerprint.SysObjectIDPrefix)
+	if fingerprint.Name == "" {
+		return fmt.Errorf("fingerprint name is required")
+	}
+	if fingerprint.SysObjectIDPrefix == "" && fingerprint.SysDescrPattern == "" {
+		return fmt.Errorf("sys_object_id_prefix or sys_descr_pattern is required")
+	}
+	if fingerprint.SysObjectIDPrefix != "" {
+		if _, err := parseOIDSubids(fingerprint.SysObjectIDPrefix); err != nil {
+			return fmt.Errorf("invalid sys_object_id_prefix %q", fingerprint.SysObjectIDPrefix)
+		}
+	}
+	if fingerprint.SysDescrPattern != "" {
+		if _, err := regexp.Compile(fingerprint.SysDescrPattern); err != nil {
+			return fmt.Errorf("invalid sys_descr_pattern: %v", err)
+		}
+	}
+	return nil
+}
+
+type fingerprintRule struct {
+	fingerprint models.DeviceFingerprint
+	subids      int
+	pattern     *regexp.Regexp
+}
+
+// fingerprintClassifier 已编译的指纹规则，按优先级、前缀长度排序
+type fingerprintClassifier struct {
+	rules  []fingerprintRule
+	source string
+}
+
+func newFingerprintClassifier(fingerprints []models.DeviceFingerprint, source string) *fingerprintClassifier {
+	classifier := &fingerprintClassifier{source: source}
+	for _, fingerprint := range fingerprints {
+		rule := fingerprintRule{fingerprint: fingerprint}
+		prefix := normalizeOID(fingerprint.SysObjectIDPrefix)
+		rule.fingerprint.SysObjectIDPrefix = prefix
+		if prefix != "" {
+			rule.subids = strings.Count(prefix, ".") + 1
+		}
+		if fingerprint.SysDescrPattern != "" {
+			pattern, err := regexp.Compile(fingerprint.SysDescrPattern)
+			if err != nil {
+				log.Printf("skip fingerprint %s: invalid sys_descr_pattern: %v", fingerprint.Name, err)
+				continue
+			}
+			rule.pattern = pattern
+		}
+		classifier.rules = append(classifier.rules, rule)
+	}
+
+	sort.SliceStable(classifier.rules, func(i, j int) bool {
+		a, b := classifier.rules[i], classifier.rules[j]
+		if a.fingerprint.Priority != b.fingerprint.Priority {
+			return a.fingerprint.Priority > b.fingerprint.Priority
+		}
+		if a.subids != b.subids {
+			return a.subids > b.subids
+		}
+		return a.pattern != nil && b.pattern == nil
+	})
+	return classifier
+}
+
+// classify 按顺序合并所有命中的规则，先命中的规则优先填充字段，最后用关键字推断补齐
+func (c *fingerprintClassifier) classify(sysObjectID, sysDescr string) *models.FingerprintMatch {
+	match := &models.FingerprintMatch{}
+	c.apply(match, sysObjectID, sysDescr)
+
+	if match.Vendor == "" && strings.HasPrefix(sysObjectID, enterprisesOIDPrefix) {
+		match.Vendor = "enterprise-" + strings.SplitN(strings.TrimPrefix(sysObjectID, enterprisesOIDPrefix), ".", 2)[0]
+	}
+	if match.OSFamily == "" {
+		match.OSFamily = guessOSFamily(sysDescr)
+	}
+	if match.DeviceType == "" {
+		match.DeviceType = guessDeviceType(sysDescr, match.Vendor)
+	}
+	return match
+}
+
+func (c *fingerprintClassifier) apply(match *models.FingerprintMatch, sysObjectID, sysDescr string) {
+	for _, rule := range c.rules {
+		prefix := rule.fingerprint.SysObjectIDPrefix
+		if prefix != "" && sysObjectID != prefix && !strings.HasPrefix(sysObjectID, prefix+".") {
+			continue
+		}
+		var submatch []int
+		if rule.pattern != nil {
+			if submatch = rule.pattern.FindStringSubmatchIndex(sysDescr); submatch == nil {
+				continue
+			}
+		}
+
+		if match.Source == "" {
+			match.Source = c.source
+			match.FingerprintName = rule.fingerprint.Name
+			if c.source == "registry" {
+				id := rule.fingerprint.ID
+				match.FingerprintID = &id
+			}
+		}
+		if match.Vendor == "" {
+			match.Vendor = rule.fingerprint.Vendor
+		}
+		if match.Model == "" && rule.fingerprint.Model != "" {
+			match.Model = rule.fingerprint.Model
+			// 型号可引用 sysDescr 正则的捕获组，如 $1
+			if submatch != nil && strings.Contains(match.Model, "$") {
+				match.Model = strings.TrimSpace(string(rule.pattern.ExpandString(nil, rule.fingerprint.Model, sysDescr, submatch)))
+			}
+		}
+		if match.OSFamily == "" {
+			match.OSFamily = rule.fingerprint.OSFamily
+		}
+		if match.DeviceType == "" {
+			match.DeviceType = rule.fingerprint.DeviceType
+		}
+		if match.TemplateID == nil && rule.fingerprint.TemplateID != nil {
+			id := *rule.fingerprint.TemplateID
+			match.TemplateID = &id
+		}
+	}
+}
+
+func guessOSFamily(sysDescr string) string {
+	descr := strings.ToLower(sysDescr)
+	switch {
+	case strings.Contains(descr, "ios-xe"), strings.Contains(descr, "ios xe"):
+		return "ios-xe"
+	case strings.Contains(descr, "ios xr"):
+		return "ios-xr"
+	case strings.Contains(descr, "nx-os"):
+		return "nx-os"
+	case strings.Contains(descr, "cisco ios"):
+		return "ios"
+	case strings.Contains(descr, "junos"):
+		return "junos"
+	case strings.Contains(descr, "vrp"), strings.Contains(descr, "versatile routing platform"):
+		return "vrp"
+	case strings.Contains(descr, "comware"):
+		return "comware"
+	case strings.Contains(descr, "arista networks eos"):
+		return "eos"
+	case strings.Contains(descr, "routeros"):
+		return "routeros"
+	case strings.Contains(descr, "fortigate"), strings.Contains(descr, "fortios"):
+		return "fortios"
+	case strings.Contains(descr, "windows"):
+		return "windows"
+	case strings.Contains(descr, "freebsd"):
+		return "freebsd"
+	case strings.Contains(descr, "linux"):
+		return "linux"
+	}
+	return ""
+}
+
+func guessDeviceType(sysDescr, vendor string) string {
+	descr := strings.ToLower(sysDescr)
+	switch {
+	case strings.Contains(descr, "firewall"), strings.Contains(descr, "fortigate"), strings.Contains(descr, "adaptive security"), vendor == "paloalto":
+		return "firewall"
+	case strings.Contains(descr, "switch"), strings.Contains(descr, "nx-os"), strings.Contains(descr, "arista networks eos"):
+		return "switch"
+	case strings.Contains(descr, "router"), strings.Contains(descr, "ios xr"), strings.Contains(descr, "routeros"):
+		return "router"
+	case strings.Contains(descr, "printer"), strings.Contains(descr, "jetdirect"):
+		return "printer"
+	case strings.Contains(descr, "linux"), strings.Contains(descr, "windows"), strings.Contains(descr, "freebsd"), vendor == "net-snmp":
+		return "server"
+	}
+	return "network"
+}
+
+// snmpEnterpriseVendors IANA 企业号到厂商的映射
+var snmpEnterpriseVendors = map[string]string{
+	"9":     "cisco",
+	"11":    "hp",
+	"43":    "3com",
+	"171":   "dlink",
+	"311":   "microsoft",
+	"674":   "dell",
+	"1588":  "brocade",
+	"1916":  "extreme",
+	"2011":  "huawei",
+	"2636":  "juniper",
+	"3375":  "f5",
+	"3902":  "zte",
+	"4526":  "netgear",
+	"4881":  "ruijie",
+	"6486":  "alcatel-lucent",
+	"6876":  "vmware",
+	"8072":  "net-snmp",
+	"12356": "fortinet",
+	"14179": "cisco",
+	"14823": "aruba",
+	"14988": "mikrotik",
+	"25461": "paloalto",
+	"25506": "h3c",
+	"30065": "arista",
+	"41112": "ubiquiti",
+}
+
+// builtinFingerprints 内置规则：企业号前缀确定厂商，产品规则补充型号和系统
+func builtinFingerprints() []models.DeviceFingerprint {
+	fingerprints := []models.DeviceFingerprint{
+		{Name: "cisco-ios-xe", SysObjectIDPrefix: "1.3.6.1.4.1.9", SysDescrPattern: `IOS[- ]XE`, OSFamily: "ios-xe", Priority: 20},
+		{Name: "cisco-ios", SysObjectIDPrefix: "1.3.6.1.4.1.9", SysDescrPattern: `Cisco IOS Software(?: \[[^\]]*\])?,\s*(\S+?) Software`, Model: "$1", OSFamily: "ios", Priority: 10},
+		{Name: "cisco-ios-xr", SysObjectIDPrefix: "1.3.6.1.4.1.9", SysDescrPattern: `IOS XR`, OSFamily: "ios-xr", DeviceType: "router", Priority: 10},
+		{Name: "cisco-nx-os", SysObjectIDPrefix: "1.3.6.1.4.1.9", SysDescrPattern: `Cisco NX-OS\(tm\) (\S+),`, Model: "$1", OSFamily: "nx-os", DeviceType: "switch", Priority: 10},
+		{Name: "cisco-asa", SysObjectIDPrefix: "1.3.6.1.4.1.9", SysDescrPattern: `Cisco Adaptive Security Appliance`, Model: "ASA", OSFamily: "asa", DeviceType: "firewall", Priority: 10},
+		{Name: "juniper-junos", SysObjectIDPrefix: "1.3.6.1.4.1.2636", SysDescrPattern: `Juniper Networks, Inc\. (\S+)`, Model: "$1", OSFamily: "junos", Priority: 10},
+		{Name: "huawei-vrp", SysObjectIDPrefix: "1.3.6.1.4.1.2011", SysDescrPattern: `(?i)(?:Quidway|Huawei)\s+((?:S|AR|CE|NE|USG)\d[\w-]*)`, Model: "$1", OSFamily: "vrp", Priority: 10},
+		{Name: "h3c-comware", SysObjectIDPrefix: "1.3.6.1.4.1.25506", SysDescrPattern: `(?i)H3C\s+((?:S|MSR|SR)\d[\w-]*)`, Model: "$1", OSFamily: "comware", Priority: 10},
+		{Name: "arista-eos", SysObjectIDPrefix: "1.3.6.1.4.1.30065", SysDescrPattern: `running on an Arista Networks (\S+)`, Model: "$1", OSFamily: "eos", DeviceType: "switch", Priority: 10},
+		{Name: "fortinet-fortigate", SysObjectIDPrefix: "1.3.6.1.4.1.12356", SysDescrPattern: `(FortiGate-\w+)`, Model: "$1", OSFamily: "fortios", DeviceType: "firewall", Priority: 10},
+		{Name: "mikrotik-routeros", SysObjectIDPrefix: "1.3.6.1.4.1.14988", SysDescrPattern: `RouterOS (\S+)`, Model: "$1", OSFamily: "routeros", DeviceType: "router", Priority: 10},
+		{Name: "paloalto-panos", SysObjectIDPrefix: "1.3.6.1.4.1.25461", OSFamily: "pan-os", DeviceType: "firewall", Priority: 10},
+		{Name: "net-snmp-linux", SysObjectIDPrefix: "1.3.6.1.4.1.8072.3.2.10", OSFamily: "linux", DeviceType: "server", Priority: 10},
+		{Name: "microsoft-windows", SysObjectIDPrefix: "1.3.6.1.4.1.311.1.1.3", OSFamily: "windows", DeviceType: "server", Priority: 10},
+	}
+
+	enterprises := make([]string, 0, len(snmpEnterpriseVendors))
+	for enterprise := range snmpEnterpriseVendors {
+		enterprises = append(enterprises, enterprise)
+	}
+	sort.Slice(enterprises, func(i, j int) bool { return compareOIDs(enterprises[i], enterprises[j]) < 0 })
+	for _, enterprise := range enterprises {
+		vendor := snmpEnterpriseVendors[enterprise]
+		fingerprints = append(fingerprints, models.DeviceFingerprint{
+			Name:              fmt.Sprintf("enterprise-%s-%s", vendor, enterprise),
+			SysObjectIDPrefix: enterprisesOIDPrefix + enterprise,
+			Vendor:            vendor,
+			Description:       "IANA enterprise " + enterprise,
+		})
+	}
+
+	for i := range fingerprints {
+		fingerprints[i].Builtin = true
+		if fingerprints[i].Vendor == "" {
+			enterprise := strings.SplitN(strings.TrimPrefix(fingerprints[i].SysObjectIDPrefix, enterprisesOIDPrefix), ".", 2)[0]
+			fingerprints[i].Vendor = snmpEnterpriseVendors[enterprise]
+		}
+	}
+	return fingerprints
+}
+
+// builtinClassifier 注册表为空时使用的内置规则
+var builtinClassifier = newFingerprintClassifier(builtinFingerprints(), "builtin")
@@ -0,0 +1,480 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+const (
+	// 抖动检测窗口，窗口内状态切换达到 flapThreshold 次标记为抖动，低于一半时解除
+	flapWindow    = 30 * time.Minute
+	flapThreshold = 4
+
+	oidSysUpTime = "1.3.6.1.2.1.1.3.0"
+)
+
+var ErrHealthCheckRunning = errors.New("health check already running")
+
+// HealthService 定时检测所有配置了 SNMP 凭据的设备，记录状态变化并统计可用性
+type HealthService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	snmpService *SNMPService
+	logger      utils.Logger
+	interval    time.Duration
+	workers     int
+
+	mu          sync.Mutex
+	running     bool
+	checking    bool
+	lastRun     *time.Time
+	lastChecked int
+	lastOnline  int
+	lastOffline int
+	stop        chan struct{}
+	wg          sync.WaitGroup
+}
+
+// NewHealthService 创建健康检查器，interval 单位为秒，0 表示不定时检查
+func NewHealthService(db *gorm.DB, redis *redis.Client, logger utils.Logger, interval, workers int) *HealthService {
+	if workers <= 0 {
+		workers = 20
+	}
+	return &HealthService{
+		db:          db,
+		redis:       redis,
+		snmpService: NewSNMPService(db, redis),
+		logger:      logger,
+		interval:    time.Duration(interval) * time.Second,
+		workers:     workers,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start 启动定时检查
+func (s *HealthService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running || s.interval <= 0 {
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.run()
+	s.logger.Info("Health checker started", "interval", s.interval.String(), "workers", s.workers)
+}
+
+// Stop 停止定时检查并等待进行中的检查结束
+func (s *HealthService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.logger.Info("Health checker stopped")
+}
+
+// GetStatus 获取健康检查器运行状态
+func (s *HealthService) GetStatus() *models.HealthCheckStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &models.HealthCheckStatus{
+		Running:     s.running,
+		Interval:    int(s.interval / time.Second),
+		Workers:     s.workers,
+		Checking:    s.checking,
+		LastRun:     s.lastRun,
+		LastChecked: s.lastChecked,
+		LastOnline:  s.lastOnline,
+		LastOffline: s.lastOffline,
+	}
+}
+
+// TriggerCheck 立即在后台检查一轮，已有检查进行中时返回 ErrHealthCheckRunning
+func (s *HealthService) TriggerCheck() error {
+	if !s.beginCheck() {
+		return ErrHealthCheckRunning
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.checkAll()
+	}()
+	return nil
+}
+
+func (s *HealthService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			// 上一轮未结束时跳过本轮
+			if s.beginCheck() {
+				s.checkAll()
+			}
+		}
+	}
+}
+
+func (s *HealthService) beginCheck() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.checking {
+		return false
+	}
+	s.checking = true
+	return true
+}
+
+// checkAll 并发检查所有配置了凭据的设备，调用前需通过 beginCheck 占用
+func (s *HealthService) checkAll() {
+	start := time.Now()
+	online, offline := 0, 0
+	defer func() {
+		s.mu.Lock()
+		s.checking = false
+		s.lastRun = &start
+		s.lastChecked = online + offline
+		s.lastOnline = online
+		s.lastOffline = offline
+		s.mu.Unlock()
+	}()
+
+	var devices []models.Device
+	err := s.db.Preload("Credentials").
+		Where("id IN (?)", s.db.Model(&models.SNMPCredential{}).Select("device_id")).
+		Find(&devices).Error
+	if err != nil {
+		s.logger.Error("Failed to load devices for health check", "error", err)
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.workers)
+	for i := range devices {
+		select {
+		case <-s.stop:
+			wg.Wait()
+			return
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(device *models.Device) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			status, err := s.CheckDevice(device)
+			if err != nil {
+				s.logger.Warn("Health check failed", "device_id", device.ID, "error", err)
+				return
+			}
+			mu.Lock()
+			if status == "online" {
+				online++
+			} else {
+				offline++
+			}
+			mu.Unlock()
+		}(&devices[i])
+	}
+	wg.Wait()
+}
+
+// CheckDevice 通过 sysUpTime 检测设备是否响应并记录状态
+func (s *HealthService) CheckDevice(device *models.Device) (string, error) {
+	if len(device.Credentials) == 0 {
+		return "", fmt.Errorf("device %s has no SNMP credentials", device.Name)
+	}
+
+	result, err := s.snmpService.TestConnection(newDeviceSNMPRequest(device, device.Credentials[0], oidSysUpTime))
+	if err != nil {
+		return "", err
+	}
+	status, reason := deviceTestStatus(result)
+
+	event, err := recordDeviceStatus(s.db, device.ID, status, reason, "health_check")
+	if err != nil {
+		return "", err
+	}
+	if event != nil {
+		s.logger.Info("Device status changed", "device_id", device.ID, "from", event.FromStatus, "to", event.ToStatus, "reason", reason, "flapping", event.Flapping, "suppressed", event.Suppressed)
+	}
+	return status, nil
+}
+
+// GetStatusEvents 获取状态变化历史，deviceID 为 0 时查询全部设备；维护窗口内被抑制的事件默认不返回
+func (s *HealthService) GetStatusEvents(deviceID uint, includeSuppressed bool, page, limit int) ([]models.DeviceStatusEvent, int64, error) {
+	var events []models.DeviceStatusEvent
+	var total int64
+
+	query := s.db.Model(&models.DeviceStatusEvent{})
+	if deviceID != 0 {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if !includeSuppressed {
+		query = query.Where("suppressed = ?", false)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// GetAvailability 统计单台设备在 [from, to) 区间内的可用性
+func (s *HealthService) GetAvailability(deviceID uint, from, to time.Time) (*models.DeviceAvailability, error) {
+	var device models.Device
+	if err := s.db.First(&device, deviceID).Error; err != nil {
+		return nil, err
+	}
+
+	report, err := s.availability([]models.Device{device}, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &report[0], nil
+}
+
+// GetAvailabilityReport 统计所有设备在 [from, to) 区间内的可用性，按可用性从低到高排序
+func (s *HealthService) GetAvailabilityReport(from, to time.Time) ([]models.DeviceAvailability, error) {
+	var devices []models.Device
+	if err := s.db.Order("id").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+
+	report, err := s.availability(devices, from, to)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(report, func(i, j int) bool {
+		a, b := report[i].Availability, report[j].Availability
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a < *b
+	})
+	return report, nil
+}
+
+func (s *HealthService) availability(devices []models.Device, from, to time.Time) ([]models.DeviceAvailability, error) {
+	if now := time.Now(); to.After(now) {
+		to = now
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("invalid time range: from must be before to")
+	}
+
+	ids := make([]uint, len(devices))
+	for i, device := range devices {
+		ids[i] = device.ID
+	}
+
+	// 区间开始前的最后一次状态
+	var priors []models.DeviceStatusEvent
+	err := s.db.Raw(`SELECT DISTINCT ON (device_id) device_id, to_status FROM device_status_events
+		WHERE device_id IN ? AND created_at < ? ORDER BY device_id, created_at DESC, id DESC`, ids, from).
+		Scan(&priors).Error
+	if err != nil {
+		return nil, err
+	}
+	prior := make(map[uint]string, len(priors))
+	for _, event := range priors {
+		prior[event.DeviceID] = event.ToStatus
+	}
+
+	var events []models.DeviceStatusEvent
+	err = s.db.Where("device_id IN ? AND created_at >= ? AND created_at < ?", ids, from, to).
+		Order("device_id, created_at, id").Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	byDevice := make(map[uint][]models.DeviceStatusEvent)
+	for _, event := range events {
+		byDevice[event.DeviceID] = append(byDevice[event.DeviceID], event)
+	}
+
+	report := make([]models.DeviceAvailability, 0, len(devices))
+	for _, device := range devices {
+		report = append(report, computeAvailability(device, prior[device.ID], byDevice[device.ID], from, to))
+	}
+	return report, nil
+}
+
+// computeAvailability 按状态变化事件累计各状态时长；设备创建之前的时间不计入
+func computeAvailability(device models.Device, prior string, events []models.DeviceStatusEvent, from, to time.Time) models.DeviceAvailability {
+	result := models.DeviceAvailability{
+		DeviceID:    device.ID,
+		DeviceName:  device.Name,
+		From:        from,
+		To:          to,
+		Transitions: len(events),
+		Flapping:    device.Flapping,
+	}
+
+	// 区间起点状态：优先取区间前最后一次变化，其次取区间内第一次变化的原状态，都没有则为当前状态
+	state := prior
+	if state == "" {
+		if len(events) > 0 {
+			state = events[0].FromStatus
+		} else {
+			state = device.Status
+		}
+	}
+
+	cursor := from
+	if device.CreatedAt.After(cursor) {
+		cursor = device.CreatedAt
+	}
+	accumulate := func(until time.Time) {
+		if !until.After(cursor) {
+			return
+		}
+		seconds := int64(until.Sub(cursor) / time.Second)
+		switch state {
+		case "online":
+			result.OnlineSeconds += seconds
+		case "offline":
+			result.OfflineSeconds += seconds
+		default:
+			result.UnknownSeconds += seconds
+		}
+		cursor = until
+	}
+	for _, event := range events {
+		accumulate(event.CreatedAt)
+		state = event.ToStatus
+	}
+	accumulate(to)
+
+	if known := result.OnlineSeconds + result.OfflineSeconds; known > 0 {
+		availability := math.Round(float64(result.OnlineSeconds)/float64(known)*10000) / 100
+		result.Availability = &availability
+	}
+	return result
+}
+
+// deviceTestStatus 将 TestConnection 的结果转换为设备状态和原因
+func deviceTestStatus(result map[string]interface{}) (string, string) {
+	if success, _ := result["success"].(bool); success {
+		return "online", fmt.Sprintf("SNMP response in %vms", result["response_time"])
+	}
+	if msg, ok := result["error"].(string); ok && msg != "" {
+		return "offline", msg
+	}
+	return "offline", "no SNMP response"
+}
+
+// recordDeviceStatus 更新设备状态；状态变化时记录事件并重新判断是否抖动，未变化时返回 nil 事件
+func recordDeviceStatus(db *gorm.DB, deviceID uint, status, reason, source string) (*models.DeviceStatusEvent, error) {
+	var event *models.DeviceStatusEvent
+	now := time.Now()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var device models.Device
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status", "flapping").First(&device, deviceID).Error
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"status": status}
+		if status == "online" {
+			updates["last_seen"] = &now
+		}
+
+		var transitions int64
+		err = tx.Model(&models.DeviceStatusEvent{}).
+			Where("device_id = ? AND created_at >= ? AND suppressed = ?", deviceID, now.Add(-flapWindow), false).
+			Count(&transitions).Error
+		if err != nil {
+			return err
+		}
+		// 维护窗口内的状态变化只记录为已抑制的事件，不计入抖动
+		changed := device.Status != status
+		var window *models.MaintenanceWindow
+		if changed {
+			if window, err = activeMaintenanceWindow(tx, deviceID, now); err != nil {
+				return err
+			}
+			if window == nil {
+				transitions++
+			}
+		}
+
+		flapping := device.Flapping
+		if transitions >= flapThreshold {
+			flapping = true
+		} else if transitions < flapThreshold/2 {
+			flapping = false
+		}
+		if flapping != device.Flapping {
+			updates["flapping"] = flapping
+		}
+
+		if changed {
+			updates["status_changed_at"] = &now
+			event = &models.DeviceStatusEvent{
+				DeviceID:   deviceID,
+				FromStatus: device.Status,
+				ToStatus:   status,
+				Reason:     reason,
+				Source:     source,
+				Flapping:   flapping,
+				CreatedAt:  now,
+			}
+			if window != nil {
+				event.Suppressed = true
+				event.WindowID = &window.ID
+			}
+			if err := tx.Create(event).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Device{}).Where("id = ?", deviceID).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to record device status: %v", err)
+	}
+
+	// 选择器可以按状态分组
+	if event != nil {
+		if err := syncDeviceMemberships(db, deviceID); err != nil {
+			log.Printf("failed to sync device group membership for device %d: %v", deviceID, err)
+		}
+	}
+	return event, nil
+}
@@ -1,14 +1,8 @@
 package services
 
 import (
-	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
-	"encoding/base64"
-	"encoding/json"
+	"bytes"
 	"fmt"
-	"io"
 	"net"
 	"os/exec"
 	"strconv"
@@ -21,21 +15,19 @@ import (
 	"gorm.io/gorm"
 
 	"mib-platform/models"
+	"mib-platform/utils"
 )
 
+// HostService 主机密码、私钥等字段由 secret 序列化器在入库时加密、读取时解密
 type HostService struct {
 	db    *gorm.DB
 	redis *redis.Client
-	encryptionKey []byte
 }
 
 func NewHostService(db *gorm.DB, redis *redis.Client) *HostService {
-	// 在实际应用中，这个密钥应该从环境变量或配置文件中读取
-	encryptionKey := []byte("your-32-byte-encryption-key-here") // 32 bytes for AES-256
 	return &HostService{
-		db:            db,
-		redis:         redis,
-		encryptionKey: encryptionKey,
+		db:    db,
+		redis: redis,
 	}
 }
 
@@ -84,42 +76,10 @@ func (s *HostService) GetHost(id uint) (*models.Host, error) {
 		return nil, err
 	}
 
-	// 解密敏感信息
-	if host.Password != "" {
-		decrypted, err := s.decrypt(host.Password)
-		if err == nil {
-			host.Password = decrypted
-		}
-	}
-
-	if host.PrivateKey != "" {
-		decrypted, err := s.decrypt(host.PrivateKey)
-		if err == nil {
-			host.PrivateKey = decrypted
-		}
-	}
-
 	return &host, nil
 }
 
 func (s *HostService) CreateHost(host *models.Host) error {
-	// 加密敏感信息
-	if host.Password != "" {
-		encrypted, err := s.encrypt(host.Password)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt password: %v", err)
-		}
-		host.Password = encrypted
-	}
-
-	if host.PrivateKey != "" {
-		encrypted, err := s.encrypt(host.PrivateKey)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt private key: %v", err)
-		}
-		host.PrivateKey = encrypted
-	}
-
 	return s.db.Create(host).Error
 }
 
@@ -129,21 +89,12 @@ func (s *HostService) UpdateHost(id uint, updates *models.Host) (*models.Host, e
 		return nil, err
 	}
 
-	// 加密敏感信息
-	if updates.Password != "" {
-		encrypted, err := s.encrypt(updates.Password)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt password: %v", err)
-		}
-		updates.Password = encrypted
+	// 接口返回的是掩码，原样提交回来时保持原值
+	if updates.Password == utils.SecretMask {
+		updates.Password = ""
 	}
-
-	if updates.PrivateKey != "" {
-		encrypted, err := s.encrypt(updates.PrivateKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt private key: %v", err)
-		}
-		updates.PrivateKey = encrypted
+	if updates.PrivateKey == utils.SecretMask {
+		updates.PrivateKey = ""
 	}
 
 	if err := s.db.Model(&host).Updates(updates).Error; err != nil {
@@ -160,23 +111,6 @@ func (s *HostService) DeleteHost(id uint) error {
 // 主机发现相关方法
 
 func (s *HostService) CreateDiscoveryTask(task *models.HostDiscoveryTask) error {
-	// 加密认证信息
-	if task.Password != "" {
-		encrypted, err := s.encrypt(task.Password)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt password: %v", err)
-		}
-		task.Password = encrypted
-	}
-
-	if task.PrivateKey != "" {
-		encrypted, err := s.encrypt(task.PrivateKey)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt private key: %v", err)
-		}
-		task.PrivateKey = encrypted
-	}
-
 	return s.db.Create(task).Error
 }
 
@@ -210,7 +144,7 @@ func (s *HostService) executeDiscovery(task *models.HostDiscoveryTask) {
 	}()
 
 	// 解析 IP 范围
-	ips, err := s.parseIPRange(task.IPRange)
+	ips, err := parseIPRange(task.IPRange)
 	if err != nil {
 		task.Status = "failed"
 		s.db.Save(task)
@@ -306,13 +240,17 @@ func (s *HostService) scanHost(ip string, ports []int, task *models.HostDiscover
 }
 
 func (s *HostService) pingHost(ip string, timeout time.Duration) bool {
-	cmd := exec.Command("ping", "-c", "1", "-W", fmt.Sprintf("%.0f", timeout.Seconds()), ip)
+	args := []string{"-c", "1", "-W", fmt.Sprintf("%.0f", timeout.Seconds())}
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+		args = append(args, "-6")
+	}
+	cmd := exec.Command("ping", append(args, ip)...)
 	err := cmd.Run()
 	return err == nil
 }
 
 func (s *HostService) scanPort(ip string, port int, timeout time.Duration) bool {
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", ip, port), timeout)
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(ip, strconv.Itoa(port)), timeout)
 	if err != nil {
 		return false
 	}
@@ -321,18 +259,7 @@ func (s *HostService) scanPort(ip string, port int, timeout time.Duration) bool
 }
 
 func (s *HostService) gatherSystemInfo(host *models.Host, task *models.HostDiscoveryTask) {
-	// 解密认证信息
-	var password, privateKey string
-	if task.Password != "" {
-		if decrypted, err := s.decrypt(task.Password); err == nil {
-			password = decrypted
-		}
-	}
-	if task.PrivateKey != "" {
-		if decrypted, err := s.decrypt(task.PrivateKey); err == nil {
-			privateKey = decrypted
-		}
-	}
+	password, privateKey := task.Password, task.PrivateKey
 
 	// 尝试 SSH 连接
 	client, err := s.createSSHClient(host.IP, host.Port, task.Username, password, privateKey)
@@ -385,10 +312,10 @@ func (s *HostService) gatherSystemInfo(host *models.Host, task *models.HostDisco
 	host.Username = task.Username
 	if password != "" {
 		host.AuthType = "password"
-		host.Password = task.Password // 已加密
+		host.Password = password
 	} else if privateKey != "" {
 		host.AuthType = "key"
-		host.PrivateKey = task.PrivateKey // 已加密
+		host.PrivateKey = privateKey
 	}
 }
 
@@ -411,7 +338,7 @@ func (s *HostService) createSSHClient(host string, port int, username, password,
 		return nil, fmt.Errorf("no authentication method provided")
 	}
 
-	return ssh.Dial("tcp", fmt.Sprintf("%s:%d", host, port), config)
+	return ssh.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)), config)
 }
 
 func (s *HostService) executeSSHCommand(client *ssh.Client, command string) (string, error) {
@@ -509,48 +436,67 @@ func (s *HostService) TestHostConnection(id uint) (map[string]interface{}, error
 
 // 工具方法
 
-func (s *HostService) parseIPRange(ipRange string) ([]string, error) {
+// maxDiscoveryHosts 单个发现任务允许展开的最大地址数，避免 IPv6 大网段无限展开
+const maxDiscoveryHosts = 65536
+
+func parseIPRange(ipRange string) ([]string, error) {
 	var ips []string
+	ipRange = strings.TrimSpace(ipRange)
 
 	if strings.Contains(ipRange, "/") {
-		// CIDR 格式
+		// CIDR 格式，支持 IPv4 和 IPv6
 		_, ipNet, err := net.ParseCIDR(ipRange)
 		if err != nil {
 			return nil, err
 		}
 
-		for ip := ipNet.IP.Mask(ipNet.Mask); ipNet.Contains(ip); s.incrementIP(ip) {
-			ips = append(ips, ip.String())
-		}
-	} else if strings.Contains(ipRange, "-") {
-		// 范围格式 192.168.1.1-192.168.1.100
-		parts := strings.Split(ipRange, "-")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid IP range format")
+		ones, bits := ipNet.Mask.Size()
+		if bits-ones > 16 {
+			return nil, fmt.Errorf("CIDR %s is too large, at most %d addresses can be scanned", ipRange, maxDiscoveryHosts)
 		}
 
+		for ip := ipNet.IP.Mask(ipNet.Mask); ipNet.Contains(ip); incrementIP(ip) {
+			ips = append(ips, ip.String())
+		}
+	} else if parts := strings.Split(ipRange, "-"); len(parts) == 2 && net.ParseIP(strings.TrimSpace(parts[0])) != nil {
+		// 范围格式 192.168.1.1-192.168.1.100 或 2001:db8::1-2001:db8::ff，带连字符的主机名不会进入这里
 		startIP := net.ParseIP(strings.TrimSpace(parts[0]))
 		endIP := net.ParseIP(strings.TrimSpace(parts[1]))
 		if startIP == nil || endIP == nil {
 			return nil, fmt.Errorf("invalid IP addresses in range")
 		}
+		if (startIP.To4() == nil) != (endIP.To4() == nil) {
+			return nil, fmt.Errorf("IP range mixes IPv4 and IPv6 addresses")
+		}
+		if bytes.Compare(startIP.To16(), endIP.To16()) > 0 {
+			return nil, fmt.Errorf("IP range start is after end")
+		}
 
-		for ip := startIP; !ip.Equal(endIP); s.incrementIP(ip) {
+		for ip := startIP; !ip.Equal(endIP); incrementIP(ip) {
+			if len(ips) >= maxDiscoveryHosts {
+				return nil, fmt.Errorf("IP range is too large, at most %d addresses can be scanned", maxDiscoveryHosts)
+			}
 			ips = append(ips, ip.String())
 		}
 		ips = append(ips, endIP.String())
-	} else {
+	} else if ip := net.ParseIP(strings.Trim(ipRange, "[]")); ip != nil {
 		// 单个 IP
-		if net.ParseIP(ipRange) == nil {
-			return nil, fmt.Errorf("invalid IP address")
+		ips = append(ips, ip.String())
+	} else {
+		// 主机名，解析后扫描其全部地址
+		addrs, err := net.LookupIP(ipRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address or unresolvable hostname %s: %v", ipRange, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.String())
 		}
-		ips = append(ips, ipRange)
 	}
 
 	return ips, nil
 }
 
-func (s *HostService) incrementIP(ip net.IP) {
+func incrementIP(ip net.IP) {
 	for j := len(ip) - 1; j >= 0; j-- {
 		ip[j]++
 		if ip[j] > 0 {
@@ -576,58 +522,6 @@ func (s *HostService) parsePorts(portStr string) []int {
 	return ports
 }
 
-// 加密解密方法
-
-func (s *HostService) encrypt(plaintext string) (string, error) {
-	block, err := aes.NewCipher(s.encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
-}
-
-func (s *HostService) decrypt(ciphertext string) (string, error) {
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(s.encryptionKey)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
-}
-
 // 凭据管理
 
 func (s *HostService) GetCredentials() ([]models.HostCredential, error) {
@@ -647,31 +541,6 @@ func (s *HostService) GetCredentials() ([]models.HostCredential, error) {
 }
 
 func (s *HostService) CreateCredential(credential *models.HostCredential) error {
-	// 加密敏感信息
-	if credential.Password != "" {
-		encrypted, err := s.encrypt(credential.Password)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt password: %v", err)
-		}
-		credential.Password = encrypted
-	}
-
-	if credential.PrivateKey != "" {
-		encrypted, err := s.encrypt(credential.PrivateKey)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt private key: %v", err)
-		}
-		credential.PrivateKey = encrypted
-	}
-
-	if credential.Passphrase != "" {
-		encrypted, err := s.encrypt(credential.Passphrase)
-		if err != nil {
-			return fmt.Errorf("failed to encrypt passphrase: %v", err)
-		}
-		credential.Passphrase = encrypted
-	}
-
 	return s.db.Create(credential).Error
 }
 
@@ -0,0 +1,499 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gosnmp/gosnmp"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+// IF-MIB 中接口采集需要的列
+const (
+	oidIfDescr       = "1.3.6.1.2.1.2.2.1.2"
+	oidIfType        = "1.3.6.1.2.1.2.2.1.3"
+	oidIfMtu         = "1.3.6.1.2.1.2.2.1.4"
+	oidIfSpeed       = "1.3.6.1.2.1.2.2.1.5"
+	oidIfPhysAddress = "1.3.6.1.2.1.2.2.1.6"
+	oidIfAdminStatus = "1.3.6.1.2.1.2.2.1.7"
+	oidIfOperStatus  = "1.3.6.1.2.1.2.2.1.8"
+	oidIfLastChange  = "1.3.6.1.2.1.2.2.1.9"
+	oidIfName        = "1.3.6.1.2.1.31.1.1.1.1"
+	oidIfHighSpeed   = "1.3.6.1.2.1.31.1.1.1.15"
+	oidIfAlias       = "1.3.6.1.2.1.31.1.1.1.18"
+)
+
+var ifStatusNames = map[int]string{
+	1: "up",
+	2: "down",
+	3: "testing",
+	4: "unknown",
+	5: "dormant",
+	6: "notPresent",
+	7: "lowerLayerDown",
+}
+
+// ifTypeNames 常见 IANAifType 值
+var ifTypeNames = map[int]string{
+	1:   "other",
+	6:   "ethernetCsmacd",
+	23:  "ppp",
+	24:  "softwareLoopback",
+	53:  "propVirtual",
+	62:  "fastEther",
+	71:  "ieee80211",
+	117: "gigabitEthernet",
+	131: "tunnel",
+	135: "l2vlan",
+	136: "l3ipvlan",
+	161: "ieee8023adLag",
+	166: "mpls",
+	209: "bridge",
+}
+
+// InterfaceService 采集 IF-MIB 接口并跟踪状态和属性变化
+type InterfaceService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	snmpService *SNMPService
+	logger      utils.Logger
+	collector   *deviceCollector
+}
+
+// NewInterfaceService 创建接口采集服务，interval 单位为秒，0 表示不定时采集
+func NewInterfaceService(db *gorm.DB, redis *redis.Client, logger utils.Logger, interval int) *InterfaceService {
+	s := &InterfaceService{
+		db:          db,
+		redis:       redis,
+		snmpService: NewSNMPService(db, redis),
+		logger:      logger,
+	}
+	s.collector = newDeviceCollector("Interface", db, logger, interval, func(deviceID uint) error {
+		_, err := s.SyncInterfaces(deviceID)
+		return err
+	})
+	return s
+}
+
+// Start 启动定时采集
+func (s *InterfaceService) Start() {
+	s.collector.Start()
+}
+
+// Stop 停止定时采集
+func (s *InterfaceService) Stop() {
+	s.collector.Stop()
+}
+
+// SyncInterfaces 采集设备接口并与已保存的接口按名称对齐，ifIndex 重新编号时沿用原记录和标签
+func (s *InterfaceService) SyncInterfaces(deviceID uint) (*models.InterfaceSyncResult, error) {
+	device, err := NewDeviceService(s.db, s.redis).GetDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(device.Credentials) == 0 {
+		return nil, fmt.Errorf("device %s has no SNMP credentials", device.Name)
+	}
+
+	start := time.Now()
+	current, err := s.walkInterfaces(device)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) == 0 {
+		return nil, fmt.Errorf("device %s returned no ifTable entries", device.Name)
+	}
+
+	var existing []models.DeviceInterface
+	if err := s.db.Where("device_id = ?", device.ID).Find(&existing).Error; err != nil {
+		return nil, err
+	}
+
+	result := &models.InterfaceSyncResult{
+		DeviceID:   device.ID,
+		Interfaces: len(current),
+		Baseline:   len(existing) == 0,
+		Events:     []models.InterfaceEvent{},
+	}
+
+	// 先按名称和 ifIndex 同时匹配，这样同名接口也能对齐到各自的记录；
+	// 剩下的有名称接口再按名称匹配（ifIndex 可能在重启后变化），名称为空的接口只按 ifIndex 匹配
+	type interfaceKey struct {
+		name  string
+		index int
+	}
+	byKey := map[interfaceKey][]*models.DeviceInterface{}
+	byName := map[string][]*models.DeviceInterface{}
+	for i := range existing {
+		iface := &existing[i]
+		key := interfaceKey{iface.Name, iface.IfIndex}
+		byKey[key] = append(byKey[key], iface)
+		if iface.Name != "" {
+			byName[iface.Name] = append(byName[iface.Name], iface)
+		}
+	}
+
+	matched := map[uint]bool{}
+	// pick 取第一条尚未匹配的记录，优先取仍在线的记录
+	pick := func(candidates []*models.DeviceInterface) *models.DeviceInterface {
+		var found *models.DeviceInterface
+		for _, candidate := range candidates {
+			if matched[candidate.ID] {
+				continue
+			}
+			if candidate.Present {
+				found = candidate
+				break
+			}
+			if found == nil {
+				found = candidate
+			}
+		}
+		if found != nil {
+			matched[found.ID] = true
+		}
+		return found
+	}
+
+	olds := make([]*models.DeviceInterface, len(current))
+	for i := range current {
+		olds[i] = pick(byKey[interfaceKey{current[i].Name, current[i].IfIndex}])
+	}
+	for i := range current {
+		if olds[i] == nil && current[i].Name != "" {
+			olds[i] = pick(byName[current[i].Name])
+		}
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range current {
+			iface := &current[i]
+			old := olds[i]
+
+			if old == nil {
+				iface.DeviceID = device.ID
+				iface.Present = true
+				iface.LastSeen = &now
+				iface.Tags = []string{}
+				if err := tx.Create(iface).Error; err != nil {
+					return err
+				}
+				if !result.Baseline {
+					result.Events = append(result.Events, interfaceEvent(iface, "added", nil, now))
+				}
+				continue
+			}
+
+			result.Events = append(result.Events, diffInterface(old, iface, now)...)
+
+			updates := map[string]interface{}{
+				"if_index":     iface.IfIndex,
+				"descr":        iface.Descr,
+				"alias":        iface.Alias,
+				"type":         iface.Type,
+				"speed":        iface.Speed,
+				"mtu":          iface.MTU,
+				"mac":          iface.MAC,
+				"admin_status": iface.AdminStatus,
+				"oper_status":  iface.OperStatus,
+				"last_change":  iface.LastChange,
+				"present":      true,
+				"last_seen":    &now,
+			}
+			if err := tx.Model(old).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range existing {
+			old := &existing[i]
+			if matched[old.ID] || !old.Present {
+				continue
+			}
+			if err := tx.Model(old).Update("present", false).Error; err != nil {
+				return err
+			}
+			result.Events = append(result.Events, interfaceEvent(old, "removed", nil, now))
+		}
+
+		if len(result.Events) > 0 {
+			return tx.Create(&result.Events).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save interfaces: %v", err)
+	}
+
+	result.Duration = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// diffInterface 比较同一接口的两次采集结果
+func diffInterface(old, iface *models.DeviceInterface, now time.Time) []models.InterfaceEvent {
+	var events []models.InterfaceEvent
+	event := func(eventType string, changes map[string]models.InventoryChange) {
+		e := interfaceEvent(iface, eventType, changes, now)
+		e.DeviceID = old.DeviceID
+		e.InterfaceID = old.ID
+		events = append(events, e)
+	}
+
+	if !old.Present {
+		event("added", nil)
+	}
+	if old.IfIndex != iface.IfIndex {
+		event("renumbered", map[string]models.InventoryChange{
+			"if_index": {Old: strconv.Itoa(old.IfIndex), New: strconv.Itoa(iface.IfIndex)},
+		})
+	}
+
+	status := map[string]models.InventoryChange{}
+	if old.AdminStatus != iface.AdminStatus {
+		status["admin_status"] = models.InventoryChange{Old: old.AdminStatus, New: iface.AdminStatus}
+	}
+	if old.OperStatus != iface.OperStatus {
+		status["oper_status"] = models.InventoryChange{Old: old.OperStatus, New: iface.OperStatus}
+	}
+	if len(status) > 0 {
+		event("status_changed", status)
+	}
+
+	changes := map[string]models.InventoryChange{}
+	compare := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes[field] = models.InventoryChange{Old: oldValue, New: newValue}
+		}
+	}
+	compare("descr", old.Descr, iface.Descr)
+	compare("alias", old.Alias, iface.Alias)
+	compare("type", old.Type, iface.Type)
+	compare("speed", strconv.FormatUint(old.Speed, 10), strconv.FormatUint(iface.Speed, 10))
+	compare("mtu", strconv.Itoa(old.MTU), strconv.Itoa(iface.MTU))
+	compare("mac", old.MAC, iface.MAC)
+	if len(changes) > 0 {
+		event("changed", changes)
+	}
+	return events
+}
+
+func interfaceEvent(iface *models.DeviceInterface, eventType string, changes map[string]models.InventoryChange, now time.Time) models.InterfaceEvent {
+	return models.InterfaceEvent{
+		DeviceID:    iface.DeviceID,
+		InterfaceID: iface.ID,
+		IfIndex:     iface.IfIndex,
+		Name:        iface.Name,
+		EventType:   eventType,
+		Changes:     changes,
+		CreatedAt:   now,
+	}
+}
+
+// walkInterfaces 逐列遍历 ifTable/ifXTable，不支持 ifXTable 的设备使用 ifDescr 和 ifSpeed
+func (s *InterfaceService) walkInterfaces(device *models.Device) ([]models.DeviceInterface, error) {
+	snmp, err := s.snmpService.createSNMPConnection(newDeviceSNMPRequest(device, device.Credentials[0], oidIfDescr))
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+
+	walk := snmp.BulkWalk
+	if snmp.Version == gosnmp.Version1 {
+		walk = snmp.Walk
+	}
+
+	byIndex := map[int]*models.DeviceInterface{}
+	get := func(index int) *models.DeviceInterface {
+		iface, ok := byIndex[index]
+		if !ok {
+			iface = &models.DeviceInterface{IfIndex: index}
+			byIndex[index] = iface
+		}
+		return iface
+	}
+
+	columns := []string{
+		oidIfDescr, oidIfType, oidIfMtu, oidIfSpeed, oidIfPhysAddress, oidIfAdminStatus,
+		oidIfOperStatus, oidIfLastChange, oidIfName, oidIfHighSpeed, oidIfAlias,
+	}
+	highSpeed := map[int]uint64{}
+	for _, column := range columns {
+		err := walk(column, func(pdu gosnmp.SnmpPDU) error {
+			if !hasSNMPValue(pdu) {
+				return nil
+			}
+			index, err := strconv.Atoi(strings.TrimPrefix(normalizeOID(pdu.Name), column+"."))
+			if err != nil {
+				return nil
+			}
+			iface := get(index)
+			switch column {
+			case oidIfDescr:
+				iface.Descr = snmpString(pdu)
+			case oidIfType:
+				ifType := int(gosnmp.ToBigInt(pdu.Value).Int64())
+				iface.Type = ifTypeNames[ifType]
+				if iface.Type == "" {
+					iface.Type = strconv.Itoa(ifType)
+				}
+			case oidIfMtu:
+				iface.MTU = int(gosnmp.ToBigInt(pdu.Value).Int64())
+			case oidIfSpeed:
+				iface.Speed = gosnmp.ToBigInt(pdu.Value).Uint64()
+			case oidIfPhysAddress:
+				if b, ok := pdu.Value.([]byte); ok && len(b) > 0 {
+					iface.MAC = formatMAC(b)
+				}
+			case oidIfAdminStatus:
+				iface.AdminStatus = ifStatusNames[int(gosnmp.ToBigInt(pdu.Value).Int64())]
+			case oidIfOperStatus:
+				iface.OperStatus = ifStatusNames[int(gosnmp.ToBigInt(pdu.Value).Int64())]
+			case oidIfLastChange:
+				iface.LastChange = gosnmp.ToBigInt(pdu.Value).Int64()
+			case oidIfName:
+				iface.Name = snmpString(pdu)
+			case oidIfHighSpeed:
+				highSpeed[index] = gosnmp.ToBigInt(pdu.Value).Uint64()
+			case oidIfAlias:
+				iface.Alias = snmpString(pdu)
+			}
+			return nil
+		})
+		if err != nil {
+			// ifXTable 可选，只有 ifTable 失败才视为采集失败
+			if strings.HasPrefix(column, "1.3.6.1.2.1.2.") {
+				return nil, fmt.Errorf("failed to walk %s: %v", column, err)
+			}
+			s.logger.Warn("Failed to walk ifXTable column", "device_id", device.ID, "oid", column, "error", err)
+		}
+	}
+
+	interfaces := make([]models.DeviceInterface, 0, len(byIndex))
+	for index, iface := range byIndex {
+		// ifSpeed 最大约 4.29G，更高速率以 ifHighSpeed（Mbit/s）为准
+		if speed, ok := highSpeed[index]; ok && speed > 0 && (iface.Speed == 0 || iface.Speed == 4294967295 || speed*1000000 > iface.Speed) {
+			iface.Speed = speed * 1000000
+		}
+		if iface.Name == "" {
+			iface.Name = iface.Descr
+		}
+		interfaces = append(interfaces, *iface)
+	}
+	sort.Slice(interfaces, func(i, j int) bool { return interfaces[i].IfIndex < interfaces[j].IfIndex })
+	return interfaces, nil
+}
+
+// GetDeviceInterfaces 获取设备接口，includeRemoved 为 true 时包含已消失的接口
+func (s *InterfaceService) GetDeviceInterfaces(deviceID uint, includeRemoved bool) ([]models.DeviceInterface, error) {
+	var interfaces []models.DeviceInterface
+	query := s.db.Where("device_id = ?", deviceID)
+	if !includeRemoved {
+		query = query.Where("present = ?", true)
+	}
+	if err := query.Order("if_index").Find(&interfaces).Error; err != nil {
+		return nil, err
+	}
+	return interfaces, nil
+}
+
+// GetInterfaces 跨设备查询接口，tags 为多个标签时要求全部匹配，供告警规则和配置生成按标签选择端口
+func (s *InterfaceService) GetInterfaces(deviceID uint, tags []string, status string, page, limit int) ([]models.DeviceInterface, int64, error) {
+	var interfaces []models.DeviceInterface
+	var total int64
+
+	query := s.db.Model(&models.DeviceInterface{}).Where("present = ?", true)
+	if deviceID != 0 {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if tags = normalizeInterfaceTags(tags); len(tags) > 0 {
+		data, _ := json.Marshal(tags)
+		query = query.Where("tags @> ?", string(data))
+	}
+	if status != "" {
+		query = query.Where("oper_status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Device").Order("device_id, if_index").Offset(offset).Limit(limit).Find(&interfaces).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return interfaces, total, nil
+}
+
+func (s *InterfaceService) GetInterface(id uint) (*models.DeviceInterface, error) {
+	var iface models.DeviceInterface
+	if err := s.db.Preload("Device").First(&iface, id).Error; err != nil {
+		return nil, err
+	}
+	return &iface, nil
+}
+
+// UpdateInterfaceTags 替换接口标签
+func (s *InterfaceService) UpdateInterfaceTags(id uint, tags []string) (*models.DeviceInterface, error) {
+	iface, err := s.GetInterface(id)
+	if err != nil {
+		return nil, err
+	}
+
+	iface.Tags = normalizeInterfaceTags(tags)
+	if err := s.db.Model(iface).Select("tags").Updates(&models.DeviceInterface{Tags: iface.Tags}).Error; err != nil {
+		return nil, err
+	}
+	return iface, nil
+}
+
+// GetInterfaceEvents 获取接口变化历史，deviceID 为 0 时查询全部设备
+func (s *InterfaceService) GetInterfaceEvents(deviceID, interfaceID uint, eventType string, page, limit int) ([]models.InterfaceEvent, int64, error) {
+	var events []models.InterfaceEvent
+	var total int64
+
+	query := s.db.Model(&models.InterfaceEvent{})
+	if deviceID != 0 {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if interfaceID != 0 {
+		query = query.Where("interface_id = ?", interfaceID)
+	}
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// normalizeInterfaceTags 标签统一为小写并去重排序
+func normalizeInterfaceTags(tags []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
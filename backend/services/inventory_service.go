@@ -0,0 +1,392 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gosnmp/gosnmp"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+// entPhysicalEntry ENTITY-MIB entPhysicalTable 的行
+const oidEntPhysicalEntry = "1.3.6.1.2.1.47.1.1.1.1"
+
+// entPhysicalClassNames entPhysicalClass 枚举值
+var entPhysicalClassNames = map[int]string{
+	1:  "other",
+	2:  "unknown",
+	3:  "chassis",
+	4:  "backplane",
+	5:  "container",
+	6:  "powerSupply",
+	7:  "fan",
+	8:  "sensor",
+	9:  "module",
+	10: "port",
+	11: "stack",
+	12: "cpu",
+	13: "energyObject",
+	14: "battery",
+	15: "storageDrive",
+}
+
+// InventoryService 采集 ENTITY-MIB 物理组件并记录两次采集之间的变化
+type InventoryService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	snmpService *SNMPService
+	collector   *deviceCollector
+}
+
+// NewInventoryService 创建库存采集服务，interval 单位为秒，0 表示不定时采集
+func NewInventoryService(db *gorm.DB, redis *redis.Client, logger utils.Logger, interval int) *InventoryService {
+	s := &InventoryService{
+		db:          db,
+		redis:       redis,
+		snmpService: NewSNMPService(db, redis),
+	}
+	s.collector = newDeviceCollector("Inventory", db, logger, interval, func(deviceID uint) error {
+		_, err := s.CollectInventory(deviceID)
+		return err
+	})
+	return s
+}
+
+// Start 启动定时采集
+func (s *InventoryService) Start() {
+	s.collector.Start()
+}
+
+// Stop 停止定时采集
+func (s *InventoryService) Stop() {
+	s.collector.Stop()
+}
+
+// CollectInventory 遍历 entPhysicalTable，替换设备的组件列表并记录变化事件
+func (s *InventoryService) CollectInventory(deviceID uint) (*models.InventoryCollectResult, error) {
+	device, err := NewDeviceService(s.db, s.redis).GetDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(device.Credentials) == 0 {
+		return nil, fmt.Errorf("device %s has no SNMP credentials", device.Name)
+	}
+
+	start := time.Now()
+	items, err := s.walkEntPhysicalTable(device)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("device %s returned no entPhysicalTable entries", device.Name)
+	}
+
+	var previous []models.InventoryItem
+	if err := s.db.Where("device_id = ?", device.ID).Find(&previous).Error; err != nil {
+		return nil, err
+	}
+
+	result := &models.InventoryCollectResult{
+		DeviceID: device.ID,
+		Items:    len(items),
+		Baseline: len(previous) == 0,
+		Events:   []models.InventoryEvent{},
+	}
+	if !result.Baseline {
+		result.Events = diffInventory(device.ID, previous, items)
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("device_id = ?", device.ID).Delete(&models.InventoryItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(items, 200).Error; err != nil {
+			return err
+		}
+		if len(result.Events) > 0 {
+			if err := tx.Create(&result.Events).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save inventory: %v", err)
+	}
+
+	result.Duration = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+func (s *InventoryService) walkEntPhysicalTable(device *models.Device) ([]models.InventoryItem, error) {
+	snmp, err := s.snmpService.createSNMPConnection(newDeviceSNMPRequest(device, device.Credentials[0], oidEntPhysicalEntry))
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+
+	now := time.Now()
+	byIndex := map[int]*models.InventoryItem{}
+	walk := snmp.BulkWalk
+	if snmp.Version == gosnmp.Version1 {
+		walk = snmp.Walk
+	}
+	err = walk(oidEntPhysicalEntry, func(pdu gosnmp.SnmpPDU) error {
+		if !hasSNMPValue(pdu) {
+			return nil
+		}
+		// 1.3.6.1.2.1.47.1.1.1.1.<column>.<entPhysicalIndex>
+		parts := strings.Split(strings.TrimPrefix(normalizeOID(pdu.Name), oidEntPhysicalEntry+"."), ".")
+		if len(parts) != 2 {
+			return nil
+		}
+		column, err1 := strconv.Atoi(parts[0])
+		index, err2 := strconv.Atoi(parts[1])
+		if err1 != nil || err2 != nil {
+			return nil
+		}
+
+		item, ok := byIndex[index]
+		if !ok {
+			item = &models.InventoryItem{DeviceID: device.ID, PhysicalIndex: index, CollectedAt: now}
+			byIndex[index] = item
+		}
+
+		switch column {
+		case 2:
+			item.Description = snmpString(pdu)
+		case 3:
+			item.VendorType = normalizeOID(fmt.Sprint(pdu.Value))
+		case 4:
+			item.ParentIndex = int(gosnmp.ToBigInt(pdu.Value).Int64())
+		case 5:
+			class := int(gosnmp.ToBigInt(pdu.Value).Int64())
+			item.Class = entPhysicalClassNames[class]
+			if item.Class == "" {
+				item.Class = strconv.Itoa(class)
+			}
+		case 6:
+			item.ParentRelPos = int(gosnmp.ToBigInt(pdu.Value).Int64())
+		case 7:
+			item.Name = snmpString(pdu)
+		case 8:
+			item.HardwareRev = snmpString(pdu)
+		case 9:
+			item.FirmwareRev = snmpString(pdu)
+		case 10:
+			item.SoftwareRev = snmpString(pdu)
+		case 11:
+			item.SerialNumber = snmpString(pdu)
+		case 12:
+			item.Manufacturer = snmpString(pdu)
+		case 13:
+			item.ModelName = snmpString(pdu)
+		case 14:
+			item.Alias = snmpString(pdu)
+		case 15:
+			item.AssetID = snmpString(pdu)
+		case 16:
+			item.IsFRU = gosnmp.ToBigInt(pdu.Value).Int64() == 1 // TruthValue: true(1)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk entPhysicalTable: %v", err)
+	}
+
+	items := make([]models.InventoryItem, 0, len(byIndex))
+	for _, item := range byIndex {
+		items = append(items, *item)
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].PhysicalIndex < items[j].PhysicalIndex })
+	return items, nil
+}
+
+// GetInventory 获取设备组件列表，tree 为 true 时按 entPhysicalContainedIn 组装为树
+func (s *InventoryService) GetInventory(deviceID uint, class string, tree bool) ([]*models.InventoryItem, error) {
+	var items []models.InventoryItem
+	query := s.db.Where("device_id = ?", deviceID)
+	if class != "" && !tree {
+		query = query.Where("class = ?", class)
+	}
+	if err := query.Order("parent_index, parent_rel_pos, physical_index").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*models.InventoryItem, len(items))
+	for i := range items {
+		nodes[i] = &items[i]
+	}
+	if !tree {
+		return nodes, nil
+	}
+	return buildInventoryTree(nodes), nil
+}
+
+// GetInventoryEvents 获取设备的库存变化历史，deviceID 为 0 时查询全部设备
+func (s *InventoryService) GetInventoryEvents(deviceID uint, eventType string, page, limit int) ([]models.InventoryEvent, int64, error) {
+	var events []models.InventoryEvent
+	var total int64
+
+	query := s.db.Model(&models.InventoryEvent{})
+	if deviceID != 0 {
+		query = query.Where("device_id = ?", deviceID)
+	}
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// SearchBySerial 按序列号查找组件所在的设备
+func (s *InventoryService) SearchBySerial(serial string) ([]models.InventoryItem, error) {
+	var items []models.InventoryItem
+	if err := s.db.Where("serial_number ILIKE ?", "%"+serial+"%").Order("device_id, physical_index").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func buildInventoryTree(nodes []*models.InventoryItem) []*models.InventoryItem {
+	byIndex := make(map[int]*models.InventoryItem, len(nodes))
+	for _, node := range nodes {
+		byIndex[node.PhysicalIndex] = node
+	}
+
+	var roots []*models.InventoryItem
+	for _, node := range nodes {
+		parent, ok := byIndex[node.ParentIndex]
+		if node.ParentIndex == 0 || !ok || parent == node {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+	return roots
+}
+
+// inventoryKeys 组件在多次采集间的标识；部分设备重启后会重新编号 entPhysicalIndex，
+// 因此优先使用类别和名称，名称为空或重复时退回到索引
+func inventoryKeys(items []models.InventoryItem) []string {
+	counts := map[string]int{}
+	for i := range items {
+		counts[items[i].Class+"|"+items[i].Name]++
+	}
+	keys := make([]string, len(items))
+	for i := range items {
+		key := items[i].Class + "|" + items[i].Name
+		if items[i].Name == "" || counts[key] > 1 {
+			key += "#" + strconv.Itoa(items[i].PhysicalIndex)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// diffInventory 比较前后两次采集：序列号变化视为更换，其余版本和型号字段变化视为变更
+func diffInventory(deviceID uint, previous, current []models.InventoryItem) []models.InventoryEvent {
+	now := time.Now()
+	oldByKey := make(map[string]*models.InventoryItem, len(previous))
+	for i, key := range inventoryKeys(previous) {
+		oldByKey[key] = &previous[i]
+	}
+	currentKeys := inventoryKeys(current)
+
+	var events []models.InventoryEvent
+	for i := range current {
+		item := &current[i]
+		key := currentKeys[i]
+		old, ok := oldByKey[key]
+		if !ok {
+			events = append(events, models.InventoryEvent{
+				DeviceID:      deviceID,
+				PhysicalIndex: item.PhysicalIndex,
+				EventType:     "added",
+				Class:         item.Class,
+				Name:          item.Name,
+				NewSerial:     item.SerialNumber,
+				NewModel:      item.ModelName,
+				CreatedAt:     now,
+			})
+			continue
+		}
+		delete(oldByKey, key)
+
+		changes := map[string]models.InventoryChange{}
+		compare := func(field, oldValue, newValue string) {
+			if oldValue != newValue {
+				changes[field] = models.InventoryChange{Old: oldValue, New: newValue}
+			}
+		}
+		compare("serial_number", old.SerialNumber, item.SerialNumber)
+		compare("model_name", old.ModelName, item.ModelName)
+		compare("hardware_rev", old.HardwareRev, item.HardwareRev)
+		compare("firmware_rev", old.FirmwareRev, item.FirmwareRev)
+		compare("software_rev", old.SoftwareRev, item.SoftwareRev)
+		compare("vendor_type", old.VendorType, item.VendorType)
+		if len(changes) == 0 {
+			continue
+		}
+
+		eventType := "changed"
+		if _, ok := changes["serial_number"]; ok && old.SerialNumber != "" && item.SerialNumber != "" {
+			eventType = "replaced"
+		}
+		events = append(events, models.InventoryEvent{
+			DeviceID:      deviceID,
+			PhysicalIndex: item.PhysicalIndex,
+			EventType:     eventType,
+			Class:         item.Class,
+			Name:          item.Name,
+			OldSerial:     old.SerialNumber,
+			NewSerial:     item.SerialNumber,
+			OldModel:      old.ModelName,
+			NewModel:      item.ModelName,
+			Changes:       changes,
+			CreatedAt:     now,
+		})
+	}
+
+	removed := make([]*models.InventoryItem, 0, len(oldByKey))
+	for _, old := range oldByKey {
+		removed = append(removed, old)
+	}
+	sort.Slice(removed, func(i, j int) bool { return removed[i].PhysicalIndex < removed[j].PhysicalIndex })
+	for _, old := range removed {
+		events = append(events, models.InventoryEvent{
+			DeviceID:      deviceID,
+			PhysicalIndex: old.PhysicalIndex,
+			EventType:     "removed",
+			Class:         old.Class,
+			Name:          old.Name,
+			OldSerial:     old.SerialNumber,
+			OldModel:      old.ModelName,
+			CreatedAt:     now,
+		})
+	}
+	return events
+}
+
+// snmpString 将 OctetString 值转换为去除首尾空白的字符串
+func snmpString(pdu gosnmp.SnmpPDU) string {
+	if b, ok := pdu.Value.([]byte); ok {
+		return strings.TrimSpace(strings.TrimRight(string(b), "\x00"))
+	}
+	return strings.TrimSpace(fmt.Sprint(pdu.Value))
+}
@@ -0,0 +1,620 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+const (
+	maintenanceSyncInterval = time.Minute
+	// 周期开始前提前创建静默（Alertmanager 中为 pending 状态），避免定时同步的间隔内漏掉告警
+	maintenanceSilenceLead = 5 * time.Minute
+	// 即将开始的维护窗口在设备详情中展示的时间范围
+	maintenanceUpcoming = 7 * 24 * time.Hour
+)
+
+var ErrInvalidMaintenanceWindow = errors.New("invalid maintenance window")
+
+// maintenancePeriods 循环窗口的最短周期，单个周期的时长不能超过它
+var maintenancePeriods = map[string]time.Duration{
+	"daily":   24 * time.Hour,
+	"weekly":  7 * 24 * time.Hour,
+	"monthly": 28 * 24 * time.Hour,
+}
+
+// MaintenanceService 管理维护窗口，并定时为生效中的周期同步 Alertmanager 静默
+type MaintenanceService struct {
+	db           *gorm.DB
+	redis        *redis.Client
+	logger       utils.Logger
+	alertmanager *alertmanagerClient
+
+	mu      sync.Mutex
+	syncMu  sync.Mutex
+	running bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewMaintenanceService alertmanagerURL 为空时只抑制状态事件，不创建静默
+func NewMaintenanceService(db *gorm.DB, redis *redis.Client, logger utils.Logger, alertmanagerURL string) *MaintenanceService {
+	return &MaintenanceService{
+		db:           db,
+		redis:        redis,
+		logger:       logger,
+		alertmanager: newAlertmanagerClient(alertmanagerURL),
+	}
+}
+
+// Start 启动静默同步
+func (s *MaintenanceService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+
+	s.wg.Add(1)
+	go s.run()
+	s.logger.Info("Maintenance window sync started", "interval", maintenanceSyncInterval.String(), "alertmanager", s.alertmanager != nil)
+}
+
+// Stop 停止静默同步
+func (s *MaintenanceService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.logger.Info("Maintenance window sync stopped")
+}
+
+func (s *MaintenanceService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(maintenanceSyncInterval)
+	defer ticker.Stop()
+
+	s.syncAll()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.syncAll()
+		}
+	}
+}
+
+// syncAll 为所有设置了静默或即将生效的窗口同步静默
+func (s *MaintenanceService) syncAll() {
+	var windows []models.MaintenanceWindow
+	err := s.db.Where("(enabled = ? AND starts_at <= ?) OR silence_id <> ''", true, time.Now().Add(maintenanceSilenceLead)).
+		Find(&windows).Error
+	if err != nil {
+		s.logger.Error("Failed to load maintenance windows", "error", err)
+		return
+	}
+	for i := range windows {
+		if err := s.syncSilence(&windows[i]); err != nil {
+			s.logger.Warn("Failed to sync maintenance silence", "window_id", windows[i].ID, "error", err)
+		}
+	}
+}
+
+// syncSilence 当前或即将开始的周期没有静默时创建，窗口停用或周期结束后清除静默记录
+func (s *MaintenanceService) syncSilence(w *models.MaintenanceWindow) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	now := time.Now()
+	start, end, ok := maintenanceOccurrence(w, now)
+	due := w.Enabled && ok && start.Before(now.Add(maintenanceSilenceLead))
+
+	if w.SilenceID != "" {
+		if due && w.SilenceEndsAt != nil && w.SilenceEndsAt.Equal(end) {
+			return nil
+		}
+		// 静默已经随周期结束过期，只需清除记录；窗口停用时需要主动结束
+		if s.alertmanager != nil && w.SilenceEndsAt != nil && w.SilenceEndsAt.After(now) {
+			if err := s.alertmanager.expireSilence(w.SilenceID); err != nil {
+				return s.saveSilence(w, w.SilenceID, w.SilenceEndsAt, err.Error())
+			}
+		}
+		if err := s.saveSilence(w, "", nil, ""); err != nil {
+			return err
+		}
+	}
+	if !due || s.alertmanager == nil {
+		return nil
+	}
+
+	matchers, err := s.silenceMatchers(w)
+	if err != nil {
+		return err
+	}
+	if len(matchers) == 0 {
+		return s.saveSilence(w, "", nil, "no devices or hosts with an address in scope")
+	}
+	id, err := s.alertmanager.createSilence(&alertmanagerSilence{
+		Matchers:  matchers,
+		StartsAt:  start,
+		EndsAt:    end,
+		CreatedBy: "mib-platform",
+		Comment:   fmt.Sprintf("Maintenance window #%d: %s", w.ID, w.Name),
+	})
+	if err != nil {
+		return s.saveSilence(w, "", nil, err.Error())
+	}
+	s.logger.Info("Created maintenance silence", "window_id", w.ID, "silence_id", id, "starts_at", start, "ends_at", end)
+	return s.saveSilence(w, id, &end, "")
+}
+
+func (s *MaintenanceService) saveSilence(w *models.MaintenanceWindow, id string, endsAt *time.Time, silenceErr string) error {
+	w.SilenceID, w.SilenceEndsAt, w.SilenceError = id, endsAt, silenceErr
+	err := s.db.Model(w).Select("silence_id", "silence_ends_at", "silence_error").Updates(w).Error
+	if err != nil {
+		return fmt.Errorf("failed to save maintenance silence: %v", err)
+	}
+	if silenceErr != "" {
+		return errors.New(silenceErr)
+	}
+	return nil
+}
+
+// silenceMatchers 按范围内设备和主机的地址匹配 instance 标签，允许带端口
+func (s *MaintenanceService) silenceMatchers(w *models.MaintenanceWindow) ([]alertmanagerMatcher, error) {
+	deviceIDs, err := maintenanceDeviceIDs(s.db, w)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	if len(deviceIDs) > 0 {
+		var ips []string
+		if err := s.db.Model(&models.Device{}).Where("id IN ?", deviceIDs).Pluck("ip_address", &ips).Error; err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, ips...)
+	}
+	if len(w.HostIDs) > 0 {
+		var ips []string
+		if err := s.db.Model(&models.Host{}).Where("id IN ?", w.HostIDs).Pluck("ip", &ips).Error; err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, ips...)
+	}
+
+	seen := make(map[string]bool)
+	var patterns []string
+	for _, address := range addresses {
+		if address == "" || seen[address] {
+			continue
+		}
+		seen[address] = true
+		patterns = append(patterns, regexp.QuoteMeta(address))
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	sort.Strings(patterns)
+	return []alertmanagerMatcher{{
+		Name:    "instance",
+		Value:   "(" + strings.Join(patterns, "|") + ")(:[0-9]+)?",
+		IsRegex: true,
+		IsEqual: true,
+	}}, nil
+}
+
+// GetWindows 分页获取维护窗口，activeOnly 时只返回生效中的窗口
+func (s *MaintenanceService) GetWindows(page, limit int, activeOnly bool) ([]models.MaintenanceWindow, int64, error) {
+	var windows []models.MaintenanceWindow
+	var total int64
+
+	query := s.db.Model(&models.MaintenanceWindow{})
+	if activeOnly {
+		if err := query.Where("enabled = ? AND starts_at <= ?", true, time.Now()).Order("starts_at DESC").Find(&windows).Error; err != nil {
+			return nil, 0, err
+		}
+		now := time.Now()
+		active := windows[:0]
+		for i := range windows {
+			fillOccurrence(&windows[i], now)
+			if windows[i].Active {
+				active = append(active, windows[i])
+			}
+		}
+		total = int64(len(active))
+		offset := (page - 1) * limit
+		if offset < 0 || offset >= len(active) {
+			return []models.MaintenanceWindow{}, total, nil
+		}
+		if offset+limit < len(active) {
+			active = active[:offset+limit]
+		}
+		return active[offset:], total, nil
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * limit
+	if err := query.Order("starts_at DESC, id DESC").Offset(offset).Limit(limit).Find(&windows).Error; err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	for i := range windows {
+		fillOccurrence(&windows[i], now)
+	}
+	return windows, total, nil
+}
+
+func (s *MaintenanceService) GetWindow(id uint) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	if err := s.db.First(&window, id).Error; err != nil {
+		return nil, err
+	}
+	fillOccurrence(&window, time.Now())
+	return &window, nil
+}
+
+// CreateWindow 创建维护窗口，已经生效时立即创建静默
+func (s *MaintenanceService) CreateWindow(req *models.MaintenanceWindowRequest) (*models.MaintenanceWindow, error) {
+	window := &models.MaintenanceWindow{}
+	if err := s.applyRequest(window, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(window).Error; err != nil {
+		return nil, fmt.Errorf("failed to create maintenance window: %v", err)
+	}
+
+	if err := s.syncSilence(window); err != nil {
+		s.logger.Warn("Failed to sync maintenance silence", "window_id", window.ID, "error", err)
+	}
+	fillOccurrence(window, time.Now())
+	return window, nil
+}
+
+// UpdateWindow 更新维护窗口，时间或范围变化后重新创建静默
+func (s *MaintenanceService) UpdateWindow(id uint, req *models.MaintenanceWindowRequest) (*models.MaintenanceWindow, error) {
+	var window models.MaintenanceWindow
+	if err := s.db.First(&window, id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(&window, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Omit("silence_id", "silence_ends_at", "silence_error").Save(&window).Error; err != nil {
+		return nil, fmt.Errorf("failed to update maintenance window: %v", err)
+	}
+
+	// 先结束旧静默，再按新的时间和范围创建
+	if err := s.expireWindowSilence(&window); err != nil {
+		s.logger.Warn("Failed to expire maintenance silence", "window_id", window.ID, "error", err)
+	}
+	if err := s.syncSilence(&window); err != nil {
+		s.logger.Warn("Failed to sync maintenance silence", "window_id", window.ID, "error", err)
+	}
+	fillOccurrence(&window, time.Now())
+	return &window, nil
+}
+
+// DeleteWindow 删除维护窗口并结束对应的静默，静默结束失败时仍会在周期结束时自动过期
+func (s *MaintenanceService) DeleteWindow(id uint) error {
+	var window models.MaintenanceWindow
+	if err := s.db.First(&window, id).Error; err != nil {
+		return err
+	}
+	if err := s.expireWindowSilence(&window); err != nil {
+		s.logger.Warn("Failed to expire maintenance silence", "window_id", window.ID, "error", err)
+	}
+	return s.db.Delete(&window).Error
+}
+
+// expireWindowSilence 主动结束窗口当前的静默并清除记录
+func (s *MaintenanceService) expireWindowSilence(w *models.MaintenanceWindow) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if w.SilenceID == "" {
+		return nil
+	}
+	if s.alertmanager != nil {
+		if err := s.alertmanager.expireSilence(w.SilenceID); err != nil {
+			return err
+		}
+	}
+	return s.saveSilence(w, "", nil, "")
+}
+
+// applyRequest 校验请求并写入窗口
+func (s *MaintenanceService) applyRequest(w *models.MaintenanceWindow, req *models.MaintenanceWindowRequest) error {
+	recurrence := req.Recurrence
+	if recurrence == "" {
+		recurrence = "none"
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidMaintenanceWindow)
+	}
+	if recurrence != "none" {
+		period, ok := maintenancePeriods[recurrence]
+		if !ok {
+			return fmt.Errorf("%w: unsupported recurrence %s", ErrInvalidMaintenanceWindow, recurrence)
+		}
+		if req.EndsAt.Sub(req.StartsAt) > period {
+			return fmt.Errorf("%w: a %s window cannot last longer than %s", ErrInvalidMaintenanceWindow, recurrence, period)
+		}
+		if req.RepeatUntil != nil && req.RepeatUntil.Before(req.StartsAt) {
+			return fmt.Errorf("%w: repeat_until must not be before starts_at", ErrInvalidMaintenanceWindow)
+		}
+	}
+	if len(req.DeviceIDs) == 0 && len(req.GroupIDs) == 0 && len(req.HostIDs) == 0 {
+		return fmt.Errorf("%w: at least one device, device group or host is required", ErrInvalidMaintenanceWindow)
+	}
+
+	if len(req.DeviceIDs) > 0 {
+		var count int64
+		if err := s.db.Model(&models.Device{}).Where("id IN ?", req.DeviceIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(uniqueUints(req.DeviceIDs)) {
+			return fmt.Errorf("%w: some devices do not exist", ErrInvalidMaintenanceWindow)
+		}
+	}
+	if len(req.GroupIDs) > 0 {
+		var count int64
+		if err := s.db.Model(&models.DeviceGroup{}).Where("id IN ?", req.GroupIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(uniqueStrings(req.GroupIDs)) {
+			return fmt.Errorf("%w: some device groups do not exist", ErrInvalidMaintenanceWindow)
+		}
+	}
+	if len(req.HostIDs) > 0 {
+		var count int64
+		if err := s.db.Model(&models.Host{}).Where("id IN ?", req.HostIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if int(count) != len(uniqueUints(req.HostIDs)) {
+			return fmt.Errorf("%w: some hosts do not exist", ErrInvalidMaintenanceWindow)
+		}
+	}
+
+	w.Name = req.Name
+	w.Description = req.Description
+	w.StartsAt = req.StartsAt
+	w.EndsAt = req.EndsAt
+	w.Recurrence = recurrence
+	w.RepeatUntil = req.RepeatUntil
+	w.DeviceIDs = uniqueUints(req.DeviceIDs)
+	w.GroupIDs = uniqueStrings(req.GroupIDs)
+	w.HostIDs = uniqueUints(req.HostIDs)
+	w.Enabled = req.Enabled == nil || *req.Enabled
+	if req.CreatedBy != "" {
+		w.CreatedBy = req.CreatedBy
+	}
+	return nil
+}
+
+// GetDeviceMaintenance 返回设备生效中和一周内将要开始的维护窗口
+func (s *MaintenanceService) GetDeviceMaintenance(deviceID uint) ([]models.DeviceMaintenance, error) {
+	result, err := deviceMaintenance(s.db, []uint{deviceID})
+	if err != nil {
+		return nil, err
+	}
+	if result[deviceID] == nil {
+		return []models.DeviceMaintenance{}, nil
+	}
+	return result[deviceID], nil
+}
+
+// maintenanceOccurrence 返回 at 时刻所在或之后的第一个周期，没有更多周期时 ok 为 false
+func maintenanceOccurrence(w *models.MaintenanceWindow, at time.Time) (start, end time.Time, ok bool) {
+	duration := w.EndsAt.Sub(w.StartsAt)
+	if _, recurring := maintenancePeriods[w.Recurrence]; !recurring {
+		return w.StartsAt, w.EndsAt, at.Before(w.EndsAt)
+	}
+
+	n := 0
+	if at.After(w.StartsAt) {
+		// 先按天、周或月粗略估算周期序号，再逐个修正（跨夏令时的天不是 24 小时）
+		switch w.Recurrence {
+		case "daily":
+			n = int(at.Sub(w.StartsAt) / (24 * time.Hour))
+		case "weekly":
+			n = int(at.Sub(w.StartsAt) / (7 * 24 * time.Hour))
+		case "monthly":
+			n = (at.Year()-w.StartsAt.Year())*12 + int(at.Month()-w.StartsAt.Month())
+		}
+		for n > 0 && occurrenceStart(w, n).After(at) {
+			n--
+		}
+		for !occurrenceStart(w, n+1).After(at) {
+			n++
+		}
+		// 此时第 n 个周期是开始时间不晚于 at 的最后一个，已经结束时取下一个
+		if !occurrenceStart(w, n).Add(duration).After(at) {
+			n++
+		}
+	}
+
+	start = occurrenceStart(w, n)
+	if w.RepeatUntil != nil && start.After(*w.RepeatUntil) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.Add(duration), true
+}
+
+func occurrenceStart(w *models.MaintenanceWindow, n int) time.Time {
+	switch w.Recurrence {
+	case "daily":
+		return w.StartsAt.AddDate(0, 0, n)
+	case "weekly":
+		return w.StartsAt.AddDate(0, 0, 7*n)
+	case "monthly":
+		// 31 号开始的窗口在小月取当月最后一天，而不是顺延到下个月
+		s := w.StartsAt
+		first := time.Date(s.Year(), s.Month()+time.Month(n), 1, s.Hour(), s.Minute(), s.Second(), s.Nanosecond(), s.Location())
+		day := s.Day()
+		if last := first.AddDate(0, 1, -1).Day(); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1)
+	}
+	return w.StartsAt
+}
+
+// fillOccurrence 填充窗口当前或下一个周期
+func fillOccurrence(w *models.MaintenanceWindow, now time.Time) {
+	start, end, ok := maintenanceOccurrence(w, now)
+	if !ok {
+		return
+	}
+	w.NextStartsAt, w.NextEndsAt = &start, &end
+	w.Active = w.Enabled && !start.After(now)
+}
+
+// maintenanceDeviceIDs 窗口直接指定的设备和所选分组中的设备
+func maintenanceDeviceIDs(db *gorm.DB, w *models.MaintenanceWindow) ([]uint, error) {
+	ids := append([]uint(nil), w.DeviceIDs...)
+	if len(w.GroupIDs) > 0 {
+		var members []string
+		err := db.Model(&models.DeviceGroupDevice{}).Where("device_group_id IN ?", w.GroupIDs).
+			Pluck("device_id", &members).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to load device group members: %v", err)
+		}
+		for _, member := range members {
+			if id, err := strconv.ParseUint(member, 10, 32); err == nil {
+				ids = append(ids, uint(id))
+			}
+		}
+	}
+	return uniqueUints(ids), nil
+}
+
+// deviceMaintenance 按设备汇总生效中和即将开始的维护窗口
+func deviceMaintenance(db *gorm.DB, deviceIDs []uint) (map[uint][]models.DeviceMaintenance, error) {
+	result := make(map[uint][]models.DeviceMaintenance)
+	if len(deviceIDs) == 0 {
+		return result, nil
+	}
+
+	now := time.Now()
+	var windows []models.MaintenanceWindow
+	if err := db.Where("enabled = ? AND starts_at <= ?", true, now.Add(maintenanceUpcoming)).Order("starts_at").Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load maintenance windows: %v", err)
+	}
+
+	wanted := make(map[uint]bool, len(deviceIDs))
+	for _, id := range deviceIDs {
+		wanted[id] = true
+	}
+	for i := range windows {
+		w := &windows[i]
+		start, end, ok := maintenanceOccurrence(w, now)
+		if !ok || start.After(now.Add(maintenanceUpcoming)) {
+			continue
+		}
+		members, err := maintenanceDeviceIDs(db, w)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range members {
+			if wanted[id] {
+				result[id] = append(result[id], models.DeviceMaintenance{
+					WindowID: w.ID,
+					Name:     w.Name,
+					Active:   !start.After(now),
+					StartsAt: start,
+					EndsAt:   end,
+				})
+			}
+		}
+	}
+	return result, nil
+}
+
+// activeMaintenanceWindow 返回 at 时刻覆盖设备的维护窗口，没有时返回 nil
+func activeMaintenanceWindow(db *gorm.DB, deviceID uint, at time.Time) (*models.MaintenanceWindow, error) {
+	var windows []models.MaintenanceWindow
+	if err := db.Where("enabled = ? AND starts_at <= ?", true, at).Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load maintenance windows: %v", err)
+	}
+
+	var groupIDs []string
+	groupsLoaded := false
+	for i := range windows {
+		w := &windows[i]
+		start, _, ok := maintenanceOccurrence(w, at)
+		if !ok || start.After(at) {
+			continue
+		}
+		for _, id := range w.DeviceIDs {
+			if id == deviceID {
+				return w, nil
+			}
+		}
+		if len(w.GroupIDs) == 0 {
+			continue
+		}
+		if !groupsLoaded {
+			err := db.Model(&models.DeviceGroupDevice{}).
+				Where("device_id = ?", strconv.FormatUint(uint64(deviceID), 10)).
+				Pluck("device_group_id", &groupIDs).Error
+			if err != nil {
+				return nil, fmt.Errorf("failed to load device groups: %v", err)
+			}
+			groupsLoaded = true
+		}
+		for _, groupID := range w.GroupIDs {
+			if containsString(groupIDs, groupID) {
+				return w, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func uniqueUints(values []uint) []uint {
+	seen := make(map[uint]bool, len(values))
+	result := make([]uint, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -88,13 +88,17 @@ func (s *MIBService) DeleteMIB(id uint) error {
 
 // MIB 解析结构
 type MIBParseResult struct {
-	OID         string `json:"oid"`
-	Name        string `json:"name"`
-	Type        string `json:"type"`
-	Access      string `json:"access"`
-	Description string `json:"description"`
-	Status      string `json:"status"`
-	Module      string `json:"module"`
+	OID         string         `json:"oid"`
+	Name        string         `json:"name"`
+	Type        string         `json:"type"`
+	Access      string         `json:"access"`
+	Description string         `json:"description"`
+	Status      string         `json:"status"`
+	Module      string         `json:"module"`
+	Syntax      string         `json:"syntax,omitempty"` // 文本约定名称，如 DisplayString
+	Indexes     []string       `json:"indexes,omitempty"`
+	Augments    string         `json:"augments,omitempty"`
+	EnumValues  map[int]string `json:"enum_values,omitempty"`
 }
 
 // 扫描指定目录中的 MIB 文件
@@ -168,8 +172,18 @@ func (s *MIBService) ParseMIBWithSNMPTranslate(filePath string) ([]MIBParseResul
 					currentOID.OID = match
 				}
 			}
+		} else if strings.HasPrefix(line, "-- TEXTUAL CONVENTION") {
+			currentOID.Syntax = strings.TrimSpace(strings.TrimPrefix(line, "-- TEXTUAL CONVENTION"))
 		} else if strings.HasPrefix(line, "SYNTAX") {
 			currentOID.Type = strings.TrimSpace(strings.TrimPrefix(line, "SYNTAX"))
+			currentOID.EnumValues = parseMIBEnumValues(currentOID.Type)
+			if currentOID.Syntax == "" {
+				currentOID.Syntax = mibSyntaxName(currentOID.Type)
+			}
+		} else if strings.HasPrefix(line, "INDEX") {
+			currentOID.Indexes = parseMIBIndexes(strings.TrimPrefix(line, "INDEX"))
+		} else if strings.HasPrefix(line, "AUGMENTS") {
+			currentOID.Augments = strings.Trim(strings.TrimPrefix(line, "AUGMENTS"), "{} \t")
 		} else if strings.HasPrefix(line, "ACCESS") {
 			currentOID.Access = strings.TrimSpace(strings.TrimPrefix(line, "ACCESS"))
 		} else if strings.HasPrefix(line, "STATUS") {
@@ -209,15 +223,24 @@ func (s *MIBService) parseMIBContentFallback(filePath string) ([]MIBParseResult,
 
 	var currentOID MIBParseResult
 	inObjectDef := false
+	// 对象定义的完整文本，INDEX 和枚举值可能跨多行
+	var definition strings.Builder
+	flush := func() {
+		if currentOID.Name == "" {
+			return
+		}
+		if inObjectDef {
+			applyMIBObjectClauses(&currentOID, definition.String())
+		}
+		results = append(results, currentOID)
+	}
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		
 		// 检查 OBJECT IDENTIFIER 定义
 		if matches := oidRegex.FindStringSubmatch(line); len(matches) > 2 {
-			if currentOID.Name != "" {
-				results = append(results, currentOID)
-			}
+			flush()
 			currentOID = MIBParseResult{
 				Name: matches[1],
 				OID:  s.parseOIDPath(matches[2]),
@@ -227,16 +250,17 @@ func (s *MIBService) parseMIBContentFallback(filePath string) ([]MIBParseResult,
 
 		// 检查 OBJECT-TYPE 定义
 		if matches := objectRegex.FindStringSubmatch(line); len(matches) > 1 {
-			if currentOID.Name != "" {
-				results = append(results, currentOID)
-			}
+			flush()
 			currentOID = MIBParseResult{
 				Name: matches[1],
 			}
 			inObjectDef = true
+			definition.Reset()
 		}
 
 		if inObjectDef {
+			definition.WriteString(line)
+			definition.WriteByte('\n')
 			if matches := syntaxRegex.FindStringSubmatch(line); len(matches) > 1 {
 				currentOID.Type = strings.TrimSpace(matches[1])
 			}
@@ -253,13 +277,75 @@ func (s *MIBService) parseMIBContentFallback(filePath string) ([]MIBParseResult,
 	}
 
 	// 添加最后一个 OID
-	if currentOID.Name != "" {
-		results = append(results, currentOID)
-	}
+	flush()
 
 	return results, nil
 }
 
+var (
+	mibIndexRegex    = regexp.MustCompile(`INDEX\s*\{([^}]*)\}`)
+	mibAugmentsRegex = regexp.MustCompile(`AUGMENTS\s*\{\s*([\w-]+)\s*\}`)
+	mibEnumSyntax    = regexp.MustCompile(`SYNTAX\s+((?:INTEGER|BITS)\s*\{[^}]*\})`)
+	mibEnumValue     = regexp.MustCompile(`([A-Za-z][\w-]*)\s*\(\s*(-?\d+)\s*\)`)
+)
+
+// applyMIBObjectClauses 从 OBJECT-TYPE 定义中提取文本约定、INDEX、AUGMENTS 和枚举值
+func applyMIBObjectClauses(result *MIBParseResult, definition string) {
+	result.Syntax = mibSyntaxName(result.Type)
+	if matches := mibIndexRegex.FindStringSubmatch(definition); len(matches) > 1 {
+		result.Indexes = parseMIBIndexes(matches[1])
+	}
+	if matches := mibAugmentsRegex.FindStringSubmatch(definition); len(matches) > 1 {
+		result.Augments = matches[1]
+	}
+	if matches := mibEnumSyntax.FindStringSubmatch(definition); len(matches) > 1 {
+		result.EnumValues = parseMIBEnumValues(matches[1])
+	}
+}
+
+// parseMIBIndexes 解析 INDEX 子句中的对象列表，保留 IMPLIED 前缀
+func parseMIBIndexes(clause string) []string {
+	var indexes []string
+	for _, part := range strings.Split(strings.Trim(strings.TrimSpace(clause), "{}"), ",") {
+		if fields := strings.Fields(part); len(fields) > 0 {
+			indexes = append(indexes, strings.Join(fields, " "))
+		}
+	}
+	return indexes
+}
+
+// parseMIBEnumValues 解析 INTEGER { up(1), down(2) } 形式的枚举值
+func parseMIBEnumValues(syntax string) map[int]string {
+	start := strings.Index(syntax, "{")
+	if start < 0 {
+		return nil
+	}
+	values := make(map[int]string)
+	for _, matches := range mibEnumValue.FindAllStringSubmatch(syntax[start:], -1) {
+		if n, err := strconv.Atoi(matches[2]); err == nil {
+			values[n] = matches[1]
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// mibSyntaxName 取 SYNTAX 的类型名，如 DisplayString、Counter32，基础类型保留完整写法
+func mibSyntaxName(syntax string) string {
+	syntax = strings.TrimSpace(syntax)
+	for _, base := range []string{"OCTET STRING", "OBJECT IDENTIFIER"} {
+		if strings.HasPrefix(syntax, base) {
+			return base
+		}
+	}
+	if i := strings.IndexAny(syntax, " \t({"); i >= 0 {
+		return syntax[:i]
+	}
+	return syntax
+}
+
 // 解析 OID 路径
 func (s *MIBService) parseOIDPath(oidPath string) string {
 	// 简单的 OID 路径解析
@@ -292,6 +378,10 @@ func (s *MIBService) ParseMIB(filePath string) ([]models.OID, error) {
 			Access:      result.Access,
 			Description: result.Description,
 			Status:      result.Status,
+			Syntax:      result.Syntax,
+			Indexes:     result.Indexes,
+			Augments:    result.Augments,
+			EnumValues:  result.EnumValues,
 		}
 		oids = append(oids, oid)
 	}
@@ -0,0 +1,538 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gosnmp/gosnmp"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+const (
+	// 设备列表刷新周期，新增或修改的设备在该周期内进入调度
+	pollRefreshInterval = 30 * time.Second
+	// 轮询历史保留时长
+	pollHistoryRetention = 7 * 24 * time.Hour
+	// 每次调度的抖动比例
+	pollJitterRatio = 0.1
+)
+
+// PollingService 按设备模板 OID 定时轮询设备的调度器
+type PollingService struct {
+	db              *gorm.DB
+	redis           *redis.Client
+	snmpService     *SNMPService
+	logger          utils.Logger
+	defaultInterval time.Duration
+	workers         int
+
+	mu          sync.Mutex
+	running     bool
+	schedule    map[uint]*pollSchedule
+	inFlight    map[uint]bool
+	lastRefresh time.Time
+	jobs        chan uint
+	stop        chan struct{}
+	wg          sync.WaitGroup
+}
+
+type pollSchedule struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// NewPollingService 创建轮询调度器，interval 单位为秒
+func NewPollingService(db *gorm.DB, redis *redis.Client, logger utils.Logger, interval, workers int) *PollingService {
+	if interval <= 0 {
+		interval = 60
+	}
+	if workers <= 0 {
+		workers = 10
+	}
+	return &PollingService{
+		db:              db,
+		redis:           redis,
+		snmpService:     NewSNMPService(db, redis),
+		logger:          logger,
+		defaultInterval: time.Duration(interval) * time.Second,
+		workers:         workers,
+		schedule:        make(map[uint]*pollSchedule),
+		inFlight:        make(map[uint]bool),
+	}
+}
+
+// Start 启动调度循环和工作池
+func (s *PollingService) Start() {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.jobs = make(chan uint, s.workers*2)
+	s.stop = make(chan struct{})
+	s.mu.Unlock()
+
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+	s.wg.Add(1)
+	go s.run()
+
+	s.logger.Info("Polling scheduler started", "workers", s.workers, "interval", s.defaultInterval.String())
+}
+
+// Stop 停止调度并等待进行中的轮询结束
+func (s *PollingService) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.logger.Info("Polling scheduler stopped")
+}
+
+// GetStatus 获取调度器运行状态
+func (s *PollingService) GetStatus() *models.PollingStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := &models.PollingStatus{
+		Running:          s.running,
+		Workers:          s.workers,
+		DefaultInterval:  int(s.defaultInterval / time.Second),
+		ScheduledDevices: len(s.schedule),
+		InFlight:         len(s.inFlight),
+		LastRefresh:      s.lastRefresh,
+	}
+	if s.jobs != nil {
+		status.QueueLength = len(s.jobs)
+	}
+	return status
+}
+
+// GetLatestValues 获取设备最新采集值
+func (s *PollingService) GetLatestValues(deviceID uint, oid string) ([]models.PollValue, error) {
+	var values []models.PollValue
+	query := s.db.Where("device_id = ?", deviceID)
+	if oid != "" {
+		query = query.Where("oid = ? OR oid LIKE ?", normalizeOID(oid), normalizeOID(oid)+".%")
+	}
+	if err := query.Order("oid").Find(&values).Error; err != nil {
+		return nil, fmt.Errorf("failed to get poll values: %v", err)
+	}
+	return values, nil
+}
+
+// GetPollHistory 获取设备轮询历史
+func (s *PollingService) GetPollHistory(deviceID uint, page, limit int) ([]models.PollRecord, int64, error) {
+	var records []models.PollRecord
+	var total int64
+
+	query := s.db.Model(&models.PollRecord{}).Where("device_id = ?", deviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count poll records: %v", err)
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("started_at DESC").Offset(offset).Limit(limit).Find(&records).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get poll records: %v", err)
+	}
+
+	return records, total, nil
+}
+
+// PollDevice 立即轮询一次设备并返回本次记录
+func (s *PollingService) PollDevice(deviceID uint) (*models.PollRecord, error) {
+	s.mu.Lock()
+	if s.inFlight[deviceID] {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("device %d is already being polled", deviceID)
+	}
+	s.inFlight[deviceID] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.inFlight, deviceID)
+		s.mu.Unlock()
+	}()
+
+	return s.poll(deviceID)
+}
+
+// run 调度循环：定期刷新设备列表，并把到期的设备放入任务队列
+func (s *PollingService) run() {
+	defer s.wg.Done()
+	defer close(s.jobs)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	s.refresh()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			if now.Sub(s.lastRefreshTime()) >= pollRefreshInterval {
+				s.refresh()
+			}
+			s.dispatch(now)
+		}
+	}
+}
+
+func (s *PollingService) lastRefreshTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRefresh
+}
+
+// refresh 重新加载带模板的设备，并清理过期的轮询历史
+func (s *PollingService) refresh() {
+	var devices []models.Device
+	if err := s.db.Where("template_id IS NOT NULL").Find(&devices).Error; err != nil {
+		s.logger.Error("Failed to load devices for polling", "error", err)
+		return
+	}
+	templates, err := loadTemplateTree(s.db)
+	if err != nil {
+		s.logger.Error("Failed to load device templates for polling", "error", err)
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	seen := make(map[uint]bool, len(devices))
+	for i := range devices {
+		device := &devices[i]
+		// 使用合并了父模板后的 OID 和配置
+		device.Template, err = templates.resolve(*device.TemplateID)
+		if err != nil || len(device.Template.OIDs) == 0 {
+			continue
+		}
+		seen[device.ID] = true
+
+		interval := s.deviceInterval(device)
+		if entry, ok := s.schedule[device.ID]; ok {
+			entry.interval = interval
+			continue
+		}
+		// 首次调度在一个周期内随机分散，避免所有设备同时轮询
+		s.schedule[device.ID] = &pollSchedule{
+			interval: interval,
+			next:     now.Add(time.Duration(rand.Int63n(int64(interval)))),
+		}
+	}
+	for id := range s.schedule {
+		if !seen[id] {
+			delete(s.schedule, id)
+		}
+	}
+	s.lastRefresh = now
+	s.mu.Unlock()
+
+	if err := s.db.Where("started_at < ?", now.Add(-pollHistoryRetention)).Delete(&models.PollRecord{}).Error; err != nil {
+		s.logger.Warn("Failed to clean up poll history", "error", err)
+	}
+}
+
+// dispatch 将到期且未在轮询中的设备放入队列，队列已满时顺延到下一秒
+func (s *PollingService) dispatch(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, entry := range s.schedule {
+		if now.Before(entry.next) || s.inFlight[id] {
+			continue
+		}
+		select {
+		case s.jobs <- id:
+			s.inFlight[id] = true
+			entry.next = now.Add(jitter(entry.interval))
+		default:
+			return
+		}
+	}
+}
+
+func (s *PollingService) worker() {
+	defer s.wg.Done()
+	for id := range s.jobs {
+		if _, err := s.poll(id); err != nil {
+			s.logger.Warn("Device poll failed", "device_id", id, "error", err)
+		}
+		s.mu.Lock()
+		delete(s.inFlight, id)
+		s.mu.Unlock()
+	}
+}
+
+// deviceInterval 轮询间隔优先级：设备配置 > 模板 config.poll_interval > 全局默认值
+func (s *PollingService) deviceInterval(device *models.Device) time.Duration {
+	if device.PollInterval > 0 {
+		return time.Duration(device.PollInterval) * time.Second
+	}
+	if device.Template != nil && device.Template.Config != nil {
+		if seconds := toPollSeconds(device.Template.Config["poll_interval"]); seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return s.defaultInterval
+}
+
+// poll 采集设备模板中的全部 OID，保存最新值和本次历史记录，并更新设备状态
+func (s *PollingService) poll(deviceID uint) (*models.PollRecord, error) {
+	var device models.Device
+	if err := s.db.Preload("Credentials").First(&device, deviceID).Error; err != nil {
+		return nil, err
+	}
+	if device.TemplateID != nil {
+		template, err := resolveDeviceTemplate(s.db, *device.TemplateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve device template: %v", err)
+		}
+		device.Template = template
+	}
+
+	record := &models.PollRecord{
+		DeviceID:  device.ID,
+		StartedAt: time.Now(),
+	}
+
+	scalars, subtrees := pollOIDs(&device)
+	record.OIDCount = len(scalars) + len(subtrees)
+	values, failed, err := s.collect(&device, scalars, subtrees)
+	if saveErr := s.saveValues(device.ID, values, failed); saveErr != nil && err == nil {
+		err = fmt.Errorf("failed to save poll values: %v", saveErr)
+	}
+
+	record.Duration = time.Since(record.StartedAt).Milliseconds()
+	record.ValueCount = len(values)
+	record.Success = err == nil
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if createErr := s.db.Create(record).Error; createErr != nil {
+		s.logger.Error("Failed to save poll record", "device_id", device.ID, "error", createErr)
+	}
+
+	// 只要取到了值就认为设备在线，部分 OID 失败不影响设备状态
+	status, reason := "online", fmt.Sprintf("poll returned %d values", record.ValueCount)
+	if record.ValueCount == 0 && err != nil {
+		status, reason = "offline", record.Error
+	}
+	if _, updateErr := recordDeviceStatus(s.db, device.ID, status, reason, "poll"); updateErr != nil {
+		s.logger.Error("Failed to update device status", "device_id", device.ID, "error", updateErr)
+	}
+
+	return record, err
+}
+
+// saveValues 在同一事务中写入最新值，并删除本次轮询集合之外的旧值（OID 已移出模板、模板换绑或实例消失）；
+// 采集失败的 OID 下的旧值保留到下次轮询
+func (s *PollingService) saveValues(deviceID uint, values []models.PollValue, failed []string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if len(values) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "device_id"}, {Name: "oid"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "type", "value", "collected_at", "updated_at"}),
+			}).CreateInBatches(values, 200).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var existing []string
+		if err := tx.Model(&models.PollValue{}).Where("device_id = ?", deviceID).Pluck("oid", &existing).Error; err != nil {
+			return err
+		}
+		current := make(map[string]bool, len(values))
+		for _, value := range values {
+			current[value.OID] = true
+		}
+		var stale []string
+		for _, oid := range existing {
+			if !current[oid] && !underAnyOID(oid, failed) {
+				stale = append(stale, oid)
+			}
+		}
+		for i := 0; i < len(stale); i += 1000 {
+			end := i + 1000
+			if end > len(stale) {
+				end = len(stale)
+			}
+			if err := tx.Where("device_id = ? AND oid IN ?", deviceID, stale[i:end]).Delete(&models.PollValue{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// underAnyOID 判断 oid 是否等于 roots 中的某个 OID 或位于其子树下
+func underAnyOID(oid string, roots []string) bool {
+	for _, root := range roots {
+		if oid == root || strings.HasPrefix(oid, root+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// pollOIDs 把模板 OID 分为用 GET 采集的标量和需要遍历的子树
+func pollOIDs(device *models.Device) (scalars, subtrees []string) {
+	if device.Template == nil {
+		return nil, nil
+	}
+	for _, oid := range device.Template.OIDs {
+		oid = normalizeOID(oid)
+		if oid == "" {
+			continue
+		}
+		if strings.HasSuffix(oid, ".0") {
+			scalars = append(scalars, oid)
+		} else {
+			subtrees = append(subtrees, oid)
+		}
+	}
+	// 继承或重叠的模板 OID 只遍历一次，否则同一 OID 会在一次批量 upsert 中出现两次
+	return scalars, uniqueRootOIDs(subtrees)
+}
+
+// collect 对标量 OID（以 .0 结尾）批量 GET，其余 OID 按子树遍历，同时返回未能采集的 OID；
+// 无法连接设备时全部 OID 都算作失败
+func (s *PollingService) collect(device *models.Device, scalars, subtrees []string) ([]models.PollValue, []string, error) {
+	all := append(append([]string{}, scalars...), subtrees...)
+	if len(all) == 0 {
+		return nil, nil, fmt.Errorf("device has no template OIDs to poll")
+	}
+	if len(device.Credentials) == 0 {
+		return nil, all, fmt.Errorf("no SNMP credentials configured for device")
+	}
+
+	snmp, err := s.snmpService.createSNMPConnection(newDeviceSNMPRequest(device, device.Credentials[0], ""))
+	if err != nil {
+		return nil, all, err
+	}
+	defer snmp.Conn.Close()
+
+	now := time.Now()
+	names := make(map[string]string)
+	var values []models.PollValue
+	index := make(map[string]int)
+	add := func(root string, pdu gosnmp.SnmpPDU) {
+		if !hasSNMPValue(pdu) {
+			return
+		}
+		valueType, value := encodeSetValue(pdu)
+		if valueType == "" {
+			valueType, value = strings.ToLower(pdu.Type.String()), fmt.Sprint(pdu.Value)
+		}
+		if _, ok := names[root]; !ok {
+			names[root] = ""
+			if object, err := s.snmpService.lookupMIBObject(root); err == nil {
+				names[root] = object.Name
+			}
+		}
+		pollValue := models.PollValue{
+			DeviceID:    device.ID,
+			OID:         normalizeOID(pdu.Name),
+			Name:        names[root],
+			Type:        valueType,
+			Value:       value,
+			CollectedAt: now,
+		}
+		// 标量也可能落在某个子树内，同一 OID 只保留最后一次取到的值
+		if i, ok := index[pollValue.OID]; ok {
+			values[i] = pollValue
+			return
+		}
+		index[pollValue.OID] = len(values)
+		values = append(values, pollValue)
+	}
+
+	var errs, failed []string
+	maxOids := snmp.MaxOids
+	if maxOids <= 0 {
+		maxOids = gosnmp.MaxOids
+	}
+	for i := 0; i < len(scalars); i += maxOids {
+		end := i + maxOids
+		if end > len(scalars) {
+			end = len(scalars)
+		}
+		result, err := snmp.Get(scalars[i:end])
+		if err != nil {
+			errs = append(errs, err.Error())
+			failed = append(failed, scalars[i:end]...)
+			continue
+		}
+		for _, pdu := range result.Variables {
+			add(normalizeOID(pdu.Name), pdu)
+		}
+	}
+
+	for _, root := range subtrees {
+		walk := snmp.BulkWalk
+		if snmp.Version == gosnmp.Version1 {
+			walk = snmp.Walk
+		}
+		err := walk(root, func(pdu gosnmp.SnmpPDU) error {
+			add(root, pdu)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", root, err))
+			failed = append(failed, root)
+		}
+	}
+
+	if len(errs) > 0 {
+		return values, failed, fmt.Errorf("failed to poll %d of %d OIDs: %s", len(errs), len(all), strings.Join(errs, "; "))
+	}
+	return values, nil, nil
+}
+
+// jitter 在间隔基础上增加 ±10% 的随机偏移
+func jitter(interval time.Duration) time.Duration {
+	delta := int64(float64(interval) * pollJitterRatio)
+	if delta <= 0 {
+		return interval
+	}
+	return interval - time.Duration(delta) + time.Duration(rand.Int63n(2*delta+1))
+}
+
+func toPollSeconds(value interface{}) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return int(d / time.Second)
+		}
+		n, _ := strconv.Atoi(v)
+		return n
+	default:
+		return 0
+	}
+}
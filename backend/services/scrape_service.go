@@ -0,0 +1,779 @@
+package services
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gosnmp/gosnmp"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+)
+
+var (
+	// ErrScrapeTargetNotFound 目标不是已登记的设备，且模块中也没有可用的认证信息
+	ErrScrapeTargetNotFound = errors.New("unknown scrape target")
+	// ErrScrapeModuleNotFound 既没有同名的设备模板，也没有同名的 snmp_exporter 模块
+	ErrScrapeModuleNotFound = errors.New("unknown module")
+)
+
+// ScrapeService 兼容 snmp_exporter 的 /snmp 采集接口
+type ScrapeService struct {
+	db            *gorm.DB
+	redis         *redis.Client
+	snmpService   *SNMPService
+	configService *ConfigService
+}
+
+func NewScrapeService(db *gorm.DB, redis *redis.Client) *ScrapeService {
+	return &ScrapeService{
+		db:            db,
+		redis:         redis,
+		snmpService:   NewSNMPService(db, redis),
+		configService: NewConfigService(db, redis),
+	}
+}
+
+// promLabel 保持插入顺序的标签，同名标签后写覆盖先写
+type promLabel struct {
+	name  string
+	value string
+}
+
+type promFamily struct {
+	name    string
+	help    string
+	typ     string
+	samples []string
+	seen    map[string]bool
+}
+
+// Scrape 采集目标设备，返回 Prometheus 文本格式的指标；authName 为新格式配置中 auths 的名称
+func (s *ScrapeService) Scrape(target, moduleName, authName string) (string, error) {
+	start := time.Now()
+
+	// 与 snmp_exporter 一致，target 可以写成 tcp://host:port 或 udp6://[addr]:port
+	transport, host, port := parseSNMPAgentAddress(target)
+
+	device := s.findDevice(host)
+	module, name, err := s.resolveModule(moduleName, authName, device)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := s.buildRequest(host, port, device, module)
+	if err != nil {
+		return "", err
+	}
+	if transport != "" {
+		req.Transport = transport
+	}
+
+	snmp, err := s.snmpService.createSNMPConnection(req)
+	if err != nil {
+		return "", err
+	}
+	defer snmp.Conn.Close()
+
+	walkStart := time.Now()
+	pdus, err := s.walkModule(snmp, module)
+	if err != nil {
+		return "", err
+	}
+	walkDuration := time.Since(walkStart)
+
+	families := make(map[string]*promFamily)
+	var order []string
+	family := func(name, help, typ string) *promFamily {
+		if f, ok := families[name]; ok {
+			return f
+		}
+		f := &promFamily{name: name, help: help, typ: typ, seen: make(map[string]bool)}
+		families[name] = f
+		order = append(order, name)
+		return f
+	}
+
+	oids := make([]string, 0, len(pdus))
+	for oid := range pdus {
+		oids = append(oids, oid)
+	}
+	sort.Slice(oids, func(i, j int) bool { return compareOIDs(oids[i], oids[j]) < 0 })
+
+	for _, metric := range module.Metrics {
+		name := sanitizeMetricName(metric.Name)
+		help := metric.Help
+		if help == "" {
+			help = fmt.Sprintf("%s - %s", metric.Name, normalizeOID(metric.OID))
+		}
+
+		prefix := normalizeOID(metric.OID)
+		for _, oid := range oids {
+			var suffix []int
+			if oid != prefix {
+				if !strings.HasPrefix(oid, prefix+".") {
+					continue
+				}
+				if suffix, err = parseOIDSubids(oid[len(prefix)+1:]); err != nil {
+					continue
+				}
+			}
+
+			labels, ok := s.indexLabels(metric, suffix, pdus)
+			if !ok {
+				continue
+			}
+
+			addSNMPSample(family, metric, name, help, labels, pdus[oid])
+		}
+	}
+
+	moduleLabel := []promLabel{{name: "module", value: name}}
+	family("snmp_scrape_walk_duration_seconds", "Time SNMP walk/bulkwalk took.", "gauge").add(moduleLabel, walkDuration.Seconds())
+	family("snmp_scrape_pdus_returned", "PDUs returned from walk.", "gauge").add(moduleLabel, float64(len(pdus)))
+	family("snmp_scrape_duration_seconds", "Total SNMP time scrape took (walk and processing).", "gauge").add(moduleLabel, time.Since(start).Seconds())
+
+	var buf strings.Builder
+	for _, name := range order {
+		f := families[name]
+		if len(f.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", f.name, f.typ)
+		for _, sample := range f.samples {
+			buf.WriteString(sample)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// addSNMPSample 按指标类型把一个 PDU 转换为样本，规则与 snmp_exporter 一致
+func addSNMPSample(family func(name, help, typ string) *promFamily, metric SNMPMetric, name, help string, labels []promLabel, pdu gosnmp.SnmpPDU) {
+	if len(metric.RegexpExtracts) > 0 {
+		// 配置了 regex_extracts 时只输出提取出的数值，每个后缀取第一个能匹配并解析的正则
+		str := formatSNMPValue(pdu, metric.Type)
+		suffixes := make([]string, 0, len(metric.RegexpExtracts))
+		for suffix := range metric.RegexpExtracts {
+			suffixes = append(suffixes, suffix)
+		}
+		sort.Strings(suffixes)
+		for _, suffix := range suffixes {
+			for _, extract := range metric.RegexpExtracts[suffix] {
+				re, err := regexp.Compile("^(?:" + extract.Regex + ")$")
+				if err != nil {
+					continue
+				}
+				match := re.FindStringSubmatchIndex(str)
+				if match == nil {
+					continue
+				}
+				value, err := strconv.ParseFloat(string(re.ExpandString(nil, extract.Value, str, match)), 64)
+				if err != nil {
+					continue
+				}
+				family(sanitizeMetricName(name+suffix), help+" (regex extracted)", "gauge").add(labels, value)
+				break
+			}
+		}
+		return
+	}
+
+	value, numeric := snmpNumericValue(pdu)
+	withLabel := func(value string) []promLabel {
+		return setLabel(append([]promLabel{}, labels...), name, value)
+	}
+	enumState := func(n int) string {
+		if state, ok := metric.EnumValues[n]; ok {
+			return state
+		}
+		return strconv.Itoa(n)
+	}
+
+	switch metric.Type {
+	case "EnumAsInfo":
+		family(name+"_info", help+" (EnumAsInfo)", "gauge").add(withLabel(enumState(int(value))), 1)
+	case "EnumAsStateSet":
+		f := family(name, help+" (EnumAsStateSet)", "gauge")
+		if _, ok := metric.EnumValues[int(value)]; !ok {
+			f.add(withLabel(strconv.Itoa(int(value))), 1)
+		}
+		for _, n := range sortedEnumKeys(metric.EnumValues) {
+			state := 0.0
+			if n == int(value) {
+				state = 1
+			}
+			f.add(withLabel(metric.EnumValues[n]), state)
+		}
+	case "Bits":
+		b, _ := pdu.Value.([]byte)
+		f := family(name, help+" (Bits)", "gauge")
+		for _, n := range sortedEnumKeys(metric.EnumValues) {
+			bit := 0.0
+			if n >= 0 && n/8 < len(b) && b[n/8]&(128>>uint(n%8)) != 0 {
+				bit = 1
+			}
+			f.add(withLabel(metric.EnumValues[n]), bit)
+		}
+	case "DateAndTime":
+		b, _ := pdu.Value.([]byte)
+		if t, ok := parseDateAndTime(b); ok {
+			family(name, help, "gauge").add(labels, float64(t.Unix()))
+		}
+	default:
+		typ := "gauge"
+		if metric.Type == "counter" {
+			typ = "counter"
+		}
+		if !numeric || isStringMetricType(metric.Type) {
+			labels = withLabel(formatSNMPValue(pdu, metric.Type))
+			value = 1
+		}
+		family(name, help, typ).add(labels, value)
+	}
+}
+
+// findDevice 按 IP、主机名、名称或 ID 查找设备
+func (s *ScrapeService) findDevice(target string) *models.Device {
+	var device models.Device
+	query := s.db.Preload("Credentials")
+	if id, err := strconv.ParseUint(target, 10, 32); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("ip_address = ? OR hostname = ? OR name = ?", target, target, target)
+	}
+	if err := query.First(&device).Error; err != nil {
+		return nil
+	}
+	if device.TemplateID != nil {
+		// 绑定的模板按继承关系合并，解析失败时当作未绑定模板
+		device.Template, _ = resolveDeviceTemplate(s.db, *device.TemplateID)
+	}
+	return &device
+}
+
+// resolveModule 依次查找同名设备模板、已生成的 snmp_exporter 配置；未指定模块时使用设备绑定的模板。
+// 新格式配置的模块没有 auth，按 authName（默认 public_v2）从同一配置的 auths 中取认证信息
+func (s *ScrapeService) resolveModule(name, authName string, device *models.Device) (*SNMPModule, string, error) {
+	if name == "" {
+		if device == nil || device.Template == nil {
+			return nil, "", fmt.Errorf("%w: no module given and target has no device template", ErrScrapeModuleNotFound)
+		}
+		module, err := s.templateModule(device.Template)
+		return module, device.Template.Name, err
+	}
+
+	var tmpl models.DeviceTemplate
+	if err := s.db.Where("name = ?", name).First(&tmpl).Error; err == nil {
+		resolved, err := resolveDeviceTemplate(s.db, tmpl.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		module, err := s.templateModule(resolved)
+		return module, name, err
+	}
+
+	var configs []models.Config
+	if err := s.db.Where("type = ?", "snmp_exporter").Order("updated_at DESC").Find(&configs).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to load snmp_exporter configs: %v", err)
+	}
+	for _, config := range configs {
+		var exporterConfig SNMPExporterConfig
+		if err := yaml.Unmarshal([]byte(config.Content), &exporterConfig); err != nil {
+			continue
+		}
+		if module, ok := exporterConfig.Modules[name]; ok {
+			if module.Auth == nil {
+				if authName == "" {
+					authName = "public_v2"
+				}
+				if auth, ok := exporterConfig.Auths[authName]; ok {
+					module.Auth = &auth
+					module.Version = auth.Version
+				}
+			}
+			return &module, name, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("%w: %s", ErrScrapeModuleNotFound, name)
+}
+
+// templateModule 根据设备模板的 OID 构造采集模块，与生成 snmp_exporter 配置使用相同的规则
+func (s *ScrapeService) templateModule(tmpl *models.DeviceTemplate) (*SNMPModule, error) {
+	walk, metrics, err := newSNMPModuleBuilder(s.db).build(tmpl.OIDs, &snmpGeneratorOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if len(walk) == 0 {
+		return nil, fmt.Errorf("device template %s has no OIDs", tmpl.Name)
+	}
+	return &SNMPModule{Walk: walk, Metrics: metrics}, nil
+}
+
+// buildRequest 优先使用设备保存的凭据，否则使用模块中的 community
+func (s *ScrapeService) buildRequest(host string, port int, device *models.Device, module *SNMPModule) (*models.SNMPRequest, error) {
+	var req *models.SNMPRequest
+	switch {
+	case device != nil && len(device.Credentials) > 0:
+		req = newDeviceSNMPRequest(device, device.Credentials[0], "")
+	case module.Auth != nil && (module.Auth.Community != "" || module.Auth.Username != ""):
+		req = snmpAuthRequest(host, module.Auth, module.Version)
+	default:
+		return nil, fmt.Errorf("%w: %s has no SNMP credentials", ErrScrapeTargetNotFound, host)
+	}
+
+	if port > 0 {
+		req.Port = port
+	}
+	if module.Timeout != "" {
+		if d, err := time.ParseDuration(module.Timeout); err == nil && d >= time.Second {
+			req.Timeout = int(d / time.Second)
+		}
+	}
+	if module.Retries > 0 {
+		req.Retries = module.Retries
+	}
+	return req, nil
+}
+
+// snmpAuthRequest 把 snmp_exporter 的认证配置转换为 SNMP 请求，version 为 0 时按 v2c 处理
+func snmpAuthRequest(host string, auth *SNMPAuth, version int) *models.SNMPRequest {
+	req := &models.SNMPRequest{
+		Target:    host,
+		Version:   "v2c",
+		Community: auth.Community,
+		Timeout:   5,
+		Retries:   3,
+	}
+	switch version {
+	case 1:
+		req.Version = "v1"
+	case 3:
+		req.Version = "v3"
+		req.Community = ""
+		req.Username = auth.Username
+		req.ContextName = auth.ContextName
+		// 安全级别由是否提供密钥决定
+		if auth.SecurityLevel == "authNoPriv" || auth.SecurityLevel == "authPriv" {
+			req.AuthProto = auth.AuthProtocol
+			req.AuthKey = auth.Password
+		}
+		if auth.SecurityLevel == "authPriv" {
+			req.PrivProto = auth.PrivProtocol
+			req.PrivKey = auth.PrivPassword
+		}
+	}
+	return req
+}
+
+// walkModule 遍历模块的 walk 列表和 lookup 引用的 OID，标量 OID 使用 GET
+func (s *ScrapeService) walkModule(snmp *gosnmp.GoSNMP, module *SNMPModule) (map[string]gosnmp.SnmpPDU, error) {
+	roots := append([]string{}, module.Walk...)
+	for _, metric := range module.Metrics {
+		for _, lookup := range metric.Lookups {
+			roots = append(roots, lookup.OID)
+		}
+	}
+
+	pdus := make(map[string]gosnmp.SnmpPDU)
+	collect := func(pdu gosnmp.SnmpPDU) error {
+		if hasSNMPValue(pdu) {
+			pdus[normalizeOID(pdu.Name)] = pdu
+		}
+		return nil
+	}
+
+	for _, root := range uniqueRootOIDs(roots) {
+		if strings.HasSuffix(root, ".0") {
+			result, err := snmp.Get([]string{root})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get %s: %v", root, err)
+			}
+			for _, pdu := range result.Variables {
+				collect(pdu)
+			}
+			continue
+		}
+
+		walk := snmp.BulkWalk
+		if snmp.Version == gosnmp.Version1 {
+			walk = snmp.Walk
+		}
+		if err := walk(root, collect); err != nil {
+			return nil, fmt.Errorf("failed to walk %s: %v", root, err)
+		}
+	}
+
+	return pdus, nil
+}
+
+// indexLabels 按模块定义解析索引标签并执行 lookup；没有索引定义的表格列使用 index 标签
+func (s *ScrapeService) indexLabels(metric SNMPMetric, suffix []int, pdus map[string]gosnmp.SnmpPDU) ([]promLabel, bool) {
+	var labels []promLabel
+	if len(metric.Indexes) == 0 {
+		if len(suffix) > 0 && !(len(suffix) == 1 && suffix[0] == 0) {
+			labels = append(labels, promLabel{name: "index", value: joinSubids(suffix)})
+		}
+		return labels, true
+	}
+
+	raw := make(map[string][]int)
+	rest := suffix
+	for _, index := range metric.Indexes {
+		// 定长和 IMPLIED 的字符串索引没有长度前缀，补上后按普通字符串解析
+		subids, prefixed := rest, 0
+		if index.Type == "OctetString" || index.Type == "DisplayString" {
+			switch {
+			case index.FixedSize > 0:
+				subids, prefixed = append([]int{index.FixedSize}, rest...), 1
+			case index.Implied:
+				subids, prefixed = append([]int{len(rest)}, rest...), 1
+			}
+		}
+		value, used, remaining, ok := parseIndexValue(index.Type, subids)
+		if !ok {
+			return nil, false
+		}
+		raw[index.LabelName] = used[prefixed:]
+		labels = setLabel(labels, sanitizeLabelName(index.LabelName), value)
+		rest = remaining
+	}
+
+	for _, lookup := range metric.Lookups {
+		if lookup.OID == "" {
+			// 没有 oid 的 lookup 用于删除已被替换的索引标签
+			labels = deleteLabel(labels, sanitizeLabelName(lookup.LabelName))
+			continue
+		}
+		var subids []int
+		for _, label := range lookup.Labels {
+			subids = append(subids, raw[label]...)
+		}
+		oid := normalizeOID(lookup.OID)
+		if len(subids) > 0 {
+			oid += "." + joinSubids(subids)
+		}
+		if pdu, ok := pdus[oid]; ok {
+			labels = setLabel(labels, sanitizeLabelName(lookup.LabelName), formatSNMPValue(pdu, lookup.Type))
+		}
+	}
+
+	return labels, true
+}
+
+func (f *promFamily) add(labels []promLabel, value float64) {
+	var buf strings.Builder
+	buf.WriteString(f.name)
+	if len(labels) > 0 {
+		buf.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(&buf, "%s=\"%s\"", label.name, escapeLabelValue(label.value))
+		}
+		buf.WriteByte('}')
+	}
+	key := buf.String()
+	if f.seen[key] {
+		return
+	}
+	f.seen[key] = true
+	f.samples = append(f.samples, key+" "+strconv.FormatFloat(value, 'g', -1, 64)+"\n")
+}
+
+func deleteLabel(labels []promLabel, name string) []promLabel {
+	for i := range labels {
+		if labels[i].name == name {
+			return append(labels[:i], labels[i+1:]...)
+		}
+	}
+	return labels
+}
+
+func setLabel(labels []promLabel, name, value string) []promLabel {
+	for i := range labels {
+		if labels[i].name == name {
+			labels[i].value = value
+			return labels
+		}
+	}
+	return append(labels, promLabel{name: name, value: value})
+}
+
+// parseIndexValue 按 snmp_exporter 的索引类型从 OID 后缀中解析一个索引值
+func parseIndexValue(typ string, subids []int) (string, []int, []int, bool) {
+	take := func(n int) ([]int, []int, bool) {
+		if n < 0 || len(subids) < n {
+			return nil, nil, false
+		}
+		return subids[:n], subids[n:], true
+	}
+	bytesOf := func(ids []int) []byte {
+		b := make([]byte, len(ids))
+		for i, id := range ids {
+			b[i] = byte(id)
+		}
+		return b
+	}
+
+	switch typ {
+	case "PhysAddress48":
+		used, rest, ok := take(6)
+		if !ok {
+			return "", nil, nil, false
+		}
+		return formatMAC(bytesOf(used)), used, rest, true
+	case "IpAddr":
+		used, rest, ok := take(4)
+		if !ok {
+			return "", nil, nil, false
+		}
+		return net.IP(bytesOf(used)).String(), used, rest, true
+	case "InetAddressIPv4", "InetAddressIPv6":
+		size := net.IPv4len
+		if typ == "InetAddressIPv6" {
+			size = net.IPv6len
+		}
+		used, rest, ok := take(size)
+		if !ok {
+			return "", nil, nil, false
+		}
+		return net.IP(bytesOf(used)).String(), used, rest, true
+	case "OctetString", "DisplayString", "InetAddress":
+		if len(subids) == 0 {
+			return "", nil, nil, false
+		}
+		used, rest, ok := take(subids[0] + 1)
+		if !ok {
+			return "", nil, nil, false
+		}
+		b := bytesOf(used[1:])
+		switch typ {
+		case "DisplayString":
+			return string(b), used, rest, true
+		case "InetAddress":
+			if len(b) == net.IPv4len || len(b) == net.IPv6len {
+				return net.IP(b).String(), used, rest, true
+			}
+		}
+		return "0x" + strings.ToUpper(hex.EncodeToString(b)), used, rest, true
+	default:
+		used, rest, ok := take(1)
+		if !ok {
+			return "", nil, nil, false
+		}
+		return strconv.Itoa(used[0]), used, rest, true
+	}
+}
+
+// snmpNumericValue 数值类型的 PDU 返回其浮点值
+func snmpNumericValue(pdu gosnmp.SnmpPDU) (float64, bool) {
+	switch pdu.Type {
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Counter64, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Uinteger32:
+		f, _ := new(big.Float).SetInt(gosnmp.ToBigInt(pdu.Value)).Float64()
+		return f, true
+	case gosnmp.OpaqueFloat:
+		if v, ok := pdu.Value.(float32); ok {
+			return float64(v), true
+		}
+	case gosnmp.OpaqueDouble:
+		if v, ok := pdu.Value.(float64); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// formatSNMPValue 把 PDU 值格式化为标签值
+func formatSNMPValue(pdu gosnmp.SnmpPDU, typ string) string {
+	switch pdu.Type {
+	case gosnmp.OctetString:
+		b, _ := pdu.Value.([]byte)
+		switch typ {
+		case "PhysAddress48":
+			return formatMAC(b)
+		case "DisplayString":
+			return string(b)
+		case "OctetString":
+			return "0x" + strings.ToUpper(hex.EncodeToString(b))
+		case "InetAddress", "InetAddressIPv4", "InetAddressIPv6":
+			if len(b) == net.IPv4len || len(b) == net.IPv6len {
+				return net.IP(b).String()
+			}
+		}
+		if isPrintable(b) {
+			return string(b)
+		}
+		return "0x" + strings.ToUpper(hex.EncodeToString(b))
+	case gosnmp.ObjectIdentifier:
+		return normalizeOID(fmt.Sprint(pdu.Value))
+	case gosnmp.IPAddress:
+		return fmt.Sprint(pdu.Value)
+	}
+	if f, ok := snmpNumericValue(pdu); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(pdu.Value)
+}
+
+func isStringMetricType(typ string) bool {
+	switch typ {
+	case "DisplayString", "OctetString", "PhysAddress48", "IpAddr", "InetAddress", "InetAddressIPv4", "InetAddressIPv6":
+		return true
+	}
+	return false
+}
+
+func sortedEnumKeys(values map[int]string) []int {
+	keys := make([]int, 0, len(values))
+	for n := range values {
+		keys = append(keys, n)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// parseDateAndTime 解析 SNMPv2-TC 的 DateAndTime，8 字节时按 UTC 处理
+func parseDateAndTime(b []byte) (time.Time, bool) {
+	if len(b) != 8 && len(b) != 11 {
+		return time.Time{}, false
+	}
+	loc := time.UTC
+	if len(b) == 11 {
+		offset := (int(b[9])*60 + int(b[10])) * 60
+		if b[8] == '-' {
+			offset = -offset
+		}
+		loc = time.FixedZone("", offset)
+	}
+	year := int(b[0])<<8 | int(b[1])
+	t := time.Date(year, time.Month(b[2]), int(b[3]), int(b[4]), int(b[5]), int(b[6]), int(b[7])*100*int(time.Millisecond), loc)
+	return t, true
+}
+
+// uniqueRootOIDs 去重并去掉已被其他子树覆盖的 OID
+func uniqueRootOIDs(oids []string) []string {
+	var normalized []string
+	for _, oid := range oids {
+		if oid = normalizeOID(oid); oid != "" {
+			normalized = append(normalized, oid)
+		}
+	}
+	sort.Slice(normalized, func(i, j int) bool { return compareOIDs(normalized[i], normalized[j]) < 0 })
+
+	var roots []string
+	for _, oid := range normalized {
+		if len(roots) > 0 {
+			last := roots[len(roots)-1]
+			if oid == last || strings.HasPrefix(oid, last+".") {
+				continue
+			}
+		}
+		roots = append(roots, oid)
+	}
+	return roots
+}
+
+// compareOIDs 按子标识符数值比较两个 OID
+func compareOIDs(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA != nil || errB != nil {
+			if c := strings.Compare(pa[i], pb[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+func parseOIDSubids(oid string) ([]int, error) {
+	parts := strings.Split(oid, ".")
+	subids := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		subids[i] = n
+	}
+	return subids, nil
+}
+
+func joinSubids(subids []int) string {
+	parts := make([]string, len(subids))
+	for i, id := range subids {
+		parts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(parts, ".")
+}
+
+func formatMAC(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = fmt.Sprintf("%02X", c)
+	}
+	return strings.Join(parts, ":")
+}
+
+func sanitizeMetricName(name string) string {
+	return sanitizePromName(name, true)
+}
+
+func sanitizeLabelName(name string) string {
+	return sanitizePromName(name, false)
+}
+
+func sanitizePromName(name string, allowColon bool) string {
+	var buf strings.Builder
+	for i, r := range name {
+		valid := r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(i > 0 && r >= '0' && r <= '9') || (allowColon && r == ':')
+		if !valid {
+			if i == 0 && r >= '0' && r <= '9' {
+				buf.WriteByte('_')
+				buf.WriteRune(r)
+				continue
+			}
+			r = '_'
+		}
+		buf.WriteRune(r)
+	}
+	if buf.Len() == 0 {
+		return "_"
+	}
+	return buf.String()
+}
+
+func escapeHelp(help string) string {
+	help = strings.ReplaceAll(help, `\`, `\\`)
+	return strings.ReplaceAll(help, "\n", `\n`)
+}
+
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
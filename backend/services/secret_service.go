@@ -0,0 +1,302 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+var ErrSecretRotationRunning = errors.New("secret rotation is already running")
+
+// secretColumn 加密存储的字段；jsonFields 不为空时该列是 JSON，只加密其中的同名字段
+type secretColumn struct {
+	table      string
+	column     string
+	jsonFields map[string]bool
+}
+
+// secretColumns 所有加密存储的字段，新增 serializer:secret 字段时需要同步加到这里才会参与轮换
+var secretColumns = []secretColumn{
+	{table: "snmp_credentials", column: "community"},
+	{table: "snmp_credentials", column: "auth_key"},
+	{table: "snmp_credentials", column: "priv_key"},
+	{table: "snmp_credential_profiles", column: "community"},
+	{table: "snmp_credential_profiles", column: "auth_key"},
+	{table: "snmp_credential_profiles", column: "priv_key"},
+	{table: "snmp_discovery_tasks", column: "credentials"},
+	{table: "hosts", column: "password"},
+	{table: "hosts", column: "private_key"},
+	{table: "host_credentials", column: "password"},
+	{table: "host_credentials", column: "private_key"},
+	{table: "host_credentials", column: "passphrase"},
+	{table: "host_discovery_tasks", column: "password"},
+	{table: "host_discovery_tasks", column: "private_key"},
+	{table: "alertmanager_configs", column: "global", jsonFields: alertmanagerSecretFields},
+	{table: "alertmanager_configs", column: "receivers", jsonFields: alertmanagerSecretFields},
+}
+
+// SecretService 查看密文使用的密钥并把全部密文轮换到当前主密钥。
+// 轮换步骤：把新密钥设为 SECRET_KEY、旧密钥加入 SECRET_KEYS_OLD 后逐台重启，
+// 此时新旧密文都能解密，再调用 Rotate 重新加密，完成后即可从 SECRET_KEYS_OLD 中移除旧密钥
+type SecretService struct {
+	db    *gorm.DB
+	redis *redis.Client
+	mu    sync.Mutex
+}
+
+func NewSecretService(db *gorm.DB, redis *redis.Client) *SecretService {
+	return &SecretService{
+		db:    db,
+		redis: redis,
+	}
+}
+
+type secretRow struct {
+	id    interface{}
+	value string
+}
+
+// GetStatus 统计每个敏感字段的密文分别由哪个密钥加密
+func (s *SecretService) GetStatus() (*models.SecretKeyStatus, error) {
+	current := utils.CurrentSecretKeyID()
+	status := &models.SecretKeyStatus{
+		CurrentKeyID: current,
+		KeyIDs:       utils.SecretKeyIDs(),
+		Columns:      []models.SecretColumnStatus{},
+	}
+
+	for _, col := range secretColumns {
+		if !s.db.Migrator().HasTable(col.table) {
+			continue
+		}
+		rows, err := s.loadSecretRows(col)
+		if err != nil {
+			return nil, err
+		}
+
+		columnStatus := models.SecretColumnStatus{Table: col.table, Column: col.column, ByKey: map[string]int{}}
+		for _, row := range rows {
+			for _, value := range col.secrets(row.value) {
+				columnStatus.Total++
+				id := utils.SecretKeyID(value)
+				if id == "" {
+					columnStatus.Plaintext++
+				} else {
+					columnStatus.ByKey[id]++
+				}
+				if id != current {
+					columnStatus.Stale++
+				}
+			}
+		}
+		status.Stale += columnStatus.Stale
+		status.Columns = append(status.Columns, columnStatus)
+	}
+	return status, nil
+}
+
+// Rotate 把不是用当前主密钥加密的值（包括明文和旧版格式）逐行重新加密。
+// 每行按原值做条件更新，轮换期间被其他请求改写的行会跳过，不会覆盖新数据
+func (s *SecretService) Rotate() (*models.SecretRotationResult, error) {
+	if !s.mu.TryLock() {
+		return nil, ErrSecretRotationRunning
+	}
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	current := utils.CurrentSecretKeyID()
+	if current == "" {
+		return nil, utils.ErrSecretKeyNotConfigured
+	}
+	result := &models.SecretRotationResult{KeyID: current}
+
+	for _, col := range secretColumns {
+		if !s.db.Migrator().HasTable(col.table) {
+			continue
+		}
+		rows, err := s.loadSecretRows(col)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			result.Scanned++
+			rotated, changed, err := col.rotate(row.value, current)
+			if err != nil {
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("%s.%s id=%v: %v", col.table, col.column, row.id, err))
+				continue
+			}
+			if !changed {
+				continue
+			}
+
+			update := s.db.Table(col.table).
+				Where("id = ? AND CAST("+col.column+" AS text) = ?", row.id, row.value).
+				Update(col.column, rotated)
+			switch {
+			case update.Error != nil:
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("%s.%s id=%v: %v", col.table, col.column, row.id, update.Error))
+			case update.RowsAffected == 0:
+				result.Skipped++
+			default:
+				result.Rotated++
+			}
+		}
+	}
+
+	result.Duration = time.Since(start).String()
+	result.FinishedAt = time.Now()
+	log.Printf("secret rotation to key %s finished: %d rotated, %d skipped, %d failed",
+		current, result.Rotated, result.Skipped, result.Failed)
+	return result, nil
+}
+
+// loadSecretRows 读取原始密文，包括已软删除的行
+func (s *SecretService) loadSecretRows(col secretColumn) ([]secretRow, error) {
+	rows, err := s.db.Table(col.table).
+		Select("id, CAST(" + col.column + " AS text)").
+		Where(col.column + " IS NOT NULL").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s.%s: %v", col.table, col.column, err)
+	}
+	defer rows.Close()
+
+	var result []secretRow
+	for rows.Next() {
+		var row secretRow
+		if err := rows.Scan(&row.id, &row.value); err != nil {
+			return nil, fmt.Errorf("failed to read %s.%s: %v", col.table, col.column, err)
+		}
+		if row.value != "" {
+			result = append(result, row)
+		}
+	}
+	return result, rows.Err()
+}
+
+// secrets 返回列值中的全部密文
+func (c secretColumn) secrets(value string) []string {
+	if c.jsonFields == nil {
+		return []string{value}
+	}
+	var values []string
+	_, _ = transformSecretJSON(models.JSON(value), nil, c.jsonFields, func(v, _ string) (string, error) {
+		values = append(values, v)
+		return v, nil
+	})
+	return values
+}
+
+// rotate 用当前主密钥重新加密列值，已经是当前密钥的密文保持不变
+func (c secretColumn) rotate(value, current string) (string, bool, error) {
+	changed := false
+	reencrypt := func(v, _ string) (string, error) {
+		if utils.SecretKeyID(v) == current {
+			return v, nil
+		}
+		plaintext, err := utils.DecryptSecret(v)
+		if err != nil {
+			return "", err
+		}
+		changed = true
+		return utils.EncryptSecret(plaintext)
+	}
+
+	if c.jsonFields == nil {
+		rotated, err := reencrypt(value, "")
+		return rotated, changed, err
+	}
+	rotated, err := transformSecretJSON(models.JSON(value), nil, c.jsonFields, reencrypt)
+	return string(rotated), changed, err
+}
+
+// transformSecretJSON 遍历 JSON，把 fields 中字段的非空字符串值交给 fn 处理；
+// prev 为修改前的 JSON，fn 会收到同一位置的旧值，用于还原接口提交回来的掩码
+func transformSecretJSON(raw, prev models.JSON, fields map[string]bool, fn func(value, prev string) (string, error)) (models.JSON, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+	var node, prevNode interface{}
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return nil, err
+	}
+	if len(prev) > 0 {
+		_ = json.Unmarshal(prev, &prevNode)
+	}
+
+	node, err := transformSecretNode(node, prevNode, fields, fn)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	return models.JSON(data), nil
+}
+
+func transformSecretNode(node, prev interface{}, fields map[string]bool, fn func(value, prev string) (string, error)) (interface{}, error) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		prevMap, _ := prev.(map[string]interface{})
+		for key, child := range v {
+			if value, ok := child.(string); ok && fields[key] {
+				if value == "" {
+					continue
+				}
+				prevValue, _ := prevMap[key].(string)
+				transformed, err := fn(value, prevValue)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", key, err)
+				}
+				v[key] = transformed
+				continue
+			}
+			transformed, err := transformSecretNode(child, prevMap[key], fields, fn)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = transformed
+		}
+	case []interface{}:
+		prevList, _ := prev.([]interface{})
+		for i, child := range v {
+			transformed, err := transformSecretNode(child, matchPrevItem(prevList, i, child), fields, fn)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = transformed
+		}
+	}
+	return node, nil
+}
+
+// matchPrevItem 列表元素优先按 name 对应（receivers 可能调整顺序），没有 name 时按下标对应
+func matchPrevItem(prevList []interface{}, i int, item interface{}) interface{} {
+	if m, ok := item.(map[string]interface{}); ok {
+		if name, ok := m["name"].(string); ok && name != "" {
+			for _, prev := range prevList {
+				if pm, ok := prev.(map[string]interface{}); ok && pm["name"] == name {
+					return prev
+				}
+			}
+			return nil
+		}
+	}
+	if i < len(prevList) {
+		return prevList[i]
+	}
+	return nil
+}
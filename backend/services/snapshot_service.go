@@ -0,0 +1,325 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gosnmp/gosnmp"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+)
+
+// 未指定根 OID 时遍历整棵 internet 子树
+const defaultSnapshotRootOID = "1.3.6.1"
+
+// ErrSnapshotMismatch 只能比较同一设备、同一根 OID 的两个快照
+var ErrSnapshotMismatch = errors.New("snapshots are not comparable")
+
+type SnapshotService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	snmpService *SNMPService
+}
+
+func NewSnapshotService(db *gorm.DB, redis *redis.Client) *SnapshotService {
+	return &SnapshotService{
+		db:          db,
+		redis:       redis,
+		snmpService: NewSNMPService(db, redis),
+	}
+}
+
+// CreateSnapshot 遍历设备的完整 MIB 树或指定子树并保存压缩快照
+func (s *SnapshotService) CreateSnapshot(deviceID uint, req *models.CreateSnapshotRequest) (*models.SNMPSnapshot, error) {
+	device, err := NewDeviceService(s.db, s.redis).GetDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(device.Credentials) == 0 {
+		return nil, fmt.Errorf("device %s has no SNMP credentials", device.Name)
+	}
+
+	root := normalizeOID(req.RootOID)
+	if root == "" {
+		root = defaultSnapshotRootOID
+	}
+
+	snmp, err := s.snmpService.createSNMPConnection(newDeviceSNMPRequest(device, device.Credentials[0], root))
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+
+	start := time.Now()
+	var entries []models.SNMPSnapshotEntry
+	walk := snmp.BulkWalk
+	if snmp.Version == gosnmp.Version1 {
+		walk = snmp.Walk
+	}
+	err = walk(root, func(pdu gosnmp.SnmpPDU) error {
+		if !hasSNMPValue(pdu) {
+			return nil
+		}
+		valueType, value := encodeSetValue(pdu)
+		if valueType == "" {
+			valueType, value = strings.ToLower(pdu.Type.String()), fmt.Sprint(pdu.Value)
+		}
+		entries = append(entries, models.SNMPSnapshotEntry{
+			OID:   normalizeOID(pdu.Name),
+			Type:  valueType,
+			Value: value,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %v", root, err)
+	}
+
+	raw, err := json.Marshal(entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %v", err)
+	}
+
+	name := req.Name
+	if name == "" {
+		name = fmt.Sprintf("%s %s", device.Name, start.Format("2006-01-02 15:04:05"))
+	}
+
+	snapshot := &models.SNMPSnapshot{
+		DeviceID:   device.ID,
+		Name:       name,
+		RootOID:    root,
+		OIDCount:   len(entries),
+		RawSize:    len(raw),
+		Size:       buf.Len(),
+		Duration:   time.Since(start).Milliseconds(),
+		Data:       buf.Bytes(),
+		CreatedBy:  req.CreatedBy,
+		CapturedAt: start,
+	}
+	if err := s.db.Create(snapshot).Error; err != nil {
+		return nil, fmt.Errorf("failed to save snapshot: %v", err)
+	}
+
+	return snapshot, nil
+}
+
+func (s *SnapshotService) GetSnapshots(deviceID uint, page, limit int) ([]models.SNMPSnapshot, int64, error) {
+	var snapshots []models.SNMPSnapshot
+	var total int64
+
+	query := s.db.Model(&models.SNMPSnapshot{}).Where("device_id = ?", deviceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Omit("data").Order("captured_at DESC").Offset(offset).Limit(limit).Find(&snapshots).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return snapshots, total, nil
+}
+
+func (s *SnapshotService) GetSnapshot(id uint) (*models.SNMPSnapshot, error) {
+	var snapshot models.SNMPSnapshot
+	if err := s.db.Preload("Device").First(&snapshot, id).Error; err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// GetSnapshotEntries 解压快照内容，可按 OID 前缀过滤
+func (s *SnapshotService) GetSnapshotEntries(snapshot *models.SNMPSnapshot, prefix string) ([]models.SNMPSnapshotEntry, error) {
+	entries, err := decodeSnapshot(snapshot.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix = normalizeOID(prefix)
+	if prefix == "" {
+		return entries, nil
+	}
+	var filtered []models.SNMPSnapshotEntry
+	for _, entry := range entries {
+		if entry.OID == prefix || strings.HasPrefix(entry.OID, prefix+".") {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *SnapshotService) DeleteSnapshot(id uint) error {
+	return s.db.Delete(&models.SNMPSnapshot{}, id).Error
+}
+
+// DiffSnapshots 比较两个快照，计数器和 TimeTicks 等易变对象的值变化默认忽略
+func (s *SnapshotService) DiffSnapshots(fromID, toID uint, includeVolatile bool) (*models.SNMPSnapshotDiff, error) {
+	from, err := s.GetSnapshot(fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.GetSnapshot(toID)
+	if err != nil {
+		return nil, err
+	}
+	if from.DeviceID != to.DeviceID {
+		return nil, fmt.Errorf("%w: snapshot %d belongs to device %d, snapshot %d to device %d", ErrSnapshotMismatch, from.ID, from.DeviceID, to.ID, to.DeviceID)
+	}
+	if from.RootOID != to.RootOID {
+		return nil, fmt.Errorf("%w: snapshot %d walks %s, snapshot %d walks %s", ErrSnapshotMismatch, from.ID, from.RootOID, to.ID, to.RootOID)
+	}
+
+	oldEntries, err := decodeSnapshot(from.Data)
+	if err != nil {
+		return nil, err
+	}
+	newEntries, err := decodeSnapshot(to.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := s.loadMIBObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &models.SNMPSnapshotDiff{
+		From:    from,
+		To:      to,
+		Added:   []models.SNMPSnapshotDiffEntry{},
+		Removed: []models.SNMPSnapshotDiffEntry{},
+		Changed: []models.SNMPSnapshotDiffEntry{},
+	}
+
+	oldByOID := make(map[string]models.SNMPSnapshotEntry, len(oldEntries))
+	for _, entry := range oldEntries {
+		oldByOID[entry.OID] = entry
+	}
+
+	for _, entry := range newEntries {
+		object := objects.find(entry.OID)
+		old, ok := oldByOID[entry.OID]
+		if !ok {
+			diff.Added = append(diff.Added, models.SNMPSnapshotDiffEntry{
+				OID:      entry.OID,
+				Name:     object.name,
+				Type:     entry.Type,
+				NewValue: entry.Value,
+			})
+			continue
+		}
+		delete(oldByOID, entry.OID)
+
+		if old.Type == entry.Type && old.Value == entry.Value {
+			diff.Unchanged++
+			continue
+		}
+		if !includeVolatile && (isVolatileSNMPType(entry.Type) || object.volatile) {
+			diff.VolatileSkipped++
+			continue
+		}
+		diff.Changed = append(diff.Changed, models.SNMPSnapshotDiffEntry{
+			OID:      entry.OID,
+			Name:     object.name,
+			Type:     entry.Type,
+			OldValue: old.Value,
+			NewValue: entry.Value,
+		})
+	}
+
+	for _, entry := range oldByOID {
+		diff.Removed = append(diff.Removed, models.SNMPSnapshotDiffEntry{
+			OID:      entry.OID,
+			Name:     objects.find(entry.OID).name,
+			Type:     entry.Type,
+			OldValue: entry.Value,
+		})
+	}
+	sort.Slice(diff.Removed, func(i, j int) bool { return compareOIDs(diff.Removed[i].OID, diff.Removed[j].OID) < 0 })
+
+	return diff, nil
+}
+
+type snapshotMIBObject struct {
+	name     string
+	volatile bool
+}
+
+type snapshotMIBIndex map[string]snapshotMIBObject
+
+// loadMIBObjects 加载 MIB 对象的名称和类型，用于标注差异和识别易变对象
+func (s *SnapshotService) loadMIBObjects() (snapshotMIBIndex, error) {
+	var objects []models.OID
+	if err := s.db.Select("oid", "name", "type", "syntax").Find(&objects).Error; err != nil {
+		return nil, fmt.Errorf("failed to load MIB objects: %v", err)
+	}
+
+	index := make(snapshotMIBIndex, len(objects))
+	for _, object := range objects {
+		syntax := strings.ToLower(object.Type + " " + object.Syntax)
+		index[normalizeOID(object.OID)] = snapshotMIBObject{
+			name:     object.Name,
+			volatile: strings.Contains(syntax, "counter") || strings.Contains(syntax, "timeticks") || strings.Contains(syntax, "timestamp"),
+		}
+	}
+	return index, nil
+}
+
+// find 按最长前缀匹配 OID 实例对应的 MIB 对象
+func (idx snapshotMIBIndex) find(oid string) snapshotMIBObject {
+	for {
+		if object, ok := idx[oid]; ok {
+			return object
+		}
+		i := strings.LastIndex(oid, ".")
+		if i < 0 {
+			return snapshotMIBObject{}
+		}
+		oid = oid[:i]
+	}
+}
+
+func isVolatileSNMPType(valueType string) bool {
+	switch valueType {
+	case "counter32", "counter64", "timeticks":
+		return true
+	}
+	return false
+}
+
+func decodeSnapshot(data []byte) ([]models.SNMPSnapshotEntry, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %v", err)
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress snapshot: %v", err)
+	}
+
+	var entries []models.SNMPSnapshotEntry
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %v", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,513 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/gosnmp/gosnmp"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+)
+
+// system 组中发现需要的对象
+const (
+	oidSysDescr    = "1.3.6.1.2.1.1.1.0"
+	oidSysObjectID = "1.3.6.1.2.1.1.2.0"
+	oidSysContact  = "1.3.6.1.2.1.1.4.0"
+	oidSysName     = "1.3.6.1.2.1.1.5.0"
+	oidSysLocation = "1.3.6.1.2.1.1.6.0"
+)
+
+type SNMPDiscoveryService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	snmpService *SNMPService
+}
+
+func NewSNMPDiscoveryService(db *gorm.DB, redis *redis.Client) *SNMPDiscoveryService {
+	return &SNMPDiscoveryService{
+		db:          db,
+		redis:       redis,
+		snmpService: NewSNMPService(db, redis),
+	}
+}
+
+// snmpSystemInfo 代理 system 组信息
+type snmpSystemInfo struct {
+	SysDescr    string
+	SysObjectID string
+	SysName     string
+	SysContact  string
+	SysLocation string
+}
+
+func (s *SNMPDiscoveryService) CreateTask(req *models.CreateSNMPDiscoveryRequest) (*models.SNMPDiscoveryTask, error) {
+	transport, err := normalizeSNMPTransport(req.Transport)
+	if err != nil {
+		return nil, err
+	}
+	// 提前校验地址范围，避免任务启动后才失败
+	if _, err := parseIPRange(req.IPRange); err != nil {
+		return nil, err
+	}
+
+	credentials, err := json.Marshal(req.Credentials)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode credentials: %v", err)
+	}
+
+	task := &models.SNMPDiscoveryTask{
+		Name:          req.Name,
+		IPRange:       req.IPRange,
+		Port:          req.Port,
+		Transport:     transport,
+		Credentials:   string(credentials),
+		TimeoutMs:     req.TimeoutMs,
+		Retries:       req.Retries,
+		Concurrency:   req.Concurrency,
+		CreateDevices: req.CreateDevices,
+		Status:        "pending",
+	}
+	if task.Name == "" {
+		task.Name = "SNMP discovery " + req.IPRange
+	}
+	if task.Port == 0 {
+		task.Port = 161
+	}
+	if task.TimeoutMs <= 0 {
+		task.TimeoutMs = 1000
+	}
+	if task.Concurrency <= 0 {
+		task.Concurrency = 50
+	}
+
+	if err := s.db.Create(task).Error; err != nil {
+		return nil, err
+	}
+
+	if req.Start {
+		if err := s.StartTask(task.ID); err != nil {
+			return nil, err
+		}
+		task.Status = "running"
+	}
+
+	return task, nil
+}
+
+func (s *SNMPDiscoveryService) GetTasks(page, limit int) ([]models.SNMPDiscoveryTask, int64, error) {
+	var tasks []models.SNMPDiscoveryTask
+	var total int64
+
+	query := s.db.Model(&models.SNMPDiscoveryTask{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return tasks, total, nil
+}
+
+func (s *SNMPDiscoveryService) GetTask(id uint) (*models.SNMPDiscoveryTask, error) {
+	var task models.SNMPDiscoveryTask
+	if err := s.db.First(&task, id).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// GetTaskResults 获取任务的逐 IP 结果，responded 为 true 时只返回有响应的地址
+func (s *SNMPDiscoveryService) GetTaskResults(taskID uint, page, limit int, respondedOnly bool) ([]models.SNMPDiscoveryResult, int64, error) {
+	var results []models.SNMPDiscoveryResult
+	var total int64
+
+	query := s.db.Model(&models.SNMPDiscoveryResult{}).Where("task_id = ?", taskID)
+	if respondedOnly {
+		query = query.Where("responded = ?", true)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("id").Offset(offset).Limit(limit).Find(&results).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
+
+func (s *SNMPDiscoveryService) StartTask(id uint) error {
+	task, err := s.GetTask(id)
+	if err != nil {
+		return err
+	}
+	if task.Status == "running" {
+		return fmt.Errorf("discovery task %d is already running", id)
+	}
+
+	var candidates []models.SNMPProbeCandidate
+	if err := json.Unmarshal([]byte(task.Credentials), &candidates); err != nil || len(candidates) == 0 {
+		return fmt.Errorf("discovery task %d has no usable credentials", id)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":          "running",
+		"progress":        0,
+		"error":           "",
+		"scanned_hosts":   0,
+		"responded_hosts": 0,
+		"created_devices": 0,
+		"updated_devices": 0,
+		"started_at":      &now,
+		"completed_at":    nil,
+	}
+	if err := s.db.Model(task).Updates(updates).Error; err != nil {
+		return err
+	}
+	// 重新执行时清除上一次的结果
+	s.db.Where("task_id = ?", task.ID).Delete(&models.SNMPDiscoveryResult{})
+
+	// 异步执行发现任务
+	go s.executeTask(task, candidates)
+
+	return nil
+}
+
+func (s *SNMPDiscoveryService) executeTask(task *models.SNMPDiscoveryTask, candidates []models.SNMPProbeCandidate) {
+	ips, err := parseIPRange(task.IPRange)
+	if err != nil {
+		s.finishTask(task, "failed", err.Error())
+		return
+	}
+	s.db.Model(task).Update("total_hosts", len(ips))
+
+	// 指纹规则在任务开始时加载一次，所有地址共用
+	classifier, err := NewFingerprintService(s.db, s.redis).loadClassifier()
+	if err != nil {
+		s.finishTask(task, "failed", err.Error())
+		return
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, task.Concurrency) // 限制并发数
+	var mu sync.Mutex
+	scanned, responded, created, updated := 0, 0, 0, 0
+	lastUpdate := time.Now()
+
+	for _, ip := range ips {
+		wg.Add(1)
+		go func(ip string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := s.scanAddress(task, ip, candidates, classifier)
+			if err := s.db.Create(result).Error; err != nil {
+				log.Printf("failed to save discovery result for %s: %v", ip, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			scanned++
+			if result.Responded {
+				responded++
+			}
+			switch result.Action {
+			case "created":
+				created++
+			case "updated":
+				updated++
+			}
+			// 进度最多每秒写一次库，大网段扫描时避免频繁更新
+			if time.Since(lastUpdate) >= time.Second || scanned == len(ips) {
+				lastUpdate = time.Now()
+				s.db.Model(task).Updates(map[string]interface{}{
+					"progress":        scanned * 100 / len(ips),
+					"scanned_hosts":   scanned,
+					"responded_hosts": responded,
+					"created_devices": created,
+					"updated_devices": updated,
+				})
+			}
+		}(ip)
+	}
+
+	wg.Wait()
+	s.finishTask(task, "completed", "")
+}
+
+func (s *SNMPDiscoveryService) finishTask(task *models.SNMPDiscoveryTask, status, message string) {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":       status,
+		"error":        message,
+		"completed_at": &now,
+	}
+	if status == "completed" {
+		updates["progress"] = 100
+	}
+	s.db.Model(task).Updates(updates)
+}
+
+// scanAddress 依次尝试候选凭据，首个有响应的凭据用于读取 system 组并登记设备
+func (s *SNMPDiscoveryService) scanAddress(task *models.SNMPDiscoveryTask, ip string, candidates []models.SNMPProbeCandidate, classifier *fingerprintClassifier) *models.SNMPDiscoveryResult {
+	start := time.Now()
+	result := &models.SNMPDiscoveryResult{
+		TaskID: task.ID,
+		IP:     ip,
+		Action: "none",
+	}
+	defer func() { result.Duration = time.Since(start).Milliseconds() }()
+
+	var lastErr error
+	for _, i := range probeOrder(candidates) {
+		candidate := candidates[i]
+		for _, version := range probeVersions(candidate) {
+			req := &models.SNMPRequest{
+				Target:    ip,
+				Port:      task.Port,
+				Transport: task.Transport,
+				Version:   version,
+				Community: candidate.Community,
+				Username:  candidate.Username,
+				AuthProto: candidate.AuthProto,
+				AuthKey:   candidate.AuthKey,
+				PrivProto: candidate.PrivProto,
+				PrivKey:   candidate.PrivKey,
+			}
+			info, err := s.snmpService.querySystemInfo(req, time.Duration(task.TimeoutMs)*time.Millisecond, task.Retries)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			result.Responded = true
+			result.Version = version
+			result.CredentialName = candidate.Name
+			result.SysDescr = info.SysDescr
+			result.SysObjectID = info.SysObjectID
+			result.SysName = info.SysName
+			result.SysLocation = info.SysLocation
+			result.SysContact = info.SysContact
+
+			match := classifier.classify(info.SysObjectID, info.SysDescr)
+			result.Vendor = match.Vendor
+			result.Model = match.Model
+			result.OSFamily = match.OSFamily
+			result.DeviceType = match.DeviceType
+			result.TemplateID = match.TemplateID
+
+			candidate.Version = version
+			if err := s.recordDevice(task, result, candidate); err != nil {
+				result.Error = err.Error()
+			}
+			return result
+		}
+	}
+
+	if lastErr != nil {
+		result.Error = lastErr.Error()
+	}
+	return result
+}
+
+// querySystemInfo 读取 system 组的描述、对象 ID、名称、位置和联系人
+func (s *SNMPService) querySystemInfo(req *models.SNMPRequest, timeout time.Duration, retries int) (*snmpSystemInfo, error) {
+	snmp, err := s.createSNMPConnection(req)
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+	snmp.Timeout = timeout
+	snmp.Retries = retries
+
+	packet, err := snmp.Get([]string{oidSysDescr, oidSysObjectID, oidSysContact, oidSysName, oidSysLocation})
+	if err != nil {
+		return nil, err
+	}
+	if packet.Error != gosnmp.NoError {
+		return nil, fmt.Errorf("SNMP error: %s", packet.Error)
+	}
+
+	info := &snmpSystemInfo{}
+	for _, pdu := range packet.Variables {
+		if !hasSNMPValue(pdu) {
+			continue
+		}
+		value := ""
+		if b, ok := pdu.Value.([]byte); ok {
+			value = strings.TrimSpace(string(b))
+		}
+		switch normalizeOID(pdu.Name) {
+		case oidSysDescr:
+			info.SysDescr = value
+		case oidSysObjectID:
+			info.SysObjectID = normalizeOID(fmt.Sprint(pdu.Value))
+		case oidSysContact:
+			info.SysContact = value
+		case oidSysName:
+			info.SysName = value
+		case oidSysLocation:
+			info.SysLocation = value
+		}
+	}
+	if info.SysObjectID == "" && info.SysDescr == "" {
+		return nil, fmt.Errorf("agent returned no system information")
+	}
+	return info, nil
+}
+
+// recordDevice 按 IP 更新已有设备；设备不存在时按任务配置创建设备，并同步 DiscoveredDevice
+func (s *SNMPDiscoveryService) recordDevice(task *models.SNMPDiscoveryTask, result *models.SNMPDiscoveryResult, candidate models.SNMPProbeCandidate) error {
+	now := time.Now()
+
+	var device models.Device
+	err := s.db.Preload("Credentials").Where("ip_address = ?", result.IP).First(&device).Error
+	switch {
+	case err == nil:
+		// 与 ClassifyDevice(force=false) 一致，只补全空字段，不覆盖手工修改的内容
+		updates := map[string]interface{}{}
+		fill := func(column, current, value string) {
+			if current == "" && value != "" {
+				updates[column] = value
+			}
+		}
+		fill("vendor", device.Vendor, result.Vendor)
+		fill("model", device.Model, result.Model)
+		fill("os_family", device.OSFamily, result.OSFamily)
+		fill("hostname", device.Hostname, result.SysName)
+		fill("location", device.Location, result.SysLocation)
+		fill("contact", device.Contact, result.SysContact)
+		fill("type", device.Type, result.DeviceType)
+		fill("description", device.Description, result.SysDescr)
+		if device.TemplateID == nil && result.TemplateID != nil {
+			updates["template_id"] = *result.TemplateID
+		}
+		if len(updates) > 0 {
+			if err := s.db.Model(&device).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update device: %v", err)
+			}
+		}
+		if _, err := recordDeviceStatus(s.db, device.ID, "online", "responded to SNMP discovery", "discovery"); err != nil {
+			return err
+		}
+		if len(device.Credentials) == 0 {
+			if err := s.db.Create(discoveredCredential(device.ID, candidate)).Error; err != nil {
+				return fmt.Errorf("failed to save credential: %v", err)
+			}
+		}
+		result.DeviceID = &device.ID
+		result.Action = "updated"
+	case errors.Is(err, gorm.ErrRecordNotFound) && task.CreateDevices:
+		device = models.Device{
+			Name:        result.SysName,
+			Hostname:    result.SysName,
+			IPAddress:   result.IP,
+			Port:        task.Port,
+			Transport:   task.Transport,
+			Type:        result.DeviceType,
+			Vendor:      result.Vendor,
+			Model:       result.Model,
+			OSFamily:    result.OSFamily,
+			TemplateID:  result.TemplateID,
+			Location:    result.SysLocation,
+			Contact:     result.SysContact,
+			Description: result.SysDescr,
+			Status:      "online",
+			LastSeen:    &now,
+		}
+		if device.Name == "" {
+			device.Name = result.IP
+		}
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&device).Error; err != nil {
+				return err
+			}
+			return tx.Create(discoveredCredential(device.ID, candidate)).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create device: %v", err)
+		}
+		result.DeviceID = &device.ID
+		result.Action = "created"
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		result.Action = "discovered"
+	default:
+		return fmt.Errorf("failed to look up device: %v", err)
+	}
+
+	if result.DeviceID != nil {
+		NewDeviceService(s.db, s.redis).syncGroups(*result.DeviceID)
+	}
+	return s.saveDiscoveredDevice(task, result)
+}
+
+func (s *SNMPDiscoveryService) saveDiscoveredDevice(task *models.SNMPDiscoveryTask, result *models.SNMPDiscoveryResult) error {
+	instance := snmpInstance(result.IP, task.Port)
+
+	var discovered models.DiscoveredDevice
+	err := s.db.Where("instance = ?", instance).First(&discovered).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to look up discovered device: %v", err)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		discovered = models.DiscoveredDevice{
+			ID:       uuid.New().String(),
+			Instance: instance,
+			Job:      "snmp",
+		}
+	}
+
+	// 设备有应答，之前被 DiscoverDevices 标记为 offline 的记录也恢复为 discovered
+	discovered.Status = "discovered"
+	discovered.DeviceType = result.DeviceType
+	discovered.Vendor = result.Vendor
+	discovered.Model = result.Model
+	discovered.SysName = result.SysName
+	discovered.SysDescr = result.SysDescr
+	discovered.Location = result.SysLocation
+	discovered.LastSeen = time.Now()
+
+	if err := s.db.Save(&discovered).Error; err != nil {
+		return fmt.Errorf("failed to save discovered device: %v", err)
+	}
+	return nil
+}
+
+func discoveredCredential(deviceID uint, candidate models.SNMPProbeCandidate) *models.SNMPCredential {
+	return &models.SNMPCredential{
+		DeviceID:  deviceID,
+		Version:   candidate.Version,
+		Community: candidate.Community,
+		Username:  candidate.Username,
+		AuthProto: candidate.AuthProto,
+		AuthKey:   candidate.AuthKey,
+		PrivProto: candidate.PrivProto,
+		PrivKey:   candidate.PrivKey,
+	}
+}
+
+// snmpInstance 与 Prometheus instance 标签一致的 host:port 形式
+func snmpInstance(ip string, port int) string {
+	if port == 0 {
+		port = 161
+	}
+	if strings.Contains(ip, ":") {
+		return fmt.Sprintf("[%s]:%d", ip, port)
+	}
+	return fmt.Sprintf("%s:%d", ip, port)
+}
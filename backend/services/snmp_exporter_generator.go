@@ -0,0 +1,512 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gosnmp/gosnmp"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+)
+
+// SNMPGeneratorLookup 对应官方 generator.yml 中的 lookups
+type SNMPGeneratorLookup struct {
+	SourceIndexes     []string `json:"source_indexes"`
+	Lookup            string   `json:"lookup"` // 对象名或数字 OID
+	DropSourceIndexes bool     `json:"drop_source_indexes"`
+}
+
+// SNMPGeneratorOverride 对应官方 generator.yml 中的 overrides，按指标名调整生成结果
+type SNMPGeneratorOverride struct {
+	Ignore         bool                           `json:"ignore"`
+	Type           string                         `json:"type"` // 如 EnumAsInfo、EnumAsStateSet、DisplayString
+	RegexpExtracts map[string][]SNMPRegexpExtract `json:"regex_extracts"`
+}
+
+// snmpGeneratorOptions 配置生成请求 options 中的 generator 参数
+type snmpGeneratorOptions struct {
+	Lookups   []SNMPGeneratorLookup            `json:"lookups"`
+	Overrides map[string]SNMPGeneratorOverride `json:"overrides"`
+	Format    string                           `json:"format"`    // 为 legacy 时生成 0.23 之前的格式，认证信息放在模块内
+	AuthName  string                           `json:"auth_name"` // 新格式中 auths 的名称，默认为配置名称加设备地址
+}
+
+// defaultSNMPLookups 未指定 lookups 时为接口表的指标补充 ifDescr 标签
+var defaultSNMPLookups = []SNMPGeneratorLookup{
+	{SourceIndexes: []string{"ifIndex"}, Lookup: "ifDescr"},
+}
+
+// snmpMetricTypes snmp_exporter 支持的指标类型，override 只能使用这些类型
+var snmpMetricTypes = map[string]bool{
+	"gauge":           true,
+	"counter":         true,
+	"DisplayString":   true,
+	"OctetString":     true,
+	"PhysAddress48":   true,
+	"IpAddr":          true,
+	"InetAddress":     true,
+	"InetAddressIPv4": true,
+	"InetAddressIPv6": true,
+	"DateAndTime":     true,
+	"Bits":            true,
+	"EnumAsInfo":      true,
+	"EnumAsStateSet":  true,
+}
+
+var mibFixedSizeRegex = regexp.MustCompile(`SIZE\s*\(\s*(\d+)\s*\)`)
+
+// parseSNMPGeneratorOptions 从请求 options 中读取 lookups 和 overrides
+func parseSNMPGeneratorOptions(raw map[string]interface{}) (*snmpGeneratorOptions, error) {
+	options := &snmpGeneratorOptions{}
+	if len(raw) > 0 {
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, options); err != nil {
+			return nil, fmt.Errorf("invalid snmp_exporter options: %v", err)
+		}
+	}
+
+	for name, override := range options.Overrides {
+		if override.Type != "" && !snmpMetricTypes[override.Type] {
+			return nil, fmt.Errorf("invalid type %q in override for %s", override.Type, name)
+		}
+		for _, extracts := range override.RegexpExtracts {
+			for _, extract := range extracts {
+				if _, err := regexp.Compile(extract.Regex); err != nil {
+					return nil, fmt.Errorf("invalid regex_extracts in override for %s: %v", name, err)
+				}
+			}
+		}
+	}
+	if options.Format != "" && options.Format != "legacy" {
+		return nil, fmt.Errorf("unsupported snmp_exporter format: %s", options.Format)
+	}
+	for _, lookup := range options.Lookups {
+		if len(lookup.SourceIndexes) == 0 || lookup.Lookup == "" {
+			return nil, fmt.Errorf("lookup requires source_indexes and lookup")
+		}
+	}
+	return options, nil
+}
+
+// snmpExporterAuthProtocols snmp_exporter 使用的认证协议名称
+var snmpExporterAuthProtocols = map[gosnmp.SnmpV3AuthProtocol]string{
+	gosnmp.MD5:    "MD5",
+	gosnmp.SHA:    "SHA",
+	gosnmp.SHA224: "SHA224",
+	gosnmp.SHA256: "SHA256",
+	gosnmp.SHA384: "SHA384",
+	gosnmp.SHA512: "SHA512",
+}
+
+// snmpExporterPrivProtocols snmp_exporter 使用的加密协议名称
+var snmpExporterPrivProtocols = map[gosnmp.SnmpV3PrivProtocol]string{
+	gosnmp.DES:     "DES",
+	gosnmp.AES:     "AES",
+	gosnmp.AES192:  "AES192",
+	gosnmp.AES256:  "AES256",
+	gosnmp.AES192C: "AES192C",
+	gosnmp.AES256C: "AES256C",
+}
+
+// snmpExporterAuth 把设备信息转换为 snmp_exporter 的认证配置；v3 未指定安全级别时按是否提供密钥推断
+func snmpExporterAuth(info DeviceInfo, version int) (SNMPAuth, error) {
+	if version != 3 {
+		return SNMPAuth{Community: info.Community}, nil
+	}
+	if info.Username == "" {
+		return SNMPAuth{}, fmt.Errorf("username is required for SNMP v3")
+	}
+
+	level := info.SecurityLevel
+	if level == "" {
+		switch {
+		case info.PrivKey != "":
+			level = "authPriv"
+		case info.AuthKey != "":
+			level = "authNoPriv"
+		default:
+			level = "noAuthNoPriv"
+		}
+	}
+
+	auth := SNMPAuth{
+		SecurityLevel: level,
+		Username:      info.Username,
+		ContextName:   info.ContextName,
+	}
+	switch level {
+	case "noAuthNoPriv":
+		return auth, nil
+	case "authNoPriv", "authPriv":
+	default:
+		return SNMPAuth{}, fmt.Errorf("unsupported SNMP v3 security level: %s", level)
+	}
+
+	if info.AuthKey == "" {
+		return SNMPAuth{}, fmt.Errorf("auth_key is required for security level %s", level)
+	}
+	authProto, err := snmpAuthProtocol(info.AuthProto)
+	if err != nil {
+		return SNMPAuth{}, err
+	}
+	auth.Password = info.AuthKey
+	auth.AuthProtocol = snmpExporterAuthProtocols[authProto]
+	if level == "authNoPriv" {
+		return auth, nil
+	}
+
+	if info.PrivKey == "" {
+		return SNMPAuth{}, fmt.Errorf("priv_key is required for security level authPriv")
+	}
+	privProto, err := snmpPrivProtocol(info.PrivProto)
+	if err != nil {
+		return SNMPAuth{}, err
+	}
+	auth.PrivPassword = info.PrivKey
+	auth.PrivProtocol = snmpExporterPrivProtocols[privProto]
+	return auth, nil
+}
+
+// snmpModuleBuilder 按 MIB 元数据生成 snmp_exporter 模块，生成过程中缓存查到的 MIB 对象
+type snmpModuleBuilder struct {
+	db     *gorm.DB
+	byOID  map[string]*models.OID
+	byName map[string][]models.OID
+}
+
+func newSNMPModuleBuilder(db *gorm.DB) *snmpModuleBuilder {
+	return &snmpModuleBuilder{
+		db:     db,
+		byOID:  make(map[string]*models.OID),
+		byName: make(map[string][]models.OID),
+	}
+}
+
+// build 生成 walk 列表和指标；MIB 库中没有定义的 OID 退化为按 OID 命名的 gauge 指标
+func (b *snmpModuleBuilder) build(roots []string, options *snmpGeneratorOptions) ([]string, []SNMPMetric, error) {
+	var walk []string
+	var metrics []SNMPMetric
+	seen := make(map[string]bool)
+
+	for _, root := range roots {
+		root = normalizeOID(root)
+		if root == "" {
+			continue
+		}
+		walk = append(walk, root)
+
+		var objects []models.OID
+		if err := b.db.Where("oid = ? OR oid LIKE ?", root, root+".%").Find(&objects).Error; err != nil {
+			return nil, nil, fmt.Errorf("failed to load MIB objects: %v", err)
+		}
+		if len(objects) == 0 {
+			if !seen[root] {
+				seen[root] = true
+				metrics = append(metrics, SNMPMetric{
+					Name: strings.ReplaceAll(root, ".", "_"),
+					OID:  root,
+					Type: "gauge",
+					Help: fmt.Sprintf("SNMP metric for OID %s", root),
+				})
+			}
+			continue
+		}
+
+		for i := range objects {
+			object := &objects[i]
+			b.byOID[object.OID] = object
+			if seen[object.OID] || !snmpMetricObject(object) {
+				continue
+			}
+			seen[object.OID] = true
+			metric, err := b.metric(object)
+			if err != nil {
+				return nil, nil, err
+			}
+			metrics = append(metrics, metric)
+		}
+	}
+	sort.SliceStable(metrics, func(i, j int) bool { return compareOIDs(metrics[i].OID, metrics[j].OID) < 0 })
+
+	lookups, required := options.Lookups, true
+	if lookups == nil {
+		lookups, required = defaultSNMPLookups, false
+	}
+	lookupOIDs, err := b.applyLookups(metrics, lookups, required)
+	if err != nil {
+		return nil, nil, err
+	}
+	walk = append(walk, lookupOIDs...)
+
+	metrics = applySNMPOverrides(metrics, options.Overrides)
+	return uniqueRootOIDs(walk), metrics, nil
+}
+
+// metric 生成单个对象的指标，表格列按所在 Entry 的 INDEX（或 AUGMENTS 的 Entry）生成索引
+func (b *snmpModuleBuilder) metric(object *models.OID) (SNMPMetric, error) {
+	metric := SNMPMetric{
+		Name: sanitizeMetricName(object.Name),
+		OID:  object.OID,
+		Type: snmpMetricType(object),
+		Help: snmpMetricHelp(object.Description, object.OID),
+	}
+	if metric.Type == "gauge" || metric.Type == "Bits" {
+		metric.EnumValues = object.EnumValues
+	}
+
+	i := strings.LastIndex(object.OID, ".")
+	if i < 0 {
+		return metric, nil
+	}
+	entry, err := b.object(object.OID[:i])
+	if err != nil || entry == nil {
+		return metric, err
+	}
+	names, err := b.entryIndexes(entry)
+	if err != nil {
+		return metric, err
+	}
+
+	for _, name := range names {
+		implied := strings.HasPrefix(name, "IMPLIED ")
+		name = strings.TrimSpace(strings.TrimPrefix(name, "IMPLIED "))
+		index := SNMPIndex{LabelName: sanitizeLabelName(name), Type: "gauge", Implied: implied}
+
+		indexObject, err := b.named(name, entry.MIBID)
+		if err != nil {
+			return metric, err
+		}
+		if indexObject != nil {
+			index.Type = snmpIndexType(indexObject)
+			if !implied && (index.Type == "OctetString" || index.Type == "DisplayString") {
+				if matches := mibFixedSizeRegex.FindStringSubmatch(indexObject.Type); len(matches) > 1 {
+					index.FixedSize, _ = strconv.Atoi(matches[1])
+				}
+			}
+		}
+		metric.Indexes = append(metric.Indexes, index)
+	}
+	return metric, nil
+}
+
+// entryIndexes 返回表 Entry 的索引对象，AUGMENTS 的 Entry 沿用被扩展表的索引
+func (b *snmpModuleBuilder) entryIndexes(entry *models.OID) ([]string, error) {
+	for depth := 0; entry != nil && depth < 8; depth++ {
+		if len(entry.Indexes) > 0 {
+			return entry.Indexes, nil
+		}
+		if entry.Augments == "" {
+			return nil, nil
+		}
+		var err error
+		if entry, err = b.named(entry.Augments, entry.MIBID); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+// applyLookups 为索引包含 source_indexes 的指标添加 lookup，返回需要额外遍历的 OID
+func (b *snmpModuleBuilder) applyLookups(metrics []SNMPMetric, lookups []SNMPGeneratorLookup, required bool) ([]string, error) {
+	var oids []string
+	for _, lookup := range lookups {
+		target, err := b.resolve(lookup.Lookup)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			if required {
+				return nil, fmt.Errorf("unknown lookup object %s", lookup.Lookup)
+			}
+			continue
+		}
+
+		labels := make([]string, len(lookup.SourceIndexes))
+		for i, name := range lookup.SourceIndexes {
+			labels[i] = sanitizeLabelName(name)
+		}
+
+		used := false
+		for i := range metrics {
+			if !hasIndexLabels(metrics[i], labels) {
+				continue
+			}
+			used = true
+			metrics[i].Lookups = append(metrics[i].Lookups, SNMPLookup{
+				Labels:    labels,
+				LabelName: sanitizeLabelName(target.Name),
+				OID:       target.OID,
+				Type:      snmpIndexType(target),
+			})
+			if lookup.DropSourceIndexes {
+				for _, label := range labels {
+					metrics[i].Lookups = append(metrics[i].Lookups, SNMPLookup{Labels: []string{}, LabelName: label})
+				}
+			}
+		}
+		if used {
+			oids = append(oids, target.OID)
+		}
+	}
+	return oids, nil
+}
+
+// object 按数字 OID 查找 MIB 对象，找不到时返回 nil
+func (b *snmpModuleBuilder) object(oid string) (*models.OID, error) {
+	if object, ok := b.byOID[oid]; ok {
+		return object, nil
+	}
+	var objects []models.OID
+	if err := b.db.Where("oid = ?", oid).Limit(1).Find(&objects).Error; err != nil {
+		return nil, fmt.Errorf("failed to load MIB object %s: %v", oid, err)
+	}
+	var object *models.OID
+	if len(objects) > 0 {
+		object = &objects[0]
+	}
+	b.byOID[oid] = object
+	return object, nil
+}
+
+// named 按对象名查找 MIB 对象，同名时优先使用同一个 MIB 中的定义
+func (b *snmpModuleBuilder) named(name string, mibID uint) (*models.OID, error) {
+	objects, ok := b.byName[name]
+	if !ok {
+		if err := b.db.Where("name = ?", name).Order("id").Find(&objects).Error; err != nil {
+			return nil, fmt.Errorf("failed to load MIB object %s: %v", name, err)
+		}
+		b.byName[name] = objects
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+	for i := range objects {
+		if objects[i].MIBID == mibID {
+			return &objects[i], nil
+		}
+	}
+	return &objects[0], nil
+}
+
+// resolve 按对象名或数字 OID 查找 MIB 对象
+func (b *snmpModuleBuilder) resolve(ref string) (*models.OID, error) {
+	if _, err := parseOIDSubids(normalizeOID(ref)); err == nil {
+		return b.object(normalizeOID(ref))
+	}
+	return b.named(ref, 0)
+}
+
+// applySNMPOverrides 按指标名忽略指标或修改类型、添加 regex_extracts
+func applySNMPOverrides(metrics []SNMPMetric, overrides map[string]SNMPGeneratorOverride) []SNMPMetric {
+	if len(overrides) == 0 {
+		return metrics
+	}
+	result := metrics[:0]
+	for _, metric := range metrics {
+		if override, ok := overrides[metric.Name]; ok {
+			if override.Ignore {
+				continue
+			}
+			if override.Type != "" {
+				metric.Type = override.Type
+			}
+			if len(override.RegexpExtracts) > 0 {
+				metric.RegexpExtracts = override.RegexpExtracts
+			}
+		}
+		result = append(result, metric)
+	}
+	return result
+}
+
+func hasIndexLabels(metric SNMPMetric, labels []string) bool {
+	for _, label := range labels {
+		found := false
+		for _, index := range metric.Indexes {
+			if index.LabelName == label {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// snmpMetricObject 只有可读的叶子对象生成指标，表和 Entry 本身没有值
+func snmpMetricObject(object *models.OID) bool {
+	if object.Type == "" || len(object.Indexes) > 0 || object.Augments != "" {
+		return false
+	}
+	switch object.Access {
+	case "not-accessible", "accessible-for-notify":
+		return false
+	}
+	return !strings.Contains(strings.ToUpper(object.Type), "SEQUENCE")
+}
+
+// snmpMetricType 将 MIB 对象的语法映射为 snmp_exporter 的指标类型，规则与官方 generator 一致
+func snmpMetricType(object *models.OID) string {
+	syntax := strings.ToLower(object.Syntax + " " + object.Type)
+	switch {
+	case strings.Contains(syntax, "physaddress"), strings.Contains(syntax, "macaddress"):
+		return "PhysAddress48"
+	case strings.Contains(syntax, "inetaddresstype"):
+		return "gauge"
+	case strings.Contains(syntax, "inetaddressipv4"):
+		return "InetAddressIPv4"
+	case strings.Contains(syntax, "inetaddressipv6"):
+		return "InetAddressIPv6"
+	case strings.Contains(syntax, "inetaddress"):
+		return "InetAddress"
+	case strings.Contains(syntax, "dateandtime"):
+		return "DateAndTime"
+	case strings.Contains(syntax, "displaystring"), strings.Contains(syntax, "snmpadminstring"):
+		return "DisplayString"
+	case strings.Contains(syntax, "ipaddress"), strings.Contains(syntax, "networkaddress"):
+		return "IpAddr"
+	case strings.Contains(syntax, "counter"):
+		return "counter"
+	case object.Syntax == "BITS", strings.HasPrefix(strings.TrimSpace(object.Type), "BITS"):
+		return "Bits"
+	case strings.Contains(syntax, "octet string"), strings.Contains(syntax, "object identifier"), strings.Contains(syntax, "opaque"):
+		return "OctetString"
+	default:
+		return "gauge"
+	}
+}
+
+// snmpIndexType 索引和 lookup 标签的类型，数值类型一律按 gauge 解析
+func snmpIndexType(object *models.OID) string {
+	switch typ := snmpMetricType(object); typ {
+	case "counter", "Bits":
+		return "gauge"
+	case "DateAndTime":
+		return "OctetString"
+	default:
+		return typ
+	}
+}
+
+// snmpMetricHelp 取描述的第一句并附上 OID，与官方 generator 的 help 相同
+func snmpMetricHelp(description, oid string) string {
+	help := strings.Join(strings.Fields(description), " ")
+	if i := strings.Index(help, ". "); i >= 0 {
+		help = help[:i]
+	}
+	help = strings.TrimSuffix(help, ".")
+	if help == "" {
+		return oid
+	}
+	return help + " - " + oid
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// inputFields 为 Categraf/Telegraf 的 snmp 输入生成 field 和 table：标量对象生成 field，
+// 表格列按所在的 MIB 表分组生成 table，字符串和地址类型的对象作为标签，表格继承标量标签
+func (b *snmpModuleBuilder) inputFields(metrics []SNMPMetric) ([]CategrafSNMPField, []CategrafSNMPTable, error) {
+	var fields []CategrafSNMPField
+	var tables []CategrafSNMPTable
+	var tags []string
+	tableIndex := make(map[string]int)
+
+	for _, metric := range metrics {
+		oid := normalizeOID(metric.OID)
+		// 以点号开头的 OID 按数字 OID 处理，采集端不需要翻译
+		field := CategrafSNMPField{Name: metric.Name, OID: "." + oid}
+		switch metric.Type {
+		case "DisplayString", "OctetString":
+			field.IsTag = true
+		case "PhysAddress48":
+			field.IsTag = true
+			field.Conversion = "hwaddr"
+		case "IpAddr", "InetAddress", "InetAddressIPv4", "InetAddressIPv6":
+			field.IsTag = true
+			field.Conversion = "ipaddr"
+		}
+
+		if len(metric.Indexes) == 0 {
+			object, err := b.object(oid)
+			if err != nil {
+				return nil, nil, err
+			}
+			// MIB 库中有定义的标量对象补上实例后缀 .0，未知 OID 按原样采集
+			if object != nil {
+				field.OID += ".0"
+			}
+			if field.IsTag {
+				tags = append(tags, field.Name)
+			}
+			fields = append(fields, field)
+			continue
+		}
+
+		tableOID := oid
+		for n := 0; n < 2; n++ {
+			if i := strings.LastIndex(tableOID, "."); i > 0 {
+				tableOID = tableOID[:i]
+			}
+		}
+		i, ok := tableIndex[tableOID]
+		if !ok {
+			name := strings.ReplaceAll(tableOID, ".", "_")
+			table, err := b.object(tableOID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if table != nil {
+				name = table.Name
+			}
+			tables = append(tables, CategrafSNMPTable{Name: name, IndexAsTag: true})
+			i = len(tables) - 1
+			tableIndex[tableOID] = i
+		}
+		tables[i].Fields = append(tables[i].Fields, field)
+	}
+
+	for i := range tables {
+		tables[i].InheritTags = tags
+	}
+	return fields, tables, nil
+}
+
+// mergeSNMPInputs 按 agent 地址合并 path 指向的数组表（Categraf 的 instances、Telegraf 的 inputs.snmp）：
+// 旧条目中与新配置重复的 agent 被移除，agent 全部被移除的条目整个删除；其他配置和未知字段原样保留
+func mergeSNMPInputs(existing, new string, path ...string) (string, error) {
+	var existingConfig, newConfig map[string]interface{}
+	if err := toml.Unmarshal([]byte(existing), &existingConfig); err != nil {
+		return "", fmt.Errorf("failed to parse existing config: %v", err)
+	}
+	if err := toml.Unmarshal([]byte(new), &newConfig); err != nil {
+		return "", fmt.Errorf("failed to parse new config: %v", err)
+	}
+	if existingConfig == nil {
+		existingConfig = make(map[string]interface{})
+	}
+
+	key := path[len(path)-1]
+	existingParent := tomlTable(existingConfig, path[:len(path)-1], true)
+	newParent := tomlTable(newConfig, path[:len(path)-1], false)
+
+	merged := snmpInputEntries(existingParent[key])
+	for _, entry := range snmpInputEntries(newParent[key]) {
+		replaced := make(map[string]bool)
+		for _, agent := range snmpInputAgents(entry) {
+			replaced[snmpInputAgentKey(agent)] = true
+		}
+
+		var kept []map[string]interface{}
+		for _, old := range merged {
+			agents := snmpInputAgents(old)
+			var remaining []interface{}
+			for _, agent := range agents {
+				if !replaced[snmpInputAgentKey(agent)] {
+					remaining = append(remaining, agent)
+				}
+			}
+			if len(agents) > 0 && len(remaining) == 0 {
+				continue
+			}
+			if len(remaining) < len(agents) {
+				old["agents"] = remaining
+			}
+			kept = append(kept, old)
+		}
+		merged = append(kept, entry)
+	}
+
+	for name, value := range newParent {
+		if name != key {
+			existingParent[name] = value
+		}
+	}
+	entries := make([]interface{}, len(merged))
+	for i, entry := range merged {
+		entries[i] = entry
+	}
+	existingParent[key] = entries
+
+	data, err := toml.Marshal(existingConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal merged config: %v", err)
+	}
+	return string(data), nil
+}
+
+// validateSNMPInputConfig 校验 Categraf/Telegraf 的 snmp 输入：agent、版本、v3 用户名以及 field/table 的 oid
+func validateSNMPInputConfig(content string, path []string, errors, warnings []string) ([]string, []string) {
+	var config map[string]interface{}
+	if err := toml.Unmarshal([]byte(content), &config); err != nil {
+		return append(errors, fmt.Sprintf("Invalid TOML: %v", err)), warnings
+	}
+
+	section := strings.Join(path, ".")
+	entries := snmpInputEntries(tomlTable(config, path[:len(path)-1], false)[path[len(path)-1]])
+	if len(entries) == 0 {
+		return append(errors, fmt.Sprintf("No [[%s]] section found", section)), warnings
+	}
+
+	for i, entry := range entries {
+		prefix := fmt.Sprintf("%s[%d]", section, i)
+		if len(snmpInputAgents(entry)) == 0 {
+			errors = append(errors, fmt.Sprintf("%s: agents is required", prefix))
+		}
+
+		version, _ := entry["version"].(int64)
+		switch version {
+		case 0:
+			warnings = append(warnings, fmt.Sprintf("%s: version not specified, defaulting to 2", prefix))
+		case 1, 2:
+			if entry["community"] == nil {
+				warnings = append(warnings, fmt.Sprintf("%s: community not specified, defaulting to public", prefix))
+			}
+		case 3:
+			if name, _ := entry["sec_name"].(string); name == "" {
+				errors = append(errors, fmt.Sprintf("%s: sec_name is required for SNMP v3", prefix))
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("%s: unsupported SNMP version %d", prefix, version))
+		}
+
+		fields := snmpInputEntries(entry["field"])
+		tables := snmpInputEntries(entry["table"])
+		if len(fields) == 0 && len(tables) == 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: no field or table defined", prefix))
+		}
+		for _, table := range tables {
+			fields = append(fields, snmpInputEntries(table["field"])...)
+			if oid, _ := table["oid"].(string); oid == "" && len(snmpInputEntries(table["field"])) == 0 {
+				errors = append(errors, fmt.Sprintf("%s: table %v has neither oid nor fields", prefix, table["name"]))
+			}
+		}
+		for _, field := range fields {
+			if oid, _ := field["oid"].(string); oid == "" {
+				errors = append(errors, fmt.Sprintf("%s: field %v has no oid", prefix, field["name"]))
+			}
+		}
+	}
+	return errors, warnings
+}
+
+// tomlTable 按路径取嵌套表，create 为 true 时创建缺少的表
+func tomlTable(config map[string]interface{}, path []string, create bool) map[string]interface{} {
+	table := config
+	for _, name := range path {
+		next, ok := table[name].(map[string]interface{})
+		if !ok {
+			if !create {
+				return map[string]interface{}{}
+			}
+			next = make(map[string]interface{})
+			table[name] = next
+		}
+		table = next
+	}
+	return table
+}
+
+func snmpInputEntries(value interface{}) []map[string]interface{} {
+	list, _ := value.([]interface{})
+	entries := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if entry, ok := item.(map[string]interface{}); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func snmpInputAgents(entry map[string]interface{}) []interface{} {
+	agents, _ := entry["agents"].([]interface{})
+	return agents
+}
+
+// snmpInputAgentKey 补全默认的传输协议和端口，用于比较 agent 地址
+func snmpInputAgentKey(agent interface{}) string {
+	address, _ := agent.(string)
+	transport, host, port := parseSNMPAgentAddress(strings.TrimSpace(address))
+	return snmpAgentAddress(strings.ToLower(transport), strings.ToLower(host), port)
+}
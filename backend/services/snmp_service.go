@@ -1,8 +1,21 @@
 package services
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/gosnmp/gosnmp"
@@ -108,51 +121,329 @@ func (s *SNMPService) SNMPWalk(req *models.SNMPRequest) (*models.SNMPResponse, e
 	}, nil
 }
 
-func (s *SNMPService) SNMPSet(req *models.SNMPSetRequest) (*models.SNMPResponse, error) {
+// SNMPSet 执行经过 MIB 校验的 SET 操作：对象必须可写、类型和取值范围必须与 MIB 定义一致。
+// 写入前会读取当前值用于回滚，每次操作（包括 dry-run 和被拒绝的请求）都会写入审计记录。
+func (s *SNMPService) SNMPSet(req *models.SNMPSetRequest) (*models.SNMPSetResponse, error) {
+	return s.executeSet(req, nil)
+}
+
+// RollbackSet 将审计记录中捕获的旧值重新写回设备，回滚本身也会生成一条审计记录
+func (s *SNMPService) RollbackSet(auditID uint, req *models.SNMPRollbackRequest) (*models.SNMPSetResponse, error) {
+	var audit models.SNMPSetAudit
+	if err := s.db.First(&audit, auditID).Error; err != nil {
+		return nil, err
+	}
+
+	if audit.Status != "success" {
+		return nil, fmt.Errorf("only successful set operations can be rolled back (status: %s)", audit.Status)
+	}
+	if !audit.HasPrevious {
+		return nil, fmt.Errorf("no previous value was captured for audit record %d", audit.ID)
+	}
+
+	snmpReq := models.SNMPRequest{
+		Target:    audit.Target,
+		Port:      audit.Port,
+		Transport: audit.Transport,
+		OID:       audit.OID,
+		Version:   req.Version,
+		Community: req.Community,
+		Username:  req.Username,
+		AuthProto: req.AuthProto,
+		AuthKey:   req.AuthKey,
+		PrivProto: req.PrivProto,
+		PrivKey:   req.PrivKey,
+		Timeout:   req.Timeout,
+		Retries:   req.Retries,
+	}
+	if req.Transport != "" {
+		snmpReq.Transport = req.Transport
+	}
+
+	// 未提供凭据时使用设备上保存的凭据
+	if snmpReq.Version == "" {
+		if audit.DeviceID == nil {
+			return nil, fmt.Errorf("no credentials supplied and target %s is not a known device", audit.Target)
+		}
+		device, err := NewDeviceService(s.db, s.redis).GetDevice(*audit.DeviceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load device: %v", err)
+		}
+		if len(device.Credentials) == 0 {
+			return nil, fmt.Errorf("no credentials supplied and device %s has no SNMP credentials", device.Name)
+		}
+		deviceReq := newDeviceSNMPRequest(device, device.Credentials[0], audit.OID)
+		deviceReq.Port = audit.Port
+		if snmpReq.Transport != "" {
+			deviceReq.Transport = snmpReq.Transport
+		}
+		if req.Timeout > 0 {
+			deviceReq.Timeout = req.Timeout
+		}
+		snmpReq = *deviceReq
+	}
+
+	setReq := &models.SNMPSetRequest{
+		SNMPRequest: snmpReq,
+		Value:       audit.PreviousValue,
+		Type:        audit.PreviousType,
+		DryRun:      req.DryRun,
+		Operator:    req.Operator,
+	}
+
+	response, err := s.executeSet(setReq, &audit)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Success && !req.DryRun {
+		now := time.Now()
+		s.db.Model(&audit).Updates(map[string]interface{}{
+			"status":         "rolled_back",
+			"rolled_back_at": &now,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *SNMPService) GetSetAudits(page, limit int, target, oid, status string) ([]models.SNMPSetAudit, int64, error) {
+	var audits []models.SNMPSetAudit
+	var total int64
+
+	query := s.db.Model(&models.SNMPSetAudit{})
+
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+	if oid != "" {
+		query = query.Where("oid LIKE ?", normalizeOID(oid)+"%")
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&audits).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return audits, total, nil
+}
+
+func (s *SNMPService) GetSetAudit(id uint) (*models.SNMPSetAudit, error) {
+	var audit models.SNMPSetAudit
+	if err := s.db.First(&audit, id).Error; err != nil {
+		return nil, err
+	}
+	return &audit, nil
+}
+
+func (s *SNMPService) executeSet(req *models.SNMPSetRequest, rollbackOf *models.SNMPSetAudit) (*models.SNMPSetResponse, error) {
 	start := time.Now()
+	oid := normalizeOID(req.OID)
+
+	// 未提供操作人时如实记录为 unknown，不冒用管理员身份
+	operator := req.Operator
+	if operator == "" {
+		operator = "unknown"
+	}
+
+	audit := &models.SNMPSetAudit{
+		DeviceID:  s.findDeviceID(req.Target),
+		Target:    req.Target,
+		Port:      req.Port,
+		Transport: req.Transport,
+		Version:   req.Version,
+		OID:       oid,
+		Type:      strings.ToLower(req.Type),
+		Value:     fmt.Sprint(req.Value),
+		DryRun:    req.DryRun,
+		Operator:  operator,
+	}
+	if rollbackOf != nil {
+		audit.RollbackOfID = &rollbackOf.ID
+	}
+
+	validation, pdu := s.validateSet(oid, req.Type, req.Value)
+	audit.ObjectName = validation.Object
+
+	response := &models.SNMPSetResponse{
+		DryRun:     req.DryRun,
+		Validation: validation,
+	}
+
+	if !validation.Valid {
+		audit.Status = "rejected"
+		audit.Error = strings.Join(validation.Errors, "; ")
+		return s.finishSet(response, audit, start, "SNMP Set rejected: "+audit.Error)
+	}
 
 	// Create SNMP connection
 	snmp, err := s.createSNMPConnection(&req.SNMPRequest)
 	if err != nil {
+		audit.Status = "failed"
+		audit.Error = err.Error()
+		s.saveSetAudit(audit, start)
 		return nil, err
 	}
 	defer snmp.Conn.Close()
 
-	// Create PDU for Set operation
-	pdu := gosnmp.SnmpPDU{
-		Name:  req.OID,
-		Type:  s.getSNMPType(req.Type),
-		Value: req.Value,
+	// 读取当前值，用于 dry-run 展示和之后的回滚
+	current, err := snmp.Get([]string{oid})
+	if err != nil {
+		validation.Warnings = append(validation.Warnings, fmt.Sprintf("failed to read current value: %v", err))
+	} else if len(current.Variables) > 0 && hasSNMPValue(current.Variables[0]) {
+		previous := current.Variables[0]
+		audit.PreviousType, audit.PreviousValue = encodeSetValue(previous)
+		audit.HasPrevious = audit.PreviousType != ""
+		response.Previous = &models.SNMPResult{
+			OID:   previous.Name,
+			Type:  previous.Type.String(),
+			Value: s.convertSNMPValue(previous),
+		}
+	} else {
+		validation.Warnings = append(validation.Warnings, "object instance does not exist yet, rollback will not be available")
+	}
+
+	if req.DryRun {
+		audit.Status = "dry_run"
+		response.Data = []models.SNMPResult{{
+			OID:   oid,
+			Type:  pdu.Type.String(),
+			Value: req.Value,
+			Name:  validation.Object,
+		}}
+		return s.finishSet(response, audit, start, "SNMP Set dry-run: no changes were made")
 	}
 
 	// Perform SNMP Set
 	result, err := snmp.Set([]gosnmp.SnmpPDU{pdu})
+	if err == nil && result.Error != gosnmp.NoError {
+		err = fmt.Errorf("agent returned error %s at index %d", result.Error, result.ErrorIndex)
+	}
 	if err != nil {
-		return &models.SNMPResponse{
-			Success:   false,
-			Message:   err.Error(),
-			Timestamp: time.Now(),
-			Duration:  time.Since(start).String(),
-		}, nil
+		audit.Status = "failed"
+		audit.Error = err.Error()
+		return s.finishSet(response, audit, start, err.Error())
 	}
 
 	// Convert results
-	var data []models.SNMPResult
 	for _, variable := range result.Variables {
-		data = append(data, models.SNMPResult{
+		response.Data = append(response.Data, models.SNMPResult{
 			OID:   variable.Name,
 			Type:  variable.Type.String(),
 			Value: s.convertSNMPValue(variable),
+			Name:  validation.Object,
 		})
 	}
 
-	return &models.SNMPResponse{
-		Success:   true,
-		Message:   "SNMP Set successful",
-		Data:      data,
-		Timestamp: time.Now(),
-		Duration:  time.Since(start).String(),
-	}, nil
+	audit.Status = "success"
+	return s.finishSet(response, audit, start, "SNMP Set successful")
+}
+
+func (s *SNMPService) finishSet(response *models.SNMPSetResponse, audit *models.SNMPSetAudit, start time.Time, message string) (*models.SNMPSetResponse, error) {
+	s.saveSetAudit(audit, start)
+
+	response.Success = audit.Status == "success" || audit.Status == "dry_run"
+	response.Message = message
+	response.AuditID = audit.ID
+	response.Timestamp = time.Now()
+	response.Duration = time.Since(start).String()
+	return response, nil
+}
+
+func (s *SNMPService) saveSetAudit(audit *models.SNMPSetAudit, start time.Time) {
+	audit.Duration = time.Since(start).String()
+	if err := s.db.Create(audit).Error; err != nil {
+		log.Printf("failed to save SNMP set audit for %s %s: %v", audit.Target, audit.OID, err)
+	}
+}
+
+// validateSet 根据 MIB 定义校验 SET 请求，并构造待写入的 PDU
+func (s *SNMPService) validateSet(oid, typeStr string, value interface{}) (*models.SNMPSetValidation, gosnmp.SnmpPDU) {
+	validation := &models.SNMPSetValidation{
+		Errors:   []string{},
+		Warnings: []string{},
+	}
+	pdu := gosnmp.SnmpPDU{Name: oid}
+
+	setType := strings.ToLower(strings.TrimSpace(typeStr))
+	asnType, err := s.getSNMPType(setType)
+	if err != nil {
+		validation.Errors = append(validation.Errors, err.Error())
+	}
+	pdu.Type = asnType
+
+	object, err := s.lookupMIBObject(oid)
+	if err != nil {
+		validation.Errors = append(validation.Errors, fmt.Sprintf("OID %s is not defined in any loaded MIB", oid))
+		return validation, pdu
+	}
+
+	syntaxStr := object.Type
+	if syntaxStr == "" {
+		syntaxStr = object.Syntax
+	}
+	validation.Object = object.Name
+	validation.MIBOID = object.OID
+	validation.Access = object.Access
+	validation.Syntax = syntaxStr
+
+	access := strings.ToLower(strings.TrimSpace(object.Access))
+	if access != "read-write" && access != "read-create" && access != "write-only" {
+		validation.Errors = append(validation.Errors, fmt.Sprintf("%s is %s and cannot be set", object.Name, object.Access))
+	}
+
+	syntax := parseMIBSyntax(syntaxStr)
+	if len(syntax.setTypes) == 0 {
+		validation.Warnings = append(validation.Warnings, fmt.Sprintf("cannot verify type %s against MIB syntax %q", setType, syntaxStr))
+	} else if asnType != 0 && !containsString(syntax.setTypes, setType) {
+		validation.Errors = append(validation.Errors, fmt.Sprintf("type %s does not match MIB syntax %q (expected %s)",
+			setType, syntaxStr, strings.Join(syntax.setTypes, " or ")))
+	}
+
+	if len(validation.Errors) > 0 {
+		return validation, pdu
+	}
+
+	converted, err := convertSetValue(setType, value, syntax)
+	if err != nil {
+		validation.Errors = append(validation.Errors, err.Error())
+		return validation, pdu
+	}
+
+	pdu.Value = converted
+	validation.Valid = true
+	return validation, pdu
+}
+
+// lookupMIBObject 按最长前缀匹配查找 OID 对应的 MIB 对象（去掉实例后缀）
+func (s *SNMPService) lookupMIBObject(oid string) (*models.OID, error) {
+	parts := strings.Split(normalizeOID(oid), ".")
+	var prefixes []string
+	for i := len(parts); i >= 2; i-- {
+		prefixes = append(prefixes, strings.Join(parts[:i], "."))
+	}
+
+	var object models.OID
+	if err := s.db.Where("oid IN ?", prefixes).Order("LENGTH(oid) DESC").First(&object).Error; err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+func (s *SNMPService) findDeviceID(target string) *uint {
+	var devices []models.Device
+	s.db.Where("ip_address = ? OR hostname = ?", target, target).Limit(1).Find(&devices)
+	if len(devices) == 0 {
+		return nil
+	}
+	return &devices[0].ID
 }
 
 func (s *SNMPService) TestConnection(req *models.SNMPRequest) (map[string]interface{}, error) {
@@ -172,11 +463,17 @@ func (s *SNMPService) TestConnection(req *models.SNMPRequest) (map[string]interf
 	duration := time.Since(start)
 
 	if err != nil {
-		return map[string]interface{}{
-			"success":      false,
-			"error":        err.Error(),
+		result := map[string]interface{}{
+			"success":       false,
+			"error":         err.Error(),
 			"response_time": duration.Milliseconds(),
-		}, nil
+		}
+		// v3 失败时附带 USM 错误分类，完整诊断见 DiagnoseV3
+		if req.Version == "v3" {
+			_, msgFlags, _ := newUSMSecurityParameters(req)
+			result["status"], result["hint"] = classifyUSMError(err, 0, req, usmSecurityLevel(msgFlags))
+		}
+		return result, nil
 	}
 
 	return map[string]interface{}{
@@ -187,6 +484,314 @@ func (s *SNMPService) TestConnection(req *models.SNMPRequest) (map[string]interf
 	}, nil
 }
 
+// ProbeCredentials 按优先级依次尝试候选凭据，报告每个候选在哪个 SNMP 版本上可用
+func (s *SNMPService) ProbeCredentials(req *models.SNMPProbeRequest) (*models.SNMPProbeResponse, error) {
+	deviceService := NewDeviceService(s.db, s.redis)
+
+	var device *models.Device
+	target, port, transport := req.Target, req.Port, req.Transport
+	if req.DeviceID != nil {
+		d, err := deviceService.GetDevice(*req.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		device = d
+		base := newDeviceSNMPRequest(device, models.SNMPCredential{}, "")
+		if target == "" {
+			target = base.Target
+		}
+		if port == 0 {
+			port = base.Port
+		}
+		if transport == "" {
+			transport = base.Transport
+		}
+	} else if target == "" {
+		return nil, fmt.Errorf("device_id or target is required")
+	} else if id := s.findDeviceID(target); id != nil {
+		device, _ = deviceService.GetDevice(*id)
+	}
+
+	timeout := time.Duration(req.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+
+	order := probeOrder(req.Candidates)
+
+	response := &models.SNMPProbeResponse{
+		Target:  target,
+		Results: []models.SNMPProbeResult{},
+	}
+	if device != nil {
+		response.DeviceID = &device.ID
+	}
+
+	var winner *models.SNMPProbeCandidate
+	for _, i := range order {
+		candidate := req.Candidates[i]
+		for _, version := range probeVersions(candidate) {
+			snmpReq := &models.SNMPRequest{
+				Target:    target,
+				Port:      port,
+				Transport: transport,
+				Version:   version,
+				Community: candidate.Community,
+				Username:  candidate.Username,
+				AuthProto: candidate.AuthProto,
+				AuthKey:   candidate.AuthKey,
+				PrivProto: candidate.PrivProto,
+				PrivKey:   candidate.PrivKey,
+			}
+			result := s.probeCandidate(snmpReq, timeout, req.Retries)
+			result.Candidate = i
+			result.Name = candidate.Name
+			response.Results = append(response.Results, result)
+
+			if result.Success {
+				if winner == nil {
+					chosen := candidate
+					chosen.Version = version
+					winner = &chosen
+					response.Winner = &result
+				}
+				// 高版本可用时不再尝试 v1
+				break
+			}
+		}
+		if winner != nil && req.StopOnFirst {
+			break
+		}
+	}
+
+	if req.Save && winner != nil {
+		if device == nil {
+			response.SaveError = fmt.Sprintf("%s is not a registered device", target)
+		} else if id, err := s.saveProbedCredential(device.ID, winner); err != nil {
+			response.SaveError = err.Error()
+		} else {
+			response.CredentialID = &id
+		}
+	}
+
+	return response, nil
+}
+
+func (s *SNMPService) probeCandidate(req *models.SNMPRequest, timeout time.Duration, retries int) models.SNMPProbeResult {
+	result := models.SNMPProbeResult{
+		Version:  req.Version,
+		Username: req.Username,
+	}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start).Milliseconds() }()
+
+	snmp, err := s.createSNMPConnection(req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer snmp.Conn.Close()
+	// 探测使用比常规请求更短的超时
+	snmp.Timeout = timeout
+	snmp.Retries = retries
+
+	// sysObjectID、sysName
+	packet, err := snmp.Get([]string{"1.3.6.1.2.1.1.2.0", "1.3.6.1.2.1.1.5.0"})
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if packet.Error != gosnmp.NoError {
+		result.Error = packet.Error.String()
+		return result
+	}
+
+	for _, pdu := range packet.Variables {
+		if !hasSNMPValue(pdu) {
+			continue
+		}
+		switch normalizeOID(pdu.Name) {
+		case "1.3.6.1.2.1.1.2.0":
+			result.SysObjectID = normalizeOID(fmt.Sprint(pdu.Value))
+		case "1.3.6.1.2.1.1.5.0":
+			if b, ok := pdu.Value.([]byte); ok {
+				result.SysName = string(b)
+			}
+		}
+	}
+	result.Success = true
+	return result
+}
+
+// saveProbedCredential 把探测成功的凭据写入设备的首个 SNMP 凭据，设备没有凭据时新建
+func (s *SNMPService) saveProbedCredential(deviceID uint, candidate *models.SNMPProbeCandidate) (uint, error) {
+	credential := models.SNMPCredential{
+		DeviceID:  deviceID,
+		Version:   candidate.Version,
+		Community: candidate.Community,
+		Username:  candidate.Username,
+		AuthProto: candidate.AuthProto,
+		AuthKey:   candidate.AuthKey,
+		PrivProto: candidate.PrivProto,
+		PrivKey:   candidate.PrivKey,
+	}
+
+	var existing models.SNMPCredential
+	err := s.db.Where("device_id = ?", deviceID).Order("id").First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := s.db.Create(&credential).Error; err != nil {
+			return 0, fmt.Errorf("failed to save credential: %v", err)
+		}
+		return credential.ID, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load credentials: %v", err)
+	}
+
+	if err := s.db.Model(&existing).Select("version", "community", "username", "auth_proto", "auth_key", "priv_proto", "priv_key").Updates(&credential).Error; err != nil {
+		return 0, fmt.Errorf("failed to save credential: %v", err)
+	}
+	return existing.ID, nil
+}
+
+// DiagnoseV3 先做引擎发现，再用给定凭据发起请求，把 USM 报告分类为可操作的提示
+func (s *SNMPService) DiagnoseV3(req *models.SNMPV3DiagnosticRequest) (*models.SNMPV3Diagnosis, error) {
+	snmpReq := &models.SNMPRequest{
+		Target:    req.Target,
+		Port:      req.Port,
+		Transport: req.Transport,
+		Version:   "v3",
+		Username:  req.Username,
+		AuthProto: req.AuthProto,
+		AuthKey:   req.AuthKey,
+		PrivProto: req.PrivProto,
+		PrivKey:   req.PrivKey,
+	}
+
+	if req.DeviceID != nil {
+		device, err := NewDeviceService(s.db, s.redis).GetDevice(*req.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		if req.Username == "" {
+			var cred *models.SNMPCredential
+			for i := range device.Credentials {
+				if device.Credentials[i].Version == "v3" {
+					cred = &device.Credentials[i]
+					break
+				}
+			}
+			if cred == nil {
+				return nil, fmt.Errorf("device %s has no SNMP v3 credentials", device.Name)
+			}
+			snmpReq = newDeviceSNMPRequest(device, *cred, "")
+		} else if snmpReq.Target == "" {
+			base := newDeviceSNMPRequest(device, models.SNMPCredential{}, "")
+			snmpReq.Target, snmpReq.Port, snmpReq.Transport = base.Target, base.Port, base.Transport
+		}
+	}
+	if snmpReq.Target == "" {
+		return nil, fmt.Errorf("device_id or target is required")
+	}
+	if snmpReq.Username == "" {
+		return nil, fmt.Errorf("username is required for SNMP v3")
+	}
+
+	timeout := time.Duration(req.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	retries := req.Retries
+	if retries <= 0 {
+		retries = 1
+	}
+
+	start := time.Now()
+	diagnosis := &models.SNMPV3Diagnosis{
+		Target:   snmpReq.Target,
+		Username: snmpReq.Username,
+	}
+	defer func() { diagnosis.Duration = time.Since(start).Milliseconds() }()
+
+	_, msgFlags, err := newUSMSecurityParameters(snmpReq)
+	if err != nil {
+		diagnosis.Status = "error"
+		diagnosis.Message = err.Error()
+		return diagnosis, nil
+	}
+	diagnosis.SecurityLevel = usmSecurityLevel(msgFlags)
+
+	// 第一步：引擎发现。发现报文不需要凭据，使用 noAuthNoPriv 连接即可
+	discovery, err := s.createSNMPConnection(&models.SNMPRequest{
+		Target:    snmpReq.Target,
+		Port:      snmpReq.Port,
+		Transport: snmpReq.Transport,
+		Version:   "v3",
+		Username:  snmpReq.Username,
+	})
+	if err != nil {
+		diagnosis.Status = "error"
+		diagnosis.Message = err.Error()
+		return diagnosis, nil
+	}
+	discovery.Timeout = timeout
+	discovery.Retries = retries
+	_, discoveryErr := discovery.Get([]string{"1.3.6.1.2.1.1.3.0"})
+	discovery.Conn.Close()
+
+	engine, _ := discovery.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	if engine == nil || engine.AuthoritativeEngineID == "" {
+		diagnosis.Status = "discovery_failed"
+		diagnosis.Message = "The agent did not answer the SNMPv3 engine discovery request. Check that SNMPv3 is enabled on the agent, and that the port, transport and any ACLs allow this host."
+		if discoveryErr != nil {
+			diagnosis.Error = discoveryErr.Error()
+		}
+		return diagnosis, nil
+	}
+	diagnosis.Engine = parseSNMPEngineID(engine.AuthoritativeEngineID)
+	diagnosis.Engine.Boots = engine.AuthoritativeEngineBoots
+	diagnosis.Engine.Time = engine.AuthoritativeEngineTime
+
+	// 第二步：使用发现到的引擎参数和真实凭据发起请求
+	snmp, err := s.createSNMPConnection(snmpReq)
+	if err != nil {
+		diagnosis.Status = "error"
+		diagnosis.Message = err.Error()
+		return diagnosis, nil
+	}
+	defer snmp.Conn.Close()
+	snmp.Timeout = timeout
+	snmp.Retries = retries
+	usm := snmp.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+	usm.AuthoritativeEngineID = engine.AuthoritativeEngineID
+	usm.AuthoritativeEngineBoots = engine.AuthoritativeEngineBoots
+	usm.AuthoritativeEngineTime = engine.AuthoritativeEngineTime
+
+	// 收到报文却最终超时，说明代理一直返回可恢复的报告（notInTimeWindow）
+	received := 0
+	snmp.OnRecv = func(*gosnmp.GoSNMP) { received++ }
+
+	packet, err := snmp.Get([]string{"1.3.6.1.2.1.1.3.0"})
+	if packet != nil && packet.PDUType == gosnmp.Report && len(packet.Variables) == 1 {
+		pdu := packet.Variables[0]
+		diagnosis.Report = &models.SNMPUSMReport{
+			OID:     normalizeOID(pdu.Name),
+			Name:    usmReportNames[normalizeOID(pdu.Name)],
+			Counter: gosnmp.ToBigInt(pdu.Value).Uint64(),
+		}
+	}
+	if err == nil {
+		diagnosis.Success = true
+		diagnosis.Status = "ok"
+		diagnosis.Message = fmt.Sprintf("Authenticated as %s at %s.", snmpReq.Username, diagnosis.SecurityLevel)
+		return diagnosis, nil
+	}
+
+	diagnosis.Error = err.Error()
+	diagnosis.Status, diagnosis.Message = classifyUSMError(err, received, snmpReq, diagnosis.SecurityLevel)
+	return diagnosis, nil
+}
+
 func (s *SNMPService) StartBulkOperation(operationType string, requests []models.SNMPRequest) (*models.BulkOperation, error) {
 	// TODO: Implement bulk operations with goroutines and progress tracking
 	operation := &models.BulkOperation{
@@ -215,10 +820,19 @@ func (s *SNMPService) GetBulkOperation(id string) (*models.BulkOperation, error)
 }
 
 func (s *SNMPService) createSNMPConnection(req *models.SNMPRequest) (*gosnmp.GoSNMP, error) {
+	transport, err := normalizeSNMPTransport(req.Transport)
+	if err != nil {
+		return nil, err
+	}
+	target, err := resolveSNMPTarget(req.Target, transport)
+	if err != nil {
+		return nil, err
+	}
+
 	snmp := &gosnmp.GoSNMP{
-		Target:    req.Target,
+		Target:    target,
 		Port:      uint16(req.Port),
-		Transport: "udp",
+		Transport: transport,
 		Timeout:   time.Duration(req.Timeout) * time.Second,
 		Retries:   req.Retries,
 	}
@@ -243,26 +857,21 @@ func (s *SNMPService) createSNMPConnection(req *models.SNMPRequest) (*gosnmp.GoS
 		snmp.Version = gosnmp.Version2c
 		snmp.Community = req.Community
 	case "v3":
+		usm, msgFlags, err := newUSMSecurityParameters(req)
+		if err != nil {
+			return nil, err
+		}
 		snmp.Version = gosnmp.Version3
 		snmp.SecurityModel = gosnmp.UserSecurityModel
-		snmp.MsgFlags = gosnmp.AuthNoPriv
-		snmp.SecurityParameters = &gosnmp.UsmSecurityParameters{
-			UserName:                 req.Username,
-			AuthenticationProtocol:   gosnmp.MD5,
-			AuthenticationPassphrase: req.AuthKey,
-		}
-		
-		if req.PrivKey != "" {
-			snmp.MsgFlags = gosnmp.AuthPriv
-			snmp.SecurityParameters.(*gosnmp.UsmSecurityParameters).PrivacyProtocol = gosnmp.DES
-			snmp.SecurityParameters.(*gosnmp.UsmSecurityParameters).PrivacyPassphrase = req.PrivKey
-		}
+		snmp.MsgFlags = msgFlags
+		snmp.SecurityParameters = usm
+		snmp.ContextName = req.ContextName
 	default:
 		return nil, fmt.Errorf("unsupported SNMP version: %s", req.Version)
 	}
 
 	// Connect
-	err := snmp.Connect()
+	err = snmp.Connect()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect: %v", err)
 	}
@@ -287,24 +896,26 @@ func (s *SNMPService) convertSNMPValue(pdu gosnmp.SnmpPDU) interface{} {
 	}
 }
 
-func (s *SNMPService) getSNMPType(typeStr string) gosnmp.Asn1BER {
-	switch typeStr {
+func (s *SNMPService) getSNMPType(typeStr string) (gosnmp.Asn1BER, error) {
+	switch strings.ToLower(typeStr) {
 	case "integer":
-		return gosnmp.Integer
-	case "string":
-		return gosnmp.OctetString
+		return gosnmp.Integer, nil
+	case "string", "hexstring":
+		return gosnmp.OctetString, nil
 	case "counter32":
-		return gosnmp.Counter32
+		return gosnmp.Counter32, nil
 	case "counter64":
-		return gosnmp.Counter64
-	case "gauge32":
-		return gosnmp.Gauge32
+		return gosnmp.Counter64, nil
+	case "gauge32", "unsigned32":
+		return gosnmp.Gauge32, nil
 	case "timeticks":
-		return gosnmp.TimeTicks
+		return gosnmp.TimeTicks, nil
 	case "ipaddress":
-		return gosnmp.IPAddress
+		return gosnmp.IPAddress, nil
+	case "oid":
+		return gosnmp.ObjectIdentifier, nil
 	default:
-		return gosnmp.OctetString
+		return 0, fmt.Errorf("unsupported SNMP type: %q", typeStr)
 	}
 }
 
@@ -312,3 +923,611 @@ func (s *SNMPService) processBulkOperation(operation *models.BulkOperation, requ
 	// TODO: Implement background bulk processing
 	// This would process requests in parallel and update progress in Redis
 }
+
+// newDeviceSNMPRequest 使用设备地址和保存的凭据构造 SNMP 请求
+func newDeviceSNMPRequest(device *models.Device, cred models.SNMPCredential, oid string) *models.SNMPRequest {
+	target := device.IPAddress
+	if target == "" {
+		target = device.Hostname
+	}
+	return &models.SNMPRequest{
+		Target:    target,
+		Port:      device.Port,
+		Transport: device.Transport,
+		Version:   cred.Version,
+		Community: cred.Community,
+		Username:  cred.Username,
+		AuthProto: cred.AuthProto,
+		AuthKey:   cred.AuthKey,
+		PrivProto: cred.PrivProto,
+		PrivKey:   cred.PrivKey,
+		OID:       oid,
+		Timeout:   5,
+		Retries:   3,
+	}
+}
+
+// usmReportNames USM 统计对象（RFC 3414 usmStats）
+var usmReportNames = map[string]string{
+	"1.3.6.1.6.3.15.1.1.1.0": "usmStatsUnsupportedSecLevels",
+	"1.3.6.1.6.3.15.1.1.2.0": "usmStatsNotInTimeWindows",
+	"1.3.6.1.6.3.15.1.1.3.0": "usmStatsUnknownUserNames",
+	"1.3.6.1.6.3.15.1.1.4.0": "usmStatsUnknownEngineIDs",
+	"1.3.6.1.6.3.15.1.1.5.0": "usmStatsWrongDigests",
+	"1.3.6.1.6.3.15.1.1.6.0": "usmStatsDecryptionErrors",
+}
+
+// classifyUSMError 将 gosnmp 的 USM 错误转换为状态码和处理建议
+func classifyUSMError(err error, received int, req *models.SNMPRequest, securityLevel string) (string, string) {
+	timedOut := strings.Contains(strings.ToLower(err.Error()), "timeout")
+
+	switch {
+	case errors.Is(err, gosnmp.ErrUnknownUsername):
+		return "unknown_user", fmt.Sprintf("The agent does not know the user %q. User names are case-sensitive; check that the user exists in the agent's USM table for this engine ID.", req.Username)
+	case errors.Is(err, gosnmp.ErrWrongDigest):
+		return "wrong_digest", fmt.Sprintf("Authentication failed for %q. The auth key or auth protocol (%s) does not match the agent's configuration.", req.Username, displayProto(req.AuthProto, "MD5"))
+	case errors.Is(err, gosnmp.ErrDecryption):
+		return "decryption_error", fmt.Sprintf("The agent could not decrypt the request. The privacy key or privacy protocol (%s) does not match the agent's configuration.", displayProto(req.PrivProto, "DES"))
+	case errors.Is(err, gosnmp.ErrUnknownSecurityLevel):
+		return "unsupported_security_level", fmt.Sprintf("The user %q is not configured for %s on the agent. Use the security level the user was created with (for example add or remove the privacy key).", req.Username, securityLevel)
+	case errors.Is(err, gosnmp.ErrNotInTimeWindow), timedOut && received > 0:
+		return "not_in_time_window", "The agent kept rejecting the request as outside its time window. Its engine boots/time may be inconsistent (for example a cloned engine ID or a clock jump); check that the engine ID is unique and restart the agent if needed."
+	case errors.Is(err, gosnmp.ErrUnknownEngineID):
+		return "unknown_engine_id", "The agent rejected the engine ID used in the request. Check that no other device answers with the same address and that the engine ID is unique."
+	case timedOut && securityLevel == "authPriv":
+		return "timeout", "Engine discovery succeeded but the authenticated request got no answer. Many agents silently drop packets they cannot decrypt, so check the privacy protocol and key, then the user's view/ACL."
+	case timedOut:
+		return "timeout", "Engine discovery succeeded but the authenticated request got no answer. Check the user's view/ACL on the agent."
+	default:
+		return "error", err.Error()
+	}
+}
+
+func displayProto(proto, fallback string) string {
+	if proto == "" {
+		return fallback
+	}
+	return proto
+}
+
+func usmSecurityLevel(flags gosnmp.SnmpV3MsgFlags) string {
+	switch flags &^ gosnmp.Reportable {
+	case gosnmp.AuthPriv:
+		return "authPriv"
+	case gosnmp.AuthNoPriv:
+		return "authNoPriv"
+	default:
+		return "noAuthNoPriv"
+	}
+}
+
+// parseSNMPEngineID 按 RFC 3411 SnmpEngineID 格式解析引擎 ID
+func parseSNMPEngineID(raw string) *models.SNMPEngineInfo {
+	b := []byte(raw)
+	info := &models.SNMPEngineInfo{EngineID: hex.EncodeToString(b)}
+
+	switch {
+	case len(b) >= 5 && b[0]&0x80 != 0:
+		info.Enterprise = binary.BigEndian.Uint32(b[:4]) &^ 0x80000000
+		switch b[4] {
+		case 1:
+			info.Format = "ipv4"
+		case 2:
+			info.Format = "ipv6"
+		case 3:
+			info.Format = "mac"
+		case 4:
+			info.Format = "text"
+		case 5:
+			info.Format = "octets"
+		default:
+			info.Format = "enterprise"
+		}
+	case len(b) == 12:
+		// RFC 1910 格式：4 字节企业号 + 8 字节自定义数据
+		info.Enterprise = binary.BigEndian.Uint32(b[:4])
+		info.Format = "legacy"
+	default:
+		info.Format = "octets"
+	}
+	return info
+}
+
+// probeOrder 按优先级排序后的候选凭据下标，优先级相同时保持原顺序
+func probeOrder(candidates []models.SNMPProbeCandidate) []int {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return candidates[order[a]].Priority < candidates[order[b]].Priority
+	})
+	return order
+}
+
+// probeVersions 候选凭据需要尝试的 SNMP 版本
+func probeVersions(candidate models.SNMPProbeCandidate) []string {
+	switch {
+	case candidate.Version != "":
+		return []string{candidate.Version}
+	case candidate.Username != "":
+		return []string{"v3"}
+	default:
+		return []string{"v2c", "v1"}
+	}
+}
+
+// newUSMSecurityParameters 根据请求中的认证/加密协议构造 USM 参数，安全级别由是否提供密钥决定
+func newUSMSecurityParameters(req *models.SNMPRequest) (*gosnmp.UsmSecurityParameters, gosnmp.SnmpV3MsgFlags, error) {
+	if req.Username == "" {
+		return nil, 0, fmt.Errorf("username is required for SNMP v3")
+	}
+
+	usm := &gosnmp.UsmSecurityParameters{
+		UserName:               req.Username,
+		AuthenticationProtocol: gosnmp.NoAuth,
+		PrivacyProtocol:        gosnmp.NoPriv,
+	}
+	if req.AuthKey == "" {
+		if req.PrivKey != "" {
+			return nil, 0, fmt.Errorf("SNMP v3 privacy requires an authentication key")
+		}
+		return usm, gosnmp.NoAuthNoPriv, nil
+	}
+
+	authProto, err := snmpAuthProtocol(req.AuthProto)
+	if err != nil {
+		return nil, 0, err
+	}
+	usm.AuthenticationProtocol = authProto
+	usm.AuthenticationPassphrase = req.AuthKey
+	if req.PrivKey == "" {
+		return usm, gosnmp.AuthNoPriv, nil
+	}
+
+	privProto, err := snmpPrivProtocol(req.PrivProto)
+	if err != nil {
+		return nil, 0, err
+	}
+	usm.PrivacyProtocol = privProto
+	usm.PrivacyPassphrase = req.PrivKey
+	return usm, gosnmp.AuthPriv, nil
+}
+
+// snmpAuthProtocol 认证协议名称映射，未指定时沿用 MD5
+func snmpAuthProtocol(name string) (gosnmp.SnmpV3AuthProtocol, error) {
+	switch strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), "-", "")) {
+	case "", "MD5":
+		return gosnmp.MD5, nil
+	case "SHA", "SHA1":
+		return gosnmp.SHA, nil
+	case "SHA224":
+		return gosnmp.SHA224, nil
+	case "SHA256":
+		return gosnmp.SHA256, nil
+	case "SHA384":
+		return gosnmp.SHA384, nil
+	case "SHA512":
+		return gosnmp.SHA512, nil
+	default:
+		return 0, fmt.Errorf("unsupported SNMP v3 auth protocol: %s", name)
+	}
+}
+
+// snmpPrivProtocol 加密协议名称映射，未指定时沿用 DES；AES192C/AES256C 为 Cisco 的密钥扩展方式
+func snmpPrivProtocol(name string) (gosnmp.SnmpV3PrivProtocol, error) {
+	switch strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), "-", "")) {
+	case "", "DES":
+		return gosnmp.DES, nil
+	case "AES", "AES128":
+		return gosnmp.AES, nil
+	case "AES192":
+		return gosnmp.AES192, nil
+	case "AES256":
+		return gosnmp.AES256, nil
+	case "AES192C":
+		return gosnmp.AES192C, nil
+	case "AES256C":
+		return gosnmp.AES256C, nil
+	default:
+		return 0, fmt.Errorf("unsupported SNMP v3 privacy protocol: %s", name)
+	}
+}
+
+// normalizeSNMPTransport 校验传输协议，空值默认为 udp
+func normalizeSNMPTransport(transport string) (string, error) {
+	transport = strings.ToLower(strings.TrimSpace(transport))
+	switch transport {
+	case "":
+		return "udp", nil
+	case "udp", "udp4", "udp6", "tcp", "tcp4", "tcp6":
+		return transport, nil
+	default:
+		return "", fmt.Errorf("unsupported SNMP transport: %s", transport)
+	}
+}
+
+// resolveSNMPTarget 解析主机名并按传输协议选择地址族；IPv6 字面量可带方括号
+func resolveSNMPTarget(target, transport string) (string, error) {
+	host := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(target), "["), "]")
+	if host == "" {
+		return "", fmt.Errorf("SNMP target is empty")
+	}
+
+	wantV6 := strings.HasSuffix(transport, "6")
+	wantV4 := strings.HasSuffix(transport, "4")
+
+	if ip := net.ParseIP(host); ip != nil {
+		if wantV6 && ip.To4() != nil {
+			return "", fmt.Errorf("transport %s requires an IPv6 target, got %s", transport, host)
+		}
+		if wantV4 && ip.To4() == nil {
+			return "", fmt.Errorf("transport %s requires an IPv4 target, got %s", transport, host)
+		}
+		return host, nil
+	}
+
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %v", host, err)
+	}
+	// 未指定地址族时优先 IPv4，没有 IPv4 地址时使用 IPv6
+	var v4, v6 net.IP
+	for _, ip := range ips {
+		if ip.To4() != nil {
+			if v4 == nil {
+				v4 = ip
+			}
+		} else if v6 == nil {
+			v6 = ip
+		}
+	}
+	switch {
+	case wantV6 && v6 != nil:
+		return v6.String(), nil
+	case wantV6:
+		return "", fmt.Errorf("%s has no IPv6 address", host)
+	case v4 != nil:
+		return v4.String(), nil
+	case v6 != nil && !wantV4:
+		return v6.String(), nil
+	default:
+		return "", fmt.Errorf("%s has no IPv4 address", host)
+	}
+}
+
+// snmpAgentAddress 生成 transport://host:port 形式的采集地址，供 Categraf/Telegraf 等使用
+func snmpAgentAddress(transport, host string, port int) string {
+	if transport == "" {
+		transport = "udp"
+	}
+	if port == 0 {
+		port = 161
+	}
+	return transport + "://" + net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(port))
+}
+
+// parseSNMPAgentAddress 解析 [transport://]host[:port] 形式的目标地址
+func parseSNMPAgentAddress(address string) (transport, host string, port int) {
+	if i := strings.Index(address, "://"); i >= 0 {
+		transport, address = address[:i], address[i+3:]
+	}
+	host = address
+	if h, p, err := net.SplitHostPort(address); err == nil {
+		if n, err := strconv.Atoi(p); err == nil {
+			host, port = h, n
+		}
+	}
+	return transport, strings.Trim(host, "[]"), port
+}
+
+// normalizeOID 去掉 OID 开头的点号，gosnmp 返回的 OID 均以点号开头
+func normalizeOID(oid string) string {
+	return strings.TrimPrefix(strings.TrimSpace(oid), ".")
+}
+
+// hasSNMPValue 判断 PDU 是否携带实际值（排除 noSuchObject 等异常）
+func hasSNMPValue(pdu gosnmp.SnmpPDU) bool {
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return false
+	default:
+		return true
+	}
+}
+
+// encodeSetValue 将读取到的值编码为可以重新 SET 的类型和字符串形式
+func encodeSetValue(pdu gosnmp.SnmpPDU) (string, string) {
+	switch pdu.Type {
+	case gosnmp.Integer:
+		return "integer", gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.OctetString:
+		b, _ := pdu.Value.([]byte)
+		if isPrintable(b) {
+			return "string", string(b)
+		}
+		return "hexstring", hex.EncodeToString(b)
+	case gosnmp.Counter32:
+		return "counter32", gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.Counter64:
+		return "counter64", gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.Gauge32:
+		return "gauge32", gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.TimeTicks:
+		return "timeticks", gosnmp.ToBigInt(pdu.Value).String()
+	case gosnmp.IPAddress:
+		return "ipaddress", fmt.Sprint(pdu.Value)
+	case gosnmp.ObjectIdentifier:
+		return "oid", normalizeOID(fmt.Sprint(pdu.Value))
+	default:
+		return "", ""
+	}
+}
+
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// mibSyntax MIB SYNTAX 子句中与 SET 校验相关的信息
+type mibSyntax struct {
+	base     string
+	setTypes []string
+	ranges   [][2]int64
+	sizes    [][2]int64
+	enums    map[string]int64
+}
+
+// MIB 基础类型及常用文本约定对应的 SET 类型
+var mibSyntaxSetTypes = map[string][]string{
+	"INTEGER":              {"integer"},
+	"INTEGER32":            {"integer"},
+	"TRUTHVALUE":           {"integer"},
+	"ROWSTATUS":            {"integer"},
+	"STORAGETYPE":          {"integer"},
+	"TESTANDINCR":          {"integer"},
+	"TIMEINTERVAL":         {"integer"},
+	"INTERFACEINDEX":       {"integer"},
+	"INTERFACEINDEXORZERO": {"integer"},
+	"INETADDRESSTYPE":      {"integer"},
+	"INETPORTNUMBER":       {"gauge32", "unsigned32"},
+	"OCTET STRING":         {"string", "hexstring"},
+	"DISPLAYSTRING":        {"string", "hexstring"},
+	"SNMPADMINSTRING":      {"string", "hexstring"},
+	"OWNERSTRING":          {"string", "hexstring"},
+	"PHYSADDRESS":          {"hexstring", "string"},
+	"MACADDRESS":           {"hexstring", "string"},
+	"DATEANDTIME":          {"hexstring", "string"},
+	"INETADDRESS":          {"hexstring", "string"},
+	"BITS":                 {"hexstring", "string"},
+	"OBJECT IDENTIFIER":    {"oid"},
+	"AUTONOMOUSTYPE":       {"oid"},
+	"ROWPOINTER":           {"oid"},
+	"VARIABLEPOINTER":      {"oid"},
+	"IPADDRESS":            {"ipaddress"},
+	"COUNTER":              {"counter32"},
+	"COUNTER32":            {"counter32"},
+	"COUNTER64":            {"counter64"},
+	"GAUGE":                {"gauge32", "unsigned32"},
+	"GAUGE32":              {"gauge32", "unsigned32"},
+	"UNSIGNED32":           {"gauge32", "unsigned32"},
+	"TIMETICKS":            {"timeticks"},
+	"TIMESTAMP":            {"timeticks"},
+}
+
+var (
+	mibEnumPattern  = regexp.MustCompile(`([A-Za-z][\w-]*)\s*\(\s*(-?\d+)\s*\)`)
+	mibSizePattern  = regexp.MustCompile(`(?i)SIZE\s*\(([^)]*)\)`)
+	mibRangePattern = regexp.MustCompile(`\(([^()]*\d[^()]*)\)`)
+	mibSpacePattern = regexp.MustCompile(`\s+`)
+
+	numericOIDPattern = regexp.MustCompile(`^\.?\d+(\.\d+)*$`)
+)
+
+// parseMIBSyntax 解析 SYNTAX 子句，例如 "INTEGER { up(1), down(2) }"、"DisplayString (SIZE (0..255))"
+func parseMIBSyntax(syntax string) mibSyntax {
+	result := mibSyntax{enums: map[string]int64{}}
+	syntax = strings.TrimSpace(syntax)
+
+	base := syntax
+	if idx := strings.IndexAny(base, "({"); idx >= 0 {
+		base = base[:idx]
+	}
+	result.base = mibSpacePattern.ReplaceAllString(strings.ToUpper(strings.TrimSpace(base)), " ")
+	result.setTypes = mibSyntaxSetTypes[result.base]
+
+	if open := strings.Index(syntax, "{"); open >= 0 {
+		if end := strings.Index(syntax[open:], "}"); end >= 0 {
+			for _, match := range mibEnumPattern.FindAllStringSubmatch(syntax[open:open+end], -1) {
+				if n, err := strconv.ParseInt(match[2], 10, 64); err == nil {
+					result.enums[match[1]] = n
+				}
+			}
+		}
+	}
+
+	if match := mibSizePattern.FindStringSubmatch(syntax); match != nil {
+		result.sizes = parseMIBRanges(match[1])
+	} else if match := mibRangePattern.FindStringSubmatch(syntax); match != nil && len(result.enums) == 0 {
+		result.ranges = parseMIBRanges(match[1])
+	}
+
+	return result
+}
+
+// parseMIBRanges 解析 "0..255 | 1024" 形式的取值范围
+func parseMIBRanges(spec string) [][2]int64 {
+	var ranges [][2]int64
+	for _, part := range strings.Split(spec, "|") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "..", 2)
+		low, err := strconv.ParseInt(strings.TrimSpace(bounds[0]), 10, 64)
+		if err != nil {
+			continue
+		}
+		high := low
+		if len(bounds) == 2 {
+			if high, err = strconv.ParseInt(strings.TrimSpace(bounds[1]), 10, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, [2]int64{low, high})
+	}
+	return ranges
+}
+
+func inMIBRanges(n int64, ranges [][2]int64) bool {
+	if len(ranges) == 0 {
+		return true
+	}
+	for _, r := range ranges {
+		if n >= r[0] && n <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func formatMIBRanges(ranges [][2]int64) string {
+	parts := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		if r[0] == r[1] {
+			parts = append(parts, strconv.FormatInt(r[0], 10))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d..%d", r[0], r[1]))
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// convertSetValue 将请求中的值转换为 gosnmp 需要的 Go 类型，并检查枚举、取值范围和长度限制
+func convertSetValue(setType string, value interface{}, syntax mibSyntax) (interface{}, error) {
+	switch setType {
+	case "integer":
+		if label, ok := value.(string); ok {
+			if n, exists := syntax.enums[label]; exists {
+				return int(n), nil
+			}
+		}
+		n, err := toInt64(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(syntax.enums) > 0 {
+			valid := false
+			for _, v := range syntax.enums {
+				if v == n {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, fmt.Errorf("value %d is not one of the enumerations defined in the MIB", n)
+			}
+		}
+		if !inMIBRanges(n, syntax.ranges) {
+			return nil, fmt.Errorf("value %d is outside the allowed range (%s)", n, formatMIBRanges(syntax.ranges))
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("value %d does not fit in a 32-bit integer", n)
+		}
+		return int(n), nil
+
+	case "string", "hexstring":
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value for type %s must be a string", setType)
+		}
+		data := []byte(str)
+		if setType == "hexstring" {
+			cleaned := strings.NewReplacer(":", "", " ", "", "-", "").Replace(strings.TrimPrefix(str, "0x"))
+			decoded, err := hex.DecodeString(cleaned)
+			if err != nil {
+				return nil, fmt.Errorf("invalid hex string: %v", err)
+			}
+			data = decoded
+		}
+		if !inMIBRanges(int64(len(data)), syntax.sizes) {
+			return nil, fmt.Errorf("length %d is outside the allowed size (%s)", len(data), formatMIBRanges(syntax.sizes))
+		}
+		return data, nil
+
+	case "counter32", "gauge32", "unsigned32", "timeticks":
+		n, err := toInt64(value)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 || n > math.MaxUint32 {
+			return nil, fmt.Errorf("value %d does not fit in an unsigned 32-bit integer", n)
+		}
+		if !inMIBRanges(n, syntax.ranges) {
+			return nil, fmt.Errorf("value %d is outside the allowed range (%s)", n, formatMIBRanges(syntax.ranges))
+		}
+		return uint32(n), nil
+
+	case "counter64":
+		n, err := toInt64(value)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("value %d must not be negative", n)
+		}
+		return uint64(n), nil
+
+	case "ipaddress":
+		str, ok := value.(string)
+		if !ok || net.ParseIP(str) == nil || net.ParseIP(str).To4() == nil {
+			return nil, fmt.Errorf("value %v is not a valid IPv4 address", value)
+		}
+		return str, nil
+
+	case "oid":
+		str, ok := value.(string)
+		if !ok || !numericOIDPattern.MatchString(str) {
+			return nil, fmt.Errorf("value %v is not a valid numeric OID", value)
+		}
+		return "." + normalizeOID(str), nil
+	}
+
+	return nil, fmt.Errorf("unsupported SNMP type: %q", setType)
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("value %v is not an integer", v)
+		}
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case json.Number:
+		return v.Int64()
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value %q is not an integer", v)
+		}
+		return n, nil
+	default:
+		return 0, fmt.Errorf("value %v is not an integer", value)
+	}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
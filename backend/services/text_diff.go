@@ -0,0 +1,209 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffTrace Myers 算法保存的中间状态上限（整数个数），超出时整体按删除加新增输出
+const maxDiffTrace = 32 << 20
+
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	line string
+}
+
+// splitLines 按行拆分文本，统一换行符并忽略末尾空行
+func splitLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// unifiedDiff 生成 unified 格式的行差异，context 为每个变更块前后保留的上下文行数；内容相同时 diff 为空
+func unifiedDiff(fromName, toName string, from, to []string, context int) (diff string, added, removed int) {
+	ops := diffLines(from, to)
+
+	// 每个操作之前已经消耗的旧行数和新行数，用于生成块头的行号
+	fromPos := make([]int, len(ops)+1)
+	toPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		fromPos[i+1], toPos[i+1] = fromPos[i], toPos[i]
+		if op.kind != '+' {
+			fromPos[i+1]++
+		}
+		if op.kind != '-' {
+			toPos[i+1]++
+		}
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	if added == 0 && removed == 0 {
+		return "", 0, 0
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		// 相邻变更之间的相同行不超过 2*context 时合并为一个块
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*context {
+				end += context
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+
+		fromStart, fromCount := fromPos[start], fromPos[end]-fromPos[start]
+		toStart, toCount := toPos[start], toPos[end]-toPos[start]
+		if fromCount > 0 {
+			fromStart++
+		}
+		if toCount > 0 {
+			toStart++
+		}
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", fromStart, fromCount, toStart, toCount)
+		for _, op := range ops[start:end] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			b.WriteByte('\n')
+		}
+		i = end
+	}
+	return b.String(), added, removed
+}
+
+// diffLines 计算两组行之间的最短编辑序列，先去掉相同的首尾再交给 Myers 算法
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b)-prefix-suffix)
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func myersDiff(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceLines(a, b)
+	}
+
+	total := n + m
+	offset := total + 1
+	v := make([]int, 2*total+3)
+	var trace [][]int
+	for d := 0; d <= total; d++ {
+		if (len(trace)+1)*len(v) > maxDiffTrace {
+			return replaceLines(a, b)
+		}
+		trace = append(trace, append([]int(nil), v...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(trace, a, b, offset)
+			}
+		}
+	}
+	return replaceLines(a, b)
+}
+
+// backtrackDiff 从终点沿保存的状态倒推编辑路径
+func backtrackDiff(trace [][]int, a, b []string, offset int) []diffOp {
+	x, y := len(a), len(b)
+	var reversed []diffOp
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, diffOp{'+', b[y-1]})
+			} else {
+				reversed = append(reversed, diffOp{'-', a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	ops := make([]diffOp, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+func replaceLines(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a {
+		ops = append(ops, diffOp{'-', line})
+	}
+	for _, line := range b {
+		ops = append(ops, diffOp{'+', line})
+	}
+	return ops
+}
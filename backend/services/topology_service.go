@@ -0,0 +1,702 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gosnmp/gosnmp"
+	"gorm.io/gorm"
+
+	"mib-platform/models"
+	"mib-platform/utils"
+)
+
+// LLDP-MIB 和 CISCO-CDP-MIB 邻居表
+const (
+	oidLldpLocPortEntry    = "1.0.8802.1.1.2.1.3.7.1"
+	oidLldpRemEntry        = "1.0.8802.1.1.2.1.4.1.1"
+	oidLldpRemManAddrEntry = "1.0.8802.1.1.2.1.4.2.1" // 管理地址在索引中
+	oidCdpCacheEntry       = "1.3.6.1.4.1.9.9.23.1.2.1.1"
+)
+
+// TopologyService 采集 LLDP/CDP 邻居，匹配已纳管的设备和接口并生成拓扑图
+type TopologyService struct {
+	db          *gorm.DB
+	redis       *redis.Client
+	snmpService *SNMPService
+	collector   *deviceCollector
+}
+
+// NewTopologyService 创建拓扑采集服务，interval 单位为秒，0 表示不定时采集
+func NewTopologyService(db *gorm.DB, redis *redis.Client, logger utils.Logger, interval int) *TopologyService {
+	s := &TopologyService{
+		db:          db,
+		redis:       redis,
+		snmpService: NewSNMPService(db, redis),
+	}
+	s.collector = newDeviceCollector("Topology", db, logger, interval, func(deviceID uint) error {
+		_, err := s.CollectNeighbors(deviceID)
+		return err
+	})
+	return s
+}
+
+// Start 启动定时采集
+func (s *TopologyService) Start() {
+	s.collector.Start()
+}
+
+// Stop 停止定时采集
+func (s *TopologyService) Stop() {
+	s.collector.Stop()
+}
+
+// CollectNeighbors 采集设备的 LLDP 和 CDP 邻居，替换已保存的邻居并记录链路出现和消失
+func (s *TopologyService) CollectNeighbors(deviceID uint) (*models.NeighborCollectResult, error) {
+	device, err := NewDeviceService(s.db, s.redis).GetDevice(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(device.Credentials) == 0 {
+		return nil, fmt.Errorf("device %s has no SNMP credentials", device.Name)
+	}
+
+	start := time.Now()
+	snmp, err := s.snmpService.createSNMPConnection(newDeviceSNMPRequest(device, device.Credentials[0], oidLldpRemEntry))
+	if err != nil {
+		return nil, err
+	}
+	defer snmp.Conn.Close()
+
+	lldp, err := walkLLDPNeighbors(snmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk LLDP-MIB: %v", err)
+	}
+	cdp, err := walkCDPNeighbors(snmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk CDP-MIB: %v", err)
+	}
+
+	result := &models.NeighborCollectResult{
+		DeviceID:  device.ID,
+		Neighbors: append(lldp, cdp...),
+		Protocols: []string{},
+		Events:    []models.TopologyLinkEvent{},
+	}
+	if len(lldp) > 0 {
+		result.Protocols = append(result.Protocols, "lldp")
+	}
+	if len(cdp) > 0 {
+		result.Protocols = append(result.Protocols, "cdp")
+	}
+
+	matcher, err := s.newNeighborMatcher(device.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range result.Neighbors {
+		neighbor := &result.Neighbors[i]
+		neighbor.DeviceID = device.ID
+		neighbor.LastSeen = now
+		matcher.resolve(neighbor)
+	}
+
+	var previous []models.DeviceNeighbor
+	if err := s.db.Where("device_id = ?", device.ID).Find(&previous).Error; err != nil {
+		return nil, err
+	}
+	result.Events = diffNeighbors(device.ID, previous, result.Neighbors, now)
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("device_id = ?", device.ID).Delete(&models.DeviceNeighbor{}).Error; err != nil {
+			return err
+		}
+		if len(result.Neighbors) > 0 {
+			if err := tx.Create(&result.Neighbors).Error; err != nil {
+				return err
+			}
+		}
+		if len(result.Events) > 0 {
+			if err := tx.Create(&result.Events).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save neighbors: %v", err)
+	}
+
+	result.Duration = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+func (s *TopologyService) GetDeviceNeighbors(deviceID uint) ([]models.DeviceNeighbor, error) {
+	var neighbors []models.DeviceNeighbor
+	if err := s.db.Where("device_id = ?", deviceID).Order("local_port_num, protocol").Find(&neighbors).Error; err != nil {
+		return nil, err
+	}
+	return neighbors, nil
+}
+
+// GetLinkEvents 获取链路变化历史，deviceID 为 0 时查询全部设备
+func (s *TopologyService) GetLinkEvents(deviceID uint, eventType string, page, limit int) ([]models.TopologyLinkEvent, int64, error) {
+	var events []models.TopologyLinkEvent
+	var total int64
+
+	query := s.db.Model(&models.TopologyLinkEvent{})
+	if deviceID != 0 {
+		query = query.Where("device_id = ? OR remote_device_id = ?", deviceID, deviceID)
+	}
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+// GetTopology 根据已保存的邻居生成拓扑图；deviceID 不为 0 时只返回该设备及其直连邻居
+func (s *TopologyService) GetTopology(deviceID uint) (*models.TopologyGraph, error) {
+	var neighbors []models.DeviceNeighbor
+	query := s.db.Model(&models.DeviceNeighbor{})
+	if deviceID != 0 {
+		query = query.Where("device_id = ? OR remote_device_id = ?", deviceID, deviceID)
+	}
+	if err := query.Order("device_id, local_port_num, id").Find(&neighbors).Error; err != nil {
+		return nil, err
+	}
+
+	deviceIDs := map[uint]bool{}
+	if deviceID != 0 {
+		deviceIDs[deviceID] = true
+	}
+	interfaceIDs := map[uint]bool{}
+	for _, neighbor := range neighbors {
+		deviceIDs[neighbor.DeviceID] = true
+		if neighbor.RemoteDeviceID != nil {
+			deviceIDs[*neighbor.RemoteDeviceID] = true
+		}
+		if neighbor.RemoteInterfaceID != nil {
+			interfaceIDs[*neighbor.RemoteInterfaceID] = true
+		}
+	}
+
+	devices := map[uint]models.Device{}
+	if len(deviceIDs) > 0 {
+		var list []models.Device
+		if err := s.db.Where("id IN ?", mapKeys(deviceIDs)).Find(&list).Error; err != nil {
+			return nil, err
+		}
+		for _, device := range list {
+			devices[device.ID] = device
+		}
+	}
+	interfaceNames := map[uint]string{}
+	if len(interfaceIDs) > 0 {
+		var list []models.DeviceInterface
+		if err := s.db.Select("id", "name").Where("id IN ?", mapKeys(interfaceIDs)).Find(&list).Error; err != nil {
+			return nil, err
+		}
+		for _, iface := range list {
+			interfaceNames[iface.ID] = iface.Name
+		}
+	}
+
+	graph := &models.TopologyGraph{Nodes: []models.TopologyNode{}, Links: []models.TopologyLink{}}
+	nodes := map[string]bool{}
+	addDeviceNode := func(id uint) string {
+		nodeID := fmt.Sprintf("device:%d", id)
+		if !nodes[nodeID] {
+			nodes[nodeID] = true
+			device := devices[id]
+			deviceID := id
+			graph.Nodes = append(graph.Nodes, models.TopologyNode{
+				ID:       nodeID,
+				DeviceID: &deviceID,
+				Label:    device.Name,
+				IP:       device.IPAddress,
+				Vendor:   device.Vendor,
+				Model:    device.Model,
+				Type:     device.Type,
+				Status:   device.Status,
+				Known:    true,
+			})
+		}
+		return nodeID
+	}
+	if deviceID != 0 {
+		if _, ok := devices[deviceID]; ok {
+			addDeviceNode(deviceID)
+		}
+	}
+
+	links := map[string]int{}
+	for _, neighbor := range neighbors {
+		source := addDeviceNode(neighbor.DeviceID)
+
+		var target string
+		if neighbor.RemoteDeviceID != nil {
+			target = addDeviceNode(*neighbor.RemoteDeviceID)
+		} else {
+			label := firstNonEmpty(neighbor.RemoteSysName, neighbor.RemoteAddress, neighbor.RemoteChassisID)
+			target = "external:" + label
+			if !nodes[target] {
+				nodes[target] = true
+				graph.Nodes = append(graph.Nodes, models.TopologyNode{
+					ID:     target,
+					Label:  label,
+					IP:     neighbor.RemoteAddress,
+					Vendor: neighbor.RemotePlatform,
+				})
+			}
+		}
+
+		targetPort := firstNonEmpty(neighbor.RemotePortID, neighbor.RemotePortDescr)
+		if neighbor.RemoteInterfaceID != nil && interfaceNames[*neighbor.RemoteInterfaceID] != "" {
+			targetPort = interfaceNames[*neighbor.RemoteInterfaceID]
+		}
+
+		// 两端各自上报的同一链路按无序端点去重
+		a, b := source+"|"+neighbor.LocalPort, target+"|"+targetPort
+		if b < a {
+			a, b = b, a
+		}
+		key := a + "~" + b
+		if i, ok := links[key]; ok {
+			link := &graph.Links[i]
+			if !containsString(link.Protocols, neighbor.Protocol) {
+				link.Protocols = append(link.Protocols, neighbor.Protocol)
+			}
+			continue
+		}
+		links[key] = len(graph.Links)
+		graph.Links = append(graph.Links, models.TopologyLink{
+			Source:     source,
+			Target:     target,
+			SourcePort: neighbor.LocalPort,
+			TargetPort: targetPort,
+			Protocols:  []string{neighbor.Protocol},
+		})
+	}
+
+	sort.SliceStable(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	return graph, nil
+}
+
+// RenderTopologyDOT 将拓扑图输出为 Graphviz DOT
+func RenderTopologyDOT(graph *models.TopologyGraph) string {
+	var b strings.Builder
+	b.WriteString("graph topology {\n")
+	b.WriteString("  node [shape=box, fontname=\"Helvetica\"];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+	for _, node := range graph.Nodes {
+		label := node.Label
+		if node.IP != "" && node.IP != node.Label {
+			label += "\\n" + node.IP
+		}
+		attrs := fmt.Sprintf("label=%s", dotQuote(label))
+		if !node.Known {
+			attrs += ", style=dashed"
+		} else if node.Status == "offline" {
+			attrs += ", color=red"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.ID), attrs)
+	}
+	for _, link := range graph.Links {
+		fmt.Fprintf(&b, "  %s -- %s [taillabel=%s, headlabel=%s, label=%s];\n",
+			dotQuote(link.Source), dotQuote(link.Target),
+			dotQuote(link.SourcePort), dotQuote(link.TargetPort),
+			dotQuote(strings.Join(link.Protocols, ",")))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return "\"" + s + "\""
+}
+
+// walkLLDPNeighbors 读取 lldpRemTable、lldpRemManAddrTable 和 lldpLocPortTable
+func walkLLDPNeighbors(snmp *gosnmp.GoSNMP) ([]models.DeviceNeighbor, error) {
+	type localPort struct{ id, descr string }
+	localPorts := map[int]*localPort{}
+	err := walkSNMPTable(snmp, oidLldpLocPortEntry, func(column int, index []int, pdu gosnmp.SnmpPDU) {
+		if len(index) != 1 {
+			return
+		}
+		port, ok := localPorts[index[0]]
+		if !ok {
+			port = &localPort{}
+			localPorts[index[0]] = port
+		}
+		switch column {
+		case 3:
+			port.id = lldpIDString(0, pdu)
+		case 4:
+			port.descr = snmpString(pdu)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 索引：lldpRemTimeMark.lldpRemLocalPortNum.lldpRemIndex
+	type remote struct {
+		neighbor       models.DeviceNeighbor
+		chassisSubtype int
+		portSubtype    int
+	}
+	remotes := map[string]*remote{}
+	var order []string
+	err = walkSNMPTable(snmp, oidLldpRemEntry, func(column int, index []int, pdu gosnmp.SnmpPDU) {
+		if len(index) != 3 {
+			return
+		}
+		key := fmt.Sprintf("%d.%d", index[1], index[2])
+		r, ok := remotes[key]
+		if !ok {
+			r = &remote{neighbor: models.DeviceNeighbor{Protocol: "lldp", LocalPortNum: index[1]}}
+			remotes[key] = r
+			order = append(order, key)
+		}
+		switch column {
+		case 4:
+			r.chassisSubtype = int(gosnmp.ToBigInt(pdu.Value).Int64())
+		case 5:
+			r.neighbor.RemoteChassisID = lldpIDString(r.chassisSubtype, pdu)
+		case 6:
+			r.portSubtype = int(gosnmp.ToBigInt(pdu.Value).Int64())
+		case 7:
+			r.neighbor.RemotePortID = lldpIDString(lldpPortToChassisSubtype(r.portSubtype), pdu)
+		case 8:
+			r.neighbor.RemotePortDescr = snmpString(pdu)
+		case 9:
+			r.neighbor.RemoteSysName = snmpString(pdu)
+		case 10:
+			r.neighbor.RemoteSysDescr = snmpString(pdu)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 管理地址：...<timeMark>.<localPort>.<remIndex>.<addrSubtype>.<len>.<addr...>
+	err = walkSNMPTable(snmp, oidLldpRemManAddrEntry, func(column int, index []int, pdu gosnmp.SnmpPDU) {
+		// 只需 lldpRemManAddrIfSubtype 一列即可得到全部索引
+		if column != 3 || len(index) < 6 {
+			return
+		}
+		r, ok := remotes[fmt.Sprintf("%d.%d", index[1], index[2])]
+		if !ok {
+			return
+		}
+		addr := index[5:]
+		if len(addr) != index[4] {
+			return
+		}
+		// 优先 IPv4 地址
+		if index[3] == 1 && len(addr) == 4 {
+			r.neighbor.RemoteAddress = fmt.Sprintf("%d.%d.%d.%d", addr[0], addr[1], addr[2], addr[3])
+		} else if index[3] == 2 && len(addr) == 16 && r.neighbor.RemoteAddress == "" {
+			ip := make(net.IP, 16)
+			for i, v := range addr {
+				ip[i] = byte(v)
+			}
+			r.neighbor.RemoteAddress = ip.String()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]models.DeviceNeighbor, 0, len(order))
+	for _, key := range order {
+		neighbor := remotes[key].neighbor
+		if port, ok := localPorts[neighbor.LocalPortNum]; ok {
+			neighbor.LocalPort = firstNonEmpty(port.id, port.descr)
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+	return neighbors, nil
+}
+
+// walkCDPNeighbors 读取 cdpCacheTable，索引为 cdpCacheIfIndex.cdpCacheDeviceIndex
+func walkCDPNeighbors(snmp *gosnmp.GoSNMP) ([]models.DeviceNeighbor, error) {
+	entries := map[string]*models.DeviceNeighbor{}
+	var order []string
+	err := walkSNMPTable(snmp, oidCdpCacheEntry, func(column int, index []int, pdu gosnmp.SnmpPDU) {
+		if len(index) != 2 {
+			return
+		}
+		key := fmt.Sprintf("%d.%d", index[0], index[1])
+		neighbor, ok := entries[key]
+		if !ok {
+			neighbor = &models.DeviceNeighbor{Protocol: "cdp", LocalPortNum: index[0]}
+			entries[key] = neighbor
+			order = append(order, key)
+		}
+		switch column {
+		case 4:
+			if b, ok := pdu.Value.([]byte); ok && len(b) == 4 {
+				neighbor.RemoteAddress = net.IP(b).String()
+			}
+		case 5:
+			neighbor.RemoteSysDescr = snmpString(pdu)
+		case 6:
+			neighbor.RemoteSysName = snmpString(pdu)
+			neighbor.RemoteChassisID = neighbor.RemoteSysName
+		case 7:
+			neighbor.RemotePortID = snmpString(pdu)
+		case 8:
+			neighbor.RemotePlatform = snmpString(pdu)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]models.DeviceNeighbor, 0, len(order))
+	for _, key := range order {
+		neighbors = append(neighbors, *entries[key])
+	}
+	return neighbors, nil
+}
+
+// walkSNMPTable 遍历表项，回调参数为列号和索引子标识
+func walkSNMPTable(snmp *gosnmp.GoSNMP, entryOID string, fn func(column int, index []int, pdu gosnmp.SnmpPDU)) error {
+	walk := snmp.BulkWalk
+	if snmp.Version == gosnmp.Version1 {
+		walk = snmp.Walk
+	}
+	return walk(entryOID, func(pdu gosnmp.SnmpPDU) error {
+		if !hasSNMPValue(pdu) {
+			return nil
+		}
+		subids, err := parseOIDSubids(strings.TrimPrefix(normalizeOID(pdu.Name), entryOID+"."))
+		if err != nil || len(subids) < 2 {
+			return nil
+		}
+		fn(subids[0], subids[1:], pdu)
+		return nil
+	})
+}
+
+// lldpIDString 按 LldpChassisIdSubtype 解码 ID：macAddress(4) 转为 MAC，networkAddress(5) 转为 IP
+func lldpIDString(subtype int, pdu gosnmp.SnmpPDU) string {
+	b, ok := pdu.Value.([]byte)
+	if !ok {
+		return snmpString(pdu)
+	}
+	switch {
+	case subtype == 4 && len(b) == 6:
+		return formatMAC(b)
+	case subtype == 5 && len(b) == 5 && b[0] == 1:
+		return net.IP(b[1:]).String()
+	case subtype == 5 && len(b) == 17 && b[0] == 2:
+		return net.IP(b[1:]).String()
+	case isPrintable(b):
+		return strings.TrimSpace(string(b))
+	case len(b) == 6:
+		return formatMAC(b)
+	}
+	return fmt.Sprintf("%X", b)
+}
+
+// lldpPortToChassisSubtype 将 LldpPortIdSubtype 中的 macAddress(3)、networkAddress(4) 映射到 chassis 子类型编号
+func lldpPortToChassisSubtype(subtype int) int {
+	switch subtype {
+	case 3:
+		return 4
+	case 4:
+		return 5
+	}
+	return 0
+}
+
+// neighborMatcher 将邻居与已纳管的设备和接口对应起来
+type neighborMatcher struct {
+	db           *gorm.DB
+	localIfaces  []models.DeviceInterface
+	byIP         map[string]uint
+	byName       map[string]uint
+	remoteIfaces map[uint][]models.DeviceInterface
+}
+
+func (s *TopologyService) newNeighborMatcher(deviceID uint) (*neighborMatcher, error) {
+	m := &neighborMatcher{
+		db:           s.db,
+		byIP:         map[string]uint{},
+		byName:       map[string]uint{},
+		remoteIfaces: map[uint][]models.DeviceInterface{},
+	}
+	if err := s.db.Where("device_id = ? AND present = ?", deviceID, true).Find(&m.localIfaces).Error; err != nil {
+		return nil, err
+	}
+
+	var devices []models.Device
+	if err := s.db.Select("id", "name", "hostname", "ip_address").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		if device.IPAddress != "" {
+			m.byIP[device.IPAddress] = device.ID
+		}
+		for _, name := range []string{device.Name, device.Hostname} {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				m.byName[name] = device.ID
+				m.byName[shortHostname(name)] = device.ID
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *neighborMatcher) resolve(neighbor *models.DeviceNeighbor) {
+	if iface := matchInterface(m.localIfaces, neighbor.LocalPort, ""); iface != nil {
+		neighbor.LocalInterfaceID = &iface.ID
+		neighbor.LocalPort = iface.Name
+	} else {
+		for i := range m.localIfaces {
+			if m.localIfaces[i].IfIndex == neighbor.LocalPortNum {
+				neighbor.LocalInterfaceID = &m.localIfaces[i].ID
+				neighbor.LocalPort = m.localIfaces[i].Name
+				break
+			}
+		}
+	}
+	if neighbor.LocalPort == "" {
+		neighbor.LocalPort = strconv.Itoa(neighbor.LocalPortNum)
+	}
+
+	// 依次按管理地址、系统名称、机箱 MAC 匹配设备
+	var remoteID uint
+	if id, ok := m.byIP[neighbor.RemoteAddress]; ok && neighbor.RemoteAddress != "" {
+		remoteID = id
+	} else if name := strings.ToLower(neighbor.RemoteSysName); name != "" {
+		if id, ok := m.byName[name]; ok {
+			remoteID = id
+		} else if id, ok := m.byName[shortHostname(name)]; ok {
+			remoteID = id
+		}
+	}
+	if remoteID == 0 && isMACString(neighbor.RemoteChassisID) {
+		var iface models.DeviceInterface
+		if err := m.db.Where("mac = ?", neighbor.RemoteChassisID).First(&iface).Error; err == nil {
+			remoteID = iface.DeviceID
+		}
+	}
+	if remoteID == 0 {
+		return
+	}
+	neighbor.RemoteDeviceID = &remoteID
+
+	ifaces, ok := m.remoteIfaces[remoteID]
+	if !ok {
+		m.db.Where("device_id = ? AND present = ?", remoteID, true).Find(&ifaces)
+		m.remoteIfaces[remoteID] = ifaces
+	}
+	if iface := matchInterface(ifaces, neighbor.RemotePortID, neighbor.RemotePortDescr); iface != nil {
+		neighbor.RemoteInterfaceID = &iface.ID
+	}
+}
+
+// matchInterface 按名称、描述或 MAC 匹配接口，忽略大小写
+func matchInterface(ifaces []models.DeviceInterface, candidates ...string) *models.DeviceInterface {
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		for i := range ifaces {
+			iface := &ifaces[i]
+			if strings.EqualFold(iface.Name, candidate) || strings.EqualFold(iface.Descr, candidate) ||
+				(iface.MAC != "" && strings.EqualFold(iface.MAC, candidate)) {
+				return iface
+			}
+		}
+	}
+	return nil
+}
+
+// diffNeighbors 比较前后两次采集的邻居，生成链路出现和消失事件
+func diffNeighbors(deviceID uint, previous, current []models.DeviceNeighbor, now time.Time) []models.TopologyLinkEvent {
+	key := func(n *models.DeviceNeighbor) string {
+		return strings.Join([]string{n.Protocol, n.LocalPort, firstNonEmpty(n.RemoteChassisID, n.RemoteSysName), n.RemotePortID}, "|")
+	}
+	event := func(n *models.DeviceNeighbor, eventType string) models.TopologyLinkEvent {
+		return models.TopologyLinkEvent{
+			DeviceID:       deviceID,
+			EventType:      eventType,
+			Protocol:       n.Protocol,
+			LocalPort:      n.LocalPort,
+			RemoteName:     firstNonEmpty(n.RemoteSysName, n.RemoteAddress, n.RemoteChassisID),
+			RemotePort:     firstNonEmpty(n.RemotePortID, n.RemotePortDescr),
+			RemoteDeviceID: n.RemoteDeviceID,
+			CreatedAt:      now,
+		}
+	}
+
+	old := make(map[string]*models.DeviceNeighbor, len(previous))
+	for i := range previous {
+		old[key(&previous[i])] = &previous[i]
+	}
+
+	var events []models.TopologyLinkEvent
+	for i := range current {
+		k := key(&current[i])
+		if _, ok := old[k]; ok {
+			delete(old, k)
+			continue
+		}
+		events = append(events, event(&current[i], "appeared"))
+	}
+	for i := range previous {
+		if _, ok := old[key(&previous[i])]; ok {
+			events = append(events, event(&previous[i], "disappeared"))
+		}
+	}
+	return events
+}
+
+func shortHostname(name string) string {
+	if i := strings.Index(name, "."); i > 0 && net.ParseIP(name) == nil {
+		return name[:i]
+	}
+	return name
+}
+
+func isMACString(s string) bool {
+	_, err := net.ParseMAC(s)
+	return err == nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func mapKeys(m map[uint]bool) []uint {
+	keys := make([]uint, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,662 @@
+package services
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"mib-platform/models"
+)
+
+// zabbixExportVersion 导出格式的版本，Zabbix 6.0 及以后的版本都可以导入
+const zabbixExportVersion = "6.0"
+
+// ZabbixExportRequest 把选中的 OID 导出为 Zabbix 模板
+type ZabbixExportRequest struct {
+	TemplateName     string                 `json:"template_name"`
+	SelectedOIDs     []string               `json:"selected_oids"`
+	AlertTemplateIDs []string               `json:"alert_template_ids"` // 转换为触发器的告警规则模板
+	Format           string                 `json:"format"`             // yaml（默认）或 xml
+	Group            string                 `json:"group"`              // 模板组，默认 Templates/Network devices
+	Interval         string                 `json:"interval"`           // 监控项采集间隔，默认 1m
+	Options          map[string]interface{} `json:"options"`            // lookups、overrides，与 snmp_exporter 的生成选项相同
+}
+
+// ZabbixExportResult 导出的模板内容，Warnings 记录无法转换的告警规则
+type ZabbixExportResult struct {
+	Filename string   `json:"filename"`
+	Format   string   `json:"format"`
+	Content  string   `json:"content"`
+	Items    int      `json:"items"`
+	Rules    int      `json:"discovery_rules"`
+	Triggers int      `json:"triggers"`
+	Warnings []string `json:"warnings"`
+}
+
+type zabbixExport struct {
+	Version   string           `yaml:"version"`
+	Groups    []zabbixGroup    `yaml:"groups"`
+	Templates []zabbixTemplate `yaml:"templates"`
+}
+
+type zabbixGroup struct {
+	UUID string `yaml:"uuid,omitempty"`
+	Name string `yaml:"name"`
+}
+
+type zabbixTemplate struct {
+	UUID           string                `yaml:"uuid"`
+	Template       string                `yaml:"template"`
+	Name           string                `yaml:"name"`
+	Description    string                `yaml:"description,omitempty"`
+	Groups         []zabbixGroup         `yaml:"groups"`
+	Items          []zabbixItem          `yaml:"items,omitempty"`
+	DiscoveryRules []zabbixDiscoveryRule `yaml:"discovery_rules,omitempty"`
+	Valuemaps      []zabbixValuemap      `yaml:"valuemaps,omitempty"`
+}
+
+// zabbixItem 监控项和监控项原型共用，原型的触发器放在 TriggerPrototypes 中
+type zabbixItem struct {
+	UUID              string                `yaml:"uuid"`
+	Name              string                `yaml:"name"`
+	Type              string                `yaml:"type"`
+	SNMPOID           string                `yaml:"snmp_oid"`
+	Key               string                `yaml:"key"`
+	Delay             string                `yaml:"delay"`
+	ValueType         string                `yaml:"value_type"`
+	Units             string                `yaml:"units,omitempty"`
+	Description       string                `yaml:"description,omitempty"`
+	Valuemap          *zabbixGroup          `yaml:"valuemap,omitempty"`
+	Preprocessing     []zabbixPreprocessing `yaml:"preprocessing,omitempty"`
+	Triggers          []zabbixTrigger       `yaml:"triggers,omitempty"`
+	TriggerPrototypes []zabbixTrigger       `yaml:"trigger_prototypes,omitempty"`
+}
+
+type zabbixPreprocessing struct {
+	Type       string   `yaml:"type"`
+	Parameters []string `yaml:"parameters"`
+}
+
+type zabbixTrigger struct {
+	UUID        string `yaml:"uuid"`
+	Expression  string `yaml:"expression"`
+	Name        string `yaml:"name"`
+	Priority    string `yaml:"priority"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type zabbixDiscoveryRule struct {
+	UUID           string       `yaml:"uuid"`
+	Name           string       `yaml:"name"`
+	Type           string       `yaml:"type"`
+	SNMPOID        string       `yaml:"snmp_oid"`
+	Key            string       `yaml:"key"`
+	Delay          string       `yaml:"delay"`
+	ItemPrototypes []zabbixItem `yaml:"item_prototypes"`
+}
+
+type zabbixValuemap struct {
+	UUID     string          `yaml:"uuid"`
+	Name     string          `yaml:"name"`
+	Mappings []zabbixMapping `yaml:"mappings"`
+}
+
+type zabbixMapping struct {
+	Value    string `yaml:"value"`
+	Newvalue string `yaml:"newvalue"`
+}
+
+// zabbixItemRef 指标名对应的监控项，rule 为 -1 表示标量监控项
+type zabbixItemRef struct {
+	rule  int
+	index int
+	macro string
+}
+
+// zabbixTemplateBuilder 生成模板时按指标名记录监控项，用于把告警规则挂到对应的监控项上
+type zabbixTemplateBuilder struct {
+	template zabbixTemplate
+	refs     map[string]zabbixItemRef
+}
+
+// ExportZabbixTemplate 把选中的 OID 导出为 Zabbix 模板：标量生成监控项，表格列按 MIB 表生成自动发现规则，
+// 枚举生成值映射，告警规则模板转换为触发器
+func (s *ConfigService) ExportZabbixTemplate(req ZabbixExportRequest) (*ZabbixExportResult, error) {
+	format := strings.ToLower(req.Format)
+	switch format {
+	case "", "yaml", "yml":
+		format = "yaml"
+	case "xml":
+	default:
+		return nil, fmt.Errorf("unsupported zabbix export format: %s", req.Format)
+	}
+	if req.TemplateName == "" {
+		return nil, fmt.Errorf("template_name is required")
+	}
+	group := req.Group
+	if group == "" {
+		group = "Templates/Network devices"
+	}
+	interval := req.Interval
+	if interval == "" {
+		interval = "1m"
+	}
+
+	options, err := parseSNMPGeneratorOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
+	builder := newSNMPModuleBuilder(s.db)
+	_, metrics, err := builder.build(req.SelectedOIDs, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("no SNMP objects found for the selected OIDs")
+	}
+
+	t := &zabbixTemplateBuilder{
+		template: zabbixTemplate{
+			UUID:        zabbixUUID(req.TemplateName),
+			Template:    req.TemplateName,
+			Name:        req.TemplateName,
+			Description: "Generated by MIB platform",
+			Groups:      []zabbixGroup{{Name: group}},
+		},
+		refs: make(map[string]zabbixItemRef),
+	}
+	if err := t.addMetrics(builder, metrics, interval); err != nil {
+		return nil, err
+	}
+
+	result := &ZabbixExportResult{Format: format, Warnings: []string{}}
+	if len(req.AlertTemplateIDs) > 0 {
+		var alerts []models.AlertRuleTemplate
+		if err := s.db.Where("id IN ?", req.AlertTemplateIDs).Order("name").Find(&alerts).Error; err != nil {
+			return nil, fmt.Errorf("failed to load alert rule templates: %v", err)
+		}
+		found := make(map[string]bool, len(alerts))
+		for i := range alerts {
+			found[alerts[i].ID] = true
+			if err := t.addTrigger(&alerts[i]); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("alert template %s: %v", alerts[i].Name, err))
+			} else {
+				result.Triggers++
+			}
+		}
+		for _, id := range req.AlertTemplateIDs {
+			if !found[id] {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("alert template %s not found", id))
+			}
+		}
+	}
+
+	export := zabbixExport{
+		Version:   zabbixExportVersion,
+		Groups:    []zabbixGroup{{UUID: zabbixUUID("group", group), Name: group}},
+		Templates: []zabbixTemplate{t.template},
+	}
+	data, err := yaml.Marshal(map[string]zabbixExport{"zabbix_export": export})
+	if err == nil && format == "xml" {
+		data, err = zabbixXML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal zabbix template: %v", err)
+	}
+
+	result.Filename = fmt.Sprintf("%s.%s", strings.ReplaceAll(req.TemplateName, " ", "_"), format)
+	result.Content = string(data)
+	result.Items = len(t.template.Items)
+	result.Rules = len(t.template.DiscoveryRules)
+	return result, nil
+}
+
+// addMetrics 标量指标生成监控项；表格列按所在表（列 OID 去掉 Entry 和列号）分组生成自动发现规则，
+// 同表的字符串列和 lookup 作为 LLD 宏
+func (t *zabbixTemplateBuilder) addMetrics(b *snmpModuleBuilder, metrics []SNMPMetric, interval string) error {
+	tableIndex := make(map[string]int)
+	macros := make(map[int]string)
+	for _, metric := range metrics {
+		oid := normalizeOID(metric.OID)
+		object, err := b.object(oid)
+		if err != nil {
+			return err
+		}
+		item := t.item(metric, object, interval)
+
+		if len(metric.Indexes) == 0 {
+			// MIB 库中有定义的标量对象补上实例后缀 .0，未知 OID 按原样采集
+			if object != nil {
+				item.SNMPOID += ".0"
+			}
+			t.refs[metric.Name] = zabbixItemRef{rule: -1, index: len(t.template.Items)}
+			t.template.Items = append(t.template.Items, item)
+			continue
+		}
+
+		tableOID := oid
+		for n := 0; n < 2; n++ {
+			if i := strings.LastIndex(tableOID, "."); i > 0 {
+				tableOID = tableOID[:i]
+			}
+		}
+		i, ok := tableIndex[tableOID]
+		if !ok {
+			name := strings.ReplaceAll(tableOID, ".", "_")
+			table, err := b.object(tableOID)
+			if err != nil {
+				return err
+			}
+			if table != nil {
+				name = table.Name
+			}
+			t.template.DiscoveryRules = append(t.template.DiscoveryRules, zabbixDiscoveryRule{
+				UUID:  zabbixUUID(t.template.Template, name+".discovery"),
+				Name:  name + " discovery",
+				Type:  "SNMP_AGENT",
+				Key:   name + ".discovery",
+				Delay: "1h",
+			})
+			i = len(t.template.DiscoveryRules) - 1
+			tableIndex[tableOID] = i
+		}
+		rule := &t.template.DiscoveryRules[i]
+
+		// 同表的字符串列和 lookup 目标可以在发现时一并取值
+		columns := []string{}
+		if metric.Type == "DisplayString" || metric.Type == "OctetString" {
+			columns = append(columns, metric.Name, oid)
+		}
+		for _, lookup := range metric.Lookups {
+			if lookup.OID != "" && strings.HasPrefix(lookup.OID, tableOID+".") {
+				columns = append(columns, lookup.LabelName, lookup.OID)
+			}
+		}
+		for n := 0; n < len(columns); n += 2 {
+			macro := "{#" + strings.ToUpper(columns[n]) + "}"
+			if !strings.Contains(rule.SNMPOID, macro+",") {
+				rule.SNMPOID = zabbixAppendDiscoveryOID(rule.SNMPOID, macro, columns[n+1])
+			}
+			if macros[i] == "" {
+				macros[i] = macro
+			}
+		}
+
+		item.Key = fmt.Sprintf("%s[{#SNMPINDEX}]", metric.Name)
+		item.SNMPOID += ".{#SNMPINDEX}"
+		item.UUID = zabbixUUID(t.template.Template, item.Key)
+		t.refs[metric.Name] = zabbixItemRef{rule: i, index: len(rule.ItemPrototypes)}
+		rule.ItemPrototypes = append(rule.ItemPrototypes, item)
+	}
+
+	for i := range t.template.DiscoveryRules {
+		rule := &t.template.DiscoveryRules[i]
+		if rule.SNMPOID == "" {
+			// 没有字符串列时用第一列做发现，只得到 {#SNMPINDEX}
+			first := strings.TrimSuffix(rule.ItemPrototypes[0].SNMPOID, ".{#SNMPINDEX}")
+			rule.SNMPOID = zabbixAppendDiscoveryOID("", "{#SNMPVALUE}", first)
+		}
+		macro := macros[i]
+		if macro == "" {
+			macro = "{#SNMPINDEX}"
+		}
+		for j := range rule.ItemPrototypes {
+			rule.ItemPrototypes[j].Name += " on " + macro
+		}
+		for name, ref := range t.refs {
+			if ref.rule == i {
+				ref.macro = macro
+				t.refs[name] = ref
+			}
+		}
+	}
+	return nil
+}
+
+// item 按指标类型确定值类型、单位、预处理和值映射
+func (t *zabbixTemplateBuilder) item(metric SNMPMetric, object *models.OID, interval string) zabbixItem {
+	item := zabbixItem{
+		UUID:      zabbixUUID(t.template.Template, metric.Name),
+		Name:      metric.Name,
+		Type:      "SNMP_AGENT",
+		SNMPOID:   normalizeOID(metric.OID),
+		Key:       metric.Name,
+		Delay:     interval,
+		ValueType: "FLOAT",
+	}
+	syntax, units := "", ""
+	if object != nil {
+		item.Description = strings.Join(strings.Fields(object.Description), " ")
+		syntax = strings.ToLower(object.Syntax + " " + object.Type)
+		units = zabbixUnits(object.Units)
+	}
+
+	switch metric.Type {
+	case "counter":
+		// 计数器换算为每秒速率
+		item.Preprocessing = []zabbixPreprocessing{{Type: "CHANGE_PER_SECOND", Parameters: []string{""}}}
+		if units == "B" {
+			units = "Bps"
+		} else if units != "" {
+			units += "/s"
+		}
+	case "gauge", "EnumAsInfo", "EnumAsStateSet":
+		switch {
+		case strings.Contains(syntax, "timeticks"):
+			item.ValueType = "UNSIGNED"
+			item.Preprocessing = []zabbixPreprocessing{{Type: "MULTIPLIER", Parameters: []string{"0.01"}}}
+			units = "uptime"
+		case len(metric.EnumValues) > 0:
+			item.ValueType = "UNSIGNED"
+			item.Valuemap = &zabbixGroup{Name: t.valuemap(metric.Name, metric.EnumValues)}
+		case strings.Contains(syntax, "gauge"), strings.Contains(syntax, "unsigned"):
+			item.ValueType = "UNSIGNED"
+		}
+	case "PhysAddress48":
+		item.ValueType = "CHAR"
+		item.Preprocessing = []zabbixPreprocessing{
+			{Type: "JAVASCRIPT", Parameters: []string{"return value.trim().replace(/\\s+/g, ':').toLowerCase();"}},
+			{Type: "DISCARD_UNCHANGED_HEARTBEAT", Parameters: []string{"1d"}},
+		}
+	default:
+		// 字符串、地址、BITS 和时间等变化很少，没有变化时每天只保存一次
+		item.ValueType = "CHAR"
+		item.Preprocessing = []zabbixPreprocessing{{Type: "DISCARD_UNCHANGED_HEARTBEAT", Parameters: []string{"1d"}}}
+	}
+	item.Units = units
+	return item
+}
+
+// valuemap 为枚举生成值映射，同名且内容相同的映射只生成一次
+func (t *zabbixTemplateBuilder) valuemap(name string, values map[int]string) string {
+	mappings := make([]zabbixMapping, 0, len(values))
+	for _, value := range sortedEnumKeys(values) {
+		mappings = append(mappings, zabbixMapping{Value: strconv.Itoa(value), Newvalue: values[value]})
+	}
+	for _, valuemap := range t.template.Valuemaps {
+		if valuemap.Name == name {
+			return name
+		}
+	}
+	t.template.Valuemaps = append(t.template.Valuemaps, zabbixValuemap{
+		UUID:     zabbixUUID(t.template.Template, "valuemap", name),
+		Name:     name,
+		Mappings: mappings,
+	})
+	return name
+}
+
+// promAlertExpression 可以转换为 Zabbix 触发器的 PromQL：单个指标（可带标签和 rate/*_over_time）与常量比较
+var promAlertExpression = regexp.MustCompile(`^\s*(?:(rate|irate|avg_over_time|min_over_time|max_over_time)\(\s*)?([a-zA-Z_:][a-zA-Z0-9_:]*)\s*(?:\{[^}]*\})?\s*(?:\[(\w+)\]\s*\))?\s*(>=|<=|==|!=|>|<)\s*(-?[0-9.]+(?:[eE][-+]?[0-9]+)?)\s*$`)
+
+var zabbixTriggerFunctions = map[string]string{
+	"rate":          "avg",
+	"avg_over_time": "avg",
+	"min_over_time": "min",
+	"max_over_time": "max",
+}
+
+var zabbixOperators = map[string]string{
+	">": ">", ">=": ">=", "<": "<", "<=": "<=", "==": "=", "!=": "<>",
+}
+
+var zabbixPriorities = map[string]string{
+	"info":     "INFO",
+	"warning":  "WARNING",
+	"average":  "AVERAGE",
+	"major":    "AVERAGE",
+	"high":     "HIGH",
+	"critical": "HIGH",
+	"disaster": "DISASTER",
+}
+
+// addTrigger 把告警规则模板转换为触发器，挂到表达式引用的监控项（或监控项原型）上；
+// 变量取模板中定义的默认值，计数器监控项已换算为速率，rate 按窗口内平均值处理
+func (t *zabbixTemplateBuilder) addTrigger(alert *models.AlertRuleTemplate) error {
+	expression, err := renderAlertExpression(alert)
+	if err != nil {
+		return err
+	}
+	m := promAlertExpression.FindStringSubmatch(expression)
+	if m == nil {
+		return fmt.Errorf("unsupported expression %q", expression)
+	}
+	function, metric, window, operator, value := m[1], m[2], m[3], m[4], m[5]
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		value = strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	if (function == "") != (window == "") {
+		return fmt.Errorf("unsupported expression %q", expression)
+	}
+	ref, ok := t.refs[metric]
+	if !ok {
+		ref, ok = t.refs[strings.TrimSuffix(metric, "_info")]
+	}
+	if !ok {
+		return fmt.Errorf("metric %s is not in the template", metric)
+	}
+
+	var key string
+	if ref.rule < 0 {
+		key = t.template.Items[ref.index].Key
+	} else {
+		key = t.template.DiscoveryRules[ref.rule].ItemPrototypes[ref.index].Key
+	}
+	target := fmt.Sprintf("/%s/%s", t.template.Template, key)
+	operator = zabbixOperators[operator]
+
+	var condition string
+	switch {
+	case function == "irate":
+		condition = fmt.Sprintf("last(%s)%s%s", target, operator, value)
+	case function != "":
+		period, err := zabbixPeriod(window)
+		if err != nil {
+			return err
+		}
+		condition = fmt.Sprintf("%s(%s,%s)%s%s", zabbixTriggerFunctions[function], target, period, operator, value)
+	case alert.Duration != "":
+		// for 持续时间：整个时间段内都满足条件才触发
+		period, err := zabbixPeriod(alert.Duration)
+		if err != nil {
+			return err
+		}
+		switch operator {
+		case ">", ">=":
+			condition = fmt.Sprintf("min(%s,%s)%s%s", target, period, operator, value)
+		case "<", "<=":
+			condition = fmt.Sprintf("max(%s,%s)%s%s", target, period, operator, value)
+		case "=":
+			condition = fmt.Sprintf(`count(%s,%s,"ne","%s")=0`, target, period, value)
+		default:
+			condition = fmt.Sprintf(`count(%s,%s,"eq","%s")=0`, target, period, value)
+		}
+	default:
+		condition = fmt.Sprintf("last(%s)%s%s", target, operator, value)
+	}
+
+	priority, ok := zabbixPriorities[strings.ToLower(alert.Severity)]
+	if !ok {
+		priority = "NOT_CLASSIFIED"
+	}
+	trigger := zabbixTrigger{
+		UUID:        zabbixUUID(t.template.Template, "trigger", alert.ID),
+		Expression:  condition,
+		Name:        alert.Name,
+		Priority:    priority,
+		Description: alert.Description,
+	}
+	if ref.rule < 0 {
+		item := &t.template.Items[ref.index]
+		item.Triggers = append(item.Triggers, trigger)
+	} else {
+		trigger.Name += " on " + ref.macro
+		item := &t.template.DiscoveryRules[ref.rule].ItemPrototypes[ref.index]
+		item.TriggerPrototypes = append(item.TriggerPrototypes, trigger)
+	}
+	return nil
+}
+
+// renderAlertExpression 用变量的默认值渲染告警规则模板的表达式
+func renderAlertExpression(alert *models.AlertRuleTemplate) (string, error) {
+	if !strings.Contains(alert.Expression, "{{") {
+		return alert.Expression, nil
+	}
+	var variables map[string]struct {
+		Default interface{} `json:"default"`
+	}
+	if len(alert.Variables) > 0 {
+		if err := json.Unmarshal(alert.Variables, &variables); err != nil {
+			return "", fmt.Errorf("invalid variables: %v", err)
+		}
+	}
+	data := make(map[string]interface{}, len(variables))
+	for name, variable := range variables {
+		if variable.Default != nil {
+			data[name] = variable.Default
+		}
+	}
+
+	tmpl, err := template.New(alert.Name).Option("missingkey=error").Parse(alert.Expression)
+	if err != nil {
+		return "", fmt.Errorf("invalid expression: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render expression: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// zabbixXML 把 YAML 格式的导出转换为 Zabbix 的 XML 格式：映射的键作为元素名，列表的元素名取单数形式
+func zabbixXML(data []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "    ")
+	var write func(name string, node *yaml.Node) error
+	write = func(name string, node *yaml.Node) error {
+		start := xml.StartElement{Name: xml.Name{Local: name}}
+		switch node.Kind {
+		case yaml.ScalarNode:
+			return enc.EncodeElement(node.Value, start)
+		case yaml.MappingNode, yaml.SequenceNode:
+			if err := enc.EncodeToken(start); err != nil {
+				return err
+			}
+			for i := 0; i < len(node.Content); i++ {
+				var err error
+				if node.Kind == yaml.MappingNode {
+					err = write(node.Content[i].Value, node.Content[i+1])
+					i++
+				} else if name == "preprocessing" {
+					err = write("step", node.Content[i])
+				} else {
+					err = write(strings.TrimSuffix(name, "s"), node.Content[i])
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return enc.EncodeToken(start.End())
+		default:
+			return fmt.Errorf("unexpected YAML node kind %d", node.Kind)
+		}
+	}
+
+	root := doc.Content[0]
+	if err := write(root.Content[0].Value, root.Content[1]); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// zabbixAppendDiscoveryOID 向 discovery[宏,OID,...] 追加一组宏和 OID
+func zabbixAppendDiscoveryOID(snmpOID, macro, oid string) string {
+	if snmpOID == "" {
+		return fmt.Sprintf("discovery[%s,%s]", macro, oid)
+	}
+	return fmt.Sprintf("%s,%s,%s]", strings.TrimSuffix(snmpOID, "]"), macro, oid)
+}
+
+// zabbixPeriod 把 Prometheus 的时间段转换为 Zabbix 的时间后缀格式
+func zabbixPeriod(value string) (string, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < time.Second {
+		return "", fmt.Errorf("invalid duration %q", value)
+	}
+	seconds := int(d / time.Second)
+	switch {
+	case seconds%86400 == 0:
+		return fmt.Sprintf("%dd", seconds/86400), nil
+	case seconds%3600 == 0:
+		return fmt.Sprintf("%dh", seconds/3600), nil
+	case seconds%60 == 0:
+		return fmt.Sprintf("%dm", seconds/60), nil
+	default:
+		return fmt.Sprintf("%ds", seconds), nil
+	}
+}
+
+// zabbixUnits 将 MIB 的 UNITS 转换为 Zabbix 能自动换算的单位
+func zabbixUnits(units string) string {
+	switch strings.ToLower(strings.TrimSpace(units)) {
+	case "":
+		return ""
+	case "octets", "bytes":
+		return "B"
+	case "bits":
+		return "b"
+	case "seconds":
+		return "s"
+	case "percent", "%":
+		return "%"
+	default:
+		return units
+	}
+}
+
+// zabbixUUID 由名称生成稳定的 UUIDv4 格式标识，重复导出时 Zabbix 能识别为同一个对象
+func zabbixUUID(parts ...string) string {
+	sum := md5.Sum([]byte(strings.Join(parts, "/")))
+	sum[6] = sum[6]&0x0f | 0x40
+	sum[8] = sum[8]&0x3f | 0x80
+	return hex.EncodeToString(sum[:])
+}
+
+// zabbixAlertTemplateIDs 设备模板配置中 alert_rule_templates 列出的告警规则模板
+func zabbixAlertTemplateIDs(config map[string]interface{}) []string {
+	var ids []string
+	switch list := config["alert_rule_templates"].(type) {
+	case []interface{}:
+		for _, item := range list {
+			if id, ok := item.(string); ok && id != "" {
+				ids = append(ids, id)
+			}
+		}
+	case []string:
+		ids = append(ids, list...)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// 加密后的密文格式：enc:v1:<密钥 ID>:<base64(nonce|密文)>
+const secretPrefix = "enc:v1:"
+
+// SecretMask 接口返回中替代敏感字段的占位符，更新时收到该值表示保持原值不变
+const SecretMask = "******"
+
+// 早期 HostService 硬编码的密钥，仅用于解密迁移前写入的主机密码
+var legacyHostKey = []byte("your-32-byte-encryption-key-here")
+
+var ErrSecretKeyNotConfigured = errors.New("secret key is not configured")
+
+var ErrInsecureSecretKey = errors.New("secret key is a publicly known default")
+
+// insecureSecretKeys 代码和部署文档中出现过的默认密钥，用它们加密等于明文存储
+var insecureSecretKeys = map[string]bool{
+	"your-secret-key":                                     true,
+	"your-super-secret-jwt-key":                           true,
+	"your_super_secret_jwt_key":                           true,
+	"your-super-secret-jwt-key-change-this-in-production": true,
+	"jwt_secret_key_2024_very_secure":                     true,
+	string(legacyHostKey):                                 true,
+}
+
+type secretKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+type secretKeyring struct {
+	current *secretKey
+	keys    map[string]*secretKey
+}
+
+var (
+	keyringMu sync.RWMutex
+	keyring   *secretKeyring
+)
+
+// InitSecrets 设置主密钥；previous 为逗号分隔的旧密钥，轮换期间仍可用于解密
+func InitSecrets(current, previous string) error {
+	if current == "" {
+		return ErrSecretKeyNotConfigured
+	}
+	if insecureSecretKeys[current] {
+		return ErrInsecureSecretKey
+	}
+
+	ring := &secretKeyring{keys: map[string]*secretKey{}}
+	key, err := newSecretKey(current)
+	if err != nil {
+		return err
+	}
+	ring.current = key
+	ring.keys[key.id] = key
+
+	for _, passphrase := range strings.Split(previous, ",") {
+		passphrase = strings.TrimSpace(passphrase)
+		if passphrase == "" {
+			continue
+		}
+		old, err := newSecretKey(passphrase)
+		if err != nil {
+			return err
+		}
+		if _, ok := ring.keys[old.id]; !ok {
+			ring.keys[old.id] = old
+		}
+	}
+
+	keyringMu.Lock()
+	keyring = ring
+	keyringMu.Unlock()
+	return nil
+}
+
+// newSecretKey 由任意长度的口令派生 AES-256 密钥，密钥 ID 取派生密钥摘要的前 8 位
+func newSecretKey(passphrase string) (*secretKey, error) {
+	derived := sha256.Sum256([]byte(passphrase))
+	aead, err := newAEAD(derived[:])
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(derived[:])
+	return &secretKey{id: hex.EncodeToString(digest[:])[:8], aead: aead}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func currentKeyring() (*secretKeyring, error) {
+	keyringMu.RLock()
+	defer keyringMu.RUnlock()
+	if keyring == nil {
+		return nil, ErrSecretKeyNotConfigured
+	}
+	return keyring, nil
+}
+
+// CurrentSecretKeyID 当前主密钥的 ID
+func CurrentSecretKeyID() string {
+	ring, err := currentKeyring()
+	if err != nil {
+		return ""
+	}
+	return ring.current.id
+}
+
+// SecretKeyIDs 当前可用于解密的全部密钥 ID，主密钥在前
+func SecretKeyIDs() []string {
+	ring, err := currentKeyring()
+	if err != nil {
+		return nil
+	}
+	ids := []string{ring.current.id}
+	for id := range ring.keys {
+		if id != ring.current.id {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// EncryptSecret 使用当前主密钥加密，空字符串原样返回
+func EncryptSecret(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	ring, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+
+	aead := ring.current.aead
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretPrefix + ring.current.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 的输出；没有前缀的值依次按旧版主机密码格式和明文处理，
+// 便于在加密上线前写入的数据被轮换前依然可读
+func DecryptSecret(value string) (string, error) {
+	if !IsEncryptedSecret(value) {
+		if plaintext, ok := decryptLegacySecret(value); ok {
+			return plaintext, nil
+		}
+		return value, nil
+	}
+
+	ring, err := currentKeyring()
+	if err != nil {
+		return "", err
+	}
+	id, payload, _ := strings.Cut(strings.TrimPrefix(value, secretPrefix), ":")
+	key, ok := ring.keys[id]
+	if !ok {
+		return "", fmt.Errorf("secret was encrypted with unknown key %s", id)
+	}
+	plaintext, err := openSecret(key.aead, payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret with key %s: %v", id, err)
+	}
+	return plaintext, nil
+}
+
+// IsEncryptedSecret 判断值是否为 EncryptSecret 的输出
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, secretPrefix)
+}
+
+// SecretKeyID 返回密文使用的密钥 ID，明文或旧格式返回空字符串
+func SecretKeyID(value string) string {
+	if !IsEncryptedSecret(value) {
+		return ""
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, secretPrefix), ":")
+	return id
+}
+
+// MaskSecret 非空的敏感字段在接口中统一返回占位符
+func MaskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return SecretMask
+}
+
+func decryptLegacySecret(value string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+	aead, err := newAEAD(legacyHostKey)
+	if err != nil {
+		return "", false
+	}
+	plaintext, err := openSecret(aead, value)
+	if err != nil {
+		return "", false
+	}
+	return plaintext, true
+}
+
+func openSecret(aead cipher.AEAD, payload string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, data[:nonceSize], data[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
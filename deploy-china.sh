@@ -381,6 +381,11 @@ HTTPS_PORT=443
 # JWT 密钥
 JWT_SECRET=$(openssl rand -base64 64 | tr -d "=+/" | cut -c1-50)
 
+# 凭据加密主密钥（必填，生成后不要随意修改，轮换方法见 DEPLOYMENT_GUIDE.md）
+SECRET_KEY=$(openssl rand -base64 32)
+# 轮换期间仍用于解密的旧密钥，逗号分隔
+SECRET_KEYS_OLD=
+
 # CORS 配置
 CORS_ORIGINS=http://localhost:3000,http://localhost
 
@@ -396,6 +401,19 @@ EOF
         log_success "环境配置文件生成完成"
     else
         log_info "环境配置文件已存在，跳过生成"
+        # 旧版本生成的 .env 没有 SECRET_KEY，补上新密钥，原来的 JWT_SECRET 作为旧密钥以便解密已有凭据
+        if ! grep -q '^SECRET_KEY=.\+' .env; then
+            local old_key
+            old_key=$(grep '^JWT_SECRET=' .env | cut -d= -f2-)
+            cat >> .env <<EOF
+
+# 凭据加密主密钥（必填，生成后不要随意修改，轮换方法见 DEPLOYMENT_GUIDE.md）
+SECRET_KEY=$(openssl rand -base64 32)
+# 轮换期间仍用于解密的旧密钥，逗号分隔
+SECRET_KEYS_OLD=${old_key:-your-super-secret-jwt-key}
+EOF
+            log_warning "已为现有 .env 生成 SECRET_KEY，启动后请调用 /api/v1/security/keys/rotate 重新加密已有凭据"
+        fi
     fi
 }
 